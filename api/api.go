@@ -0,0 +1,10 @@
+// Package api содержит описание HTTP API микроблога в формате OpenAPI 3.
+package api
+
+import _ "embed"
+
+// OpenAPI - документ OpenAPI 3 в JSON, пути заданы относительно /api/v1.
+// Соответствие документа таблице маршрутов проверяется тестом в internal/handlers.
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -12,18 +12,44 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Cere6rum/MicroBlog2/internal/blob"
+	"github.com/Cere6rum/MicroBlog2/internal/config"
+	"github.com/Cere6rum/MicroBlog2/internal/dump"
 	"github.com/Cere6rum/MicroBlog2/internal/handlers"
+	"github.com/Cere6rum/MicroBlog2/internal/health"
 	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
 	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/ratelimit"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
 	"github.com/Cere6rum/MicroBlog2/internal/service"
+	"github.com/Cere6rum/MicroBlog2/internal/tracing"
+	"github.com/Cere6rum/MicroBlog2/internal/trending"
+	"github.com/Cere6rum/MicroBlog2/internal/webhook"
 )
 
 func main() {
+	// 0. Загрузка конфигурации: по умолчанию < файл < окружение < флаги
+	cfg, opts, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if opts.PrintConfig {
+		if err := cfg.Write(os.Stdout, "yaml"); err != nil {
+			log.Fatalf("Ошибка вывода конфигурации: %v", err)
+		}
+		return
+	}
+
 	// 1. Инициализация логгера
-	appLogger, err := logger.NewLogger("app.log")
+	appLogger, err := logger.NewLogger(cfg.Log.File)
 	if err != nil {
 		log.Fatalf("Ошибка создания логгера: %v", err)
 	}
+	if err := appLogger.SetLevel(cfg.Log.Level); err != nil {
+		log.Fatalf("Ошибка настройки логгера: %v", err)
+	}
 	defer func() {
 		if appLogger != nil {
 			if err := appLogger.Close(); err != nil {
@@ -33,67 +59,254 @@ func main() {
 	}()
 
 	appLogger.Info("=== Запуск MicroBlog v1 ===")
+	if opts.ConfigPath != "" {
+		appLogger.Info(fmt.Sprintf("Конфигурация загружена из %s", opts.ConfigPath))
+	}
+
+	// 1.1. Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingSetup())
+	if err != nil {
+		log.Fatalf("Ошибка настройки трассировки: %v", err)
+	}
+	appLogger.Info(fmt.Sprintf("Трассировка настроена (экспортер: %s)", cfg.Tracing.Exporter))
+
+	// 2. Создание очереди лайков
+	likeQueue := queue.NewLikeQueue(cfg.Queue.BufferSize, cfg.Queue.Workers)
+	likeQueue.SetDeadLetterCapacity(cfg.Queue.DeadLetterSize)
+	appLogger.Info(fmt.Sprintf("Очередь лайков создана (буфер: %d, воркеры: %d)", cfg.Queue.BufferSize, cfg.Queue.Workers))
+
+	// 2.1. Очередь обновления поискового индекса
+	indexQueue := queue.NewIndexQueue(cfg.Search.BufferSize, cfg.Search.Workers)
+	indexQueue.SetDeadLetterCapacity(cfg.Search.DeadLetterSize)
 
-	// 2. Создание очереди лайков (буфер 100, 3 воркера)
-	likeQueue := queue.NewLikeQueue(100, 3)
-	appLogger.Info("Очередь лайков создана (буфер: 100, воркеры: 3)")
+	// 2.1.1. Очередь создания уведомлений
+	notificationQueue := queue.NewNotificationQueue(cfg.Notifications.BufferSize, cfg.Notifications.Workers)
+	notificationQueue.SetDeadLetterCapacity(cfg.Notifications.DeadLetterSize)
+
+	// 2.2. Подсчет популярных постов и хэштегов
+	trendingTracker, err := trending.NewTracker(cfg.Trending.Setup())
+	if err != nil {
+		log.Fatalf("Ошибка настройки подсчета популярного: %v", err)
+	}
+
+	// 2.3. Правила проверки ввода
+	policy, err := cfg.Validation.Setup()
+	if err != nil {
+		log.Fatalf("Ошибка настройки проверки ввода: %v", err)
+	}
+
+	// 2.4. Шина событий для GET /stream и GET /ws
+	var eventHub *pubsub.Hub
+	if cfg.Stream.Enabled || cfg.WebSocket.Enabled {
+		eventHub = pubsub.NewHub(cfg.Stream.ClientBuffer, cfg.Stream.ReplaySize)
+	}
+
+	// 2.5. Исходящие вебхуки
+	var (
+		webhookQueue  *queue.WebhookQueue
+		webhookSender *webhook.Sender
+	)
+	if cfg.Webhooks.Enabled {
+		webhookQueue = queue.NewWebhookQueue(cfg.Webhooks.BufferSize, cfg.Webhooks.Workers)
+		webhookQueue.SetDeadLetterCapacity(cfg.Webhooks.DeadLetterSize)
+		webhookSender = webhook.NewSender(cfg.Webhooks.Setup())
+	}
+
+	// 2.6. Хранилища; данные из архива microblog-dump загружаются до запуска сервиса
+	userRepo := repository.NewInMemoryUserRepo()
+	postRepo := repository.NewInMemoryPostRepo()
+	serviceOpts := []service.Option{
+		service.WithIndexQueue(indexQueue), service.WithTrending(trendingTracker), service.WithValidation(policy),
+		service.WithEventHub(eventHub), service.WithNotificationQueue(notificationQueue),
+		service.WithNameCooldown(cfg.Users.NameCooldown.Std()),
+		service.WithPendingMediaTTL(cfg.Media.PendingTTL.Std()),
+	}
+	if webhookQueue != nil {
+		serviceOpts = append(serviceOpts, service.WithWebhooks(webhookQueue, webhookSender))
+	}
+	if cfg.Storage.BlobDir != "" {
+		blobStore, err := blob.NewFSStore(cfg.Storage.BlobDir)
+		if err != nil {
+			log.Fatalf("Ошибка открытия хранилища файлов: %v", err)
+		}
+		serviceOpts = append(serviceOpts, service.WithBlobStore(blobStore))
+		appLogger.Info(fmt.Sprintf("Загруженные файлы хранятся в %s", cfg.Storage.BlobDir))
+	}
+	if cfg.Storage.ImportFile != "" {
+		report, err := importArchive(context.Background(), cfg.Storage, userRepo, postRepo, policy)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки данных: %v", err)
+		}
+		for _, w := range report.Warnings {
+			appLogger.Info("Импорт: " + w)
+		}
+		serviceOpts = append(serviceOpts, service.WithLastIDs(report.LastUserID, report.LastPostID))
+		appLogger.Info(fmt.Sprintf("Данные загружены из %s: пользователей %d, постов %d, лайков %d",
+			cfg.Storage.ImportFile, report.Users.Created, report.Posts.Created, report.Likes.Created))
+	}
 
 	// 3. Создание сервиса бизнес-логики
-	microBlogService := service.NewMicroBlogService(appLogger, likeQueue)
+	microBlogService := service.NewMicroBlogServiceWithRepos(appLogger, likeQueue, userRepo, postRepo, serviceOpts...)
 	appLogger.Info("Сервис MicroBlog инициализирован")
 
-	// 4. Запуск обработчиков очереди лайков
+	// 4. Запуск обработчиков очередей лайков и индексации
 	likeQueue.Start(microBlogService.ProcessLikeEvent)
 	appLogger.Info("Воркеры очереди лайков запущены")
+	indexQueue.Start(microBlogService.ProcessIndexEvent)
+	appLogger.Info(fmt.Sprintf("Воркеры очереди индексации запущены (буфер: %d, воркеры: %d)", cfg.Search.BufferSize, cfg.Search.Workers))
+	notificationQueue.Start(microBlogService.ProcessNotificationEvent)
+	appLogger.Info(fmt.Sprintf("Воркеры очереди уведомлений запущены (буфер: %d, воркеры: %d)",
+		cfg.Notifications.BufferSize, cfg.Notifications.Workers))
+	if webhookQueue != nil {
+		webhookQueue.Start(microBlogService.ProcessWebhookDelivery)
+		appLogger.Info(fmt.Sprintf("Воркеры очереди вебхуков запущены (буфер: %d, воркеры: %d)", cfg.Webhooks.BufferSize, cfg.Webhooks.Workers))
+	}
+	if cfg.Storage.ImportFile != "" {
+		// Загруженные посты попадают в поиск и индекс хэштегов через очередь индексации
+		if _, err := microBlogService.Reindex(context.Background()); err != nil {
+			log.Fatalf("Ошибка построения индексов: %v", err)
+		}
+	}
+
+	// 4.1. Автомасштабирование воркеров (метрики публикуются всегда, даже если оно выключено)
+	autoscaler, err := queue.NewAutoscaler(likeQueue, cfg.Queue.Autoscale.Setup(), appLogger)
+	if err != nil {
+		log.Fatalf("Ошибка настройки автомасштабирования: %v", err)
+	}
+	autoscaleCtx, stopAutoscale := context.WithCancel(context.Background())
+	defer stopAutoscale()
+	go autoscaler.Run(autoscaleCtx)
+	if cfg.Queue.Autoscale.Enabled {
+		appLogger.Info(fmt.Sprintf("Автомасштабирование очереди лайков включено (%d..%d воркеров)",
+			cfg.Queue.Autoscale.MinWorkers, cfg.Queue.Autoscale.MaxWorkers))
+	}
+
+	// 4.2. Удаление изображений, так и не прикрепленных к посту
+	expiryCtx, stopExpiry := context.WithCancel(context.Background())
+	defer stopExpiry()
+	go microBlogService.RunMediaExpiry(expiryCtx)
 
 	// 5. Создание HTTP-обработчиков
-	handler := handlers.NewMicroBlogHandler(microBlogService)
+	handlerOpts := []handlers.Option{
+		handlers.WithMaxBodyBytes(cfg.Server.MaxBodyBytes),
+		handlers.WithAdminToken(cfg.Server.AdminToken),
+		handlers.WithAdminQueue("likes", likeQueue),
+		handlers.WithAdminQueue("index", indexQueue),
+		handlers.WithAdminQueue("notifications", notificationQueue),
+		handlers.WithMaxAvatarBytes(cfg.Avatars.MaxBytes),
+		handlers.WithMaxMediaBytes(cfg.Media.MaxBytes),
+	}
+	if webhookQueue != nil {
+		handlerOpts = append(handlerOpts, handlers.WithAdminQueue("webhooks", webhookQueue))
+	}
+	if cfg.Stream.Enabled {
+		handlerOpts = append(handlerOpts, handlers.WithEventStream(eventHub, cfg.Stream.Heartbeat.Std()))
+	}
+	if cfg.WebSocket.Enabled {
+		handlerOpts = append(handlerOpts, socketOptions(cfg.WebSocket, eventHub)...)
+		if len(cfg.WebSocket.Tokens) == 0 {
+			appLogger.Info("websocket.tokens не заданы: соединения /ws анонимные, лайки через WebSocket недоступны")
+		}
+	}
+	handler := handlers.NewMicroBlogHandler(microBlogService, handlerOpts...)
+	apiMux := http.NewServeMux()
+	handler.RegisterRoutes(apiMux)
+
+	// 5.0. Ограничение частоты запросов к API (лимиты меняются по SIGHUP);
+	// проверки здоровья под ограничение не попадают
+	limiter := ratelimit.NewLimiter(cfg.RateLimit.Enabled, cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
 	mux := http.NewServeMux()
-	handler.RegisterRoutes(mux)
+	mux.Handle("/", limiter.Middleware(apiMux))
+
+	// 5.1. Проверки liveness/readiness для оркестратора
+	checker := health.NewChecker(cfg.Health.CheckTimeout.Std())
+	checker.Add("repository", microBlogService.Ping)
+	checker.Add("like_queue", func(context.Context) error { return likeQueue.Healthy() })
+	checker.Add("index_queue", func(context.Context) error { return indexQueue.Healthy() })
+	checker.Add("notification_queue", func(context.Context) error { return notificationQueue.Healthy() })
+	if webhookQueue != nil {
+		checker.Add("webhook_queue", func(context.Context) error { return webhookQueue.Healthy() })
+	}
+	checker.Add("logger", func(context.Context) error { return appLogger.Healthy() })
+	checker.RegisterRoutes(mux)
 	appLogger.Info("HTTP-маршруты зарегистрированы")
 
 	// 6. Запуск HTTP-сервера для профилирования на отдельном порту
-	go func() {
-		pprofAddr := ":6060"
-		appLogger.Info(fmt.Sprintf("Профилирование pprof доступно на http://localhost%s/debug/pprof/", pprofAddr))
-		if err := http.ListenAndServe(pprofAddr, nil); err != nil {
-			appLogger.Error(fmt.Sprintf("Ошибка запуска pprof сервера: %v", err))
-		}
-	}()
+	if cfg.Pprof.Enabled {
+		go func() {
+			pprofAddr := cfg.Pprof.Addr
+			appLogger.Info(fmt.Sprintf("Профилирование pprof доступно на http://localhost%s/debug/pprof/", pprofAddr))
+			if err := http.ListenAndServe(pprofAddr, nil); err != nil {
+				appLogger.Error(fmt.Sprintf("Ошибка запуска pprof сервера: %v", err))
+			}
+		}()
+	}
 
 	// 7. Создание основного HTTP-сервера
 	server := &http.Server{
-		Addr:         ":8080",
-		Handler:      mux,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:         cfg.Server.Addr,
+		Handler:      tracing.Middleware(mux),
+		ReadTimeout:  cfg.Server.ReadTimeout.Std(),
+		WriteTimeout: cfg.Server.WriteTimeout.Std(),
+		IdleTimeout:  cfg.Server.IdleTimeout.Std(),
+	}
+
+	// Shutdown не прерывает открытые потоки /stream и захваченные соединения /ws:
+	// закрываем их вместе с хабом
+	if eventHub != nil {
+		server.RegisterOnShutdown(eventHub.Close)
 	}
 
 	// 8. Запуск сервера в отдельной горутине
 	go func() {
-		appLogger.Info("HTTP-сервер запущен на :8080")
-		fmt.Println("MicroBlog v1 запущен на http://localhost:8080")
-		fmt.Println("Профилирование доступно на http://localhost:6060/debug/pprof/")
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("server error: %v", err)
+		appLogger.Info(fmt.Sprintf("HTTP-сервер запущен на %s", cfg.Server.Addr))
+		fmt.Printf("MicroBlog v1 запущен на http://localhost%s\n", cfg.Server.Addr)
+		if cfg.Pprof.Enabled {
+			fmt.Printf("Профилирование доступно на http://localhost%s/debug/pprof/\n", cfg.Pprof.Addr)
 		}
-		{
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			appLogger.Error(fmt.Sprintf("Ошибка запуска сервера: %v", err))
 			log.Fatalf("Ошибка запуска сервера: %v", err)
 		}
 	}()
 
-	// 9. Graceful Shutdown - ожидание сигнала завершения
+	// 9. Ожидание сигналов: SIGHUP перечитывает конфигурацию, SIGINT/SIGTERM завершают работу
+	reload := &reloader{
+		args:       os.Args[1:],
+		current:    cfg,
+		logger:     appLogger,
+		limiter:    limiter,
+		likeQueue:  likeQueue,
+		autoscaler: autoscaler,
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit
+
+wait:
+	for {
+		select {
+		case <-hup:
+			appLogger.Info("Получен SIGHUP, перечитываем конфигурацию...")
+			if err := reload.Reload(); err != nil {
+				appLogger.Error(fmt.Sprintf("Конфигурация не применена, действует прежняя: %v", err))
+			}
+		case <-quit:
+			break wait
+		}
+	}
 
 	appLogger.Info("Получен сигнал завершения, начинаем graceful shutdown...")
 	fmt.Println("Завершение работы...")
 
+	// Сначала проваливаем readiness и даем балансировщику время снять трафик,
+	// сервер при этом продолжает обслуживать уже пришедшие запросы
+	checker.SetShuttingDown()
+	time.Sleep(cfg.Health.DrainDelay.Std())
+
 	// 10. Контекст с таймаутом для завершения
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
 	defer cancel()
 
 	// 11. Остановка HTTP-сервера
@@ -103,9 +316,42 @@ func main() {
 		appLogger.Info("HTTP-сервер успешно остановлен")
 	}
 
+	// 11.1. Захваченные соединения /ws Shutdown не ждет: хаб уже закрыт,
+	// дожидаемся их обработчиков, чтобы они не писали в остановленные очереди
+	if err := handler.WaitSockets(ctx); err != nil {
+		appLogger.Error(fmt.Sprintf("Не дождались закрытия WebSocket-соединений: %v", err))
+	}
+
 	// 12. Остановка очереди лайков
+	stopAutoscale()
+	stopExpiry()
 	likeQueue.Stop()
 	appLogger.Info("Очередь лайков остановлена")
+	indexQueue.Stop()
+	appLogger.Info("Очередь индексации остановлена")
+	notificationQueue.Stop()
+	appLogger.Info("Очередь уведомлений остановлена")
+	if webhookQueue != nil {
+		// Текущие попытки ограничены таймаутом, доставки, ждущие повтора, отбрасываются
+		webhookQueue.Stop()
+		appLogger.Info("Очередь вебхуков остановлена")
+	}
+
+	// 12.1. Выгрузка данных: после остановки очередей посты больше не меняются
+	if cfg.Storage.ExportFile != "" {
+		if h, err := dump.WriteFile(ctx, cfg.Storage.ExportFile, userRepo, postRepo,
+			dump.WithLastIDs(microBlogService.LastIDs())); err != nil {
+			appLogger.Error(fmt.Sprintf("Ошибка выгрузки данных в %s: %v", cfg.Storage.ExportFile, err))
+		} else {
+			appLogger.Info(fmt.Sprintf("Данные выгружены в %s: пользователей %d, постов %d, лайков %d",
+				cfg.Storage.ExportFile, h.Users, h.Posts, h.Likes))
+		}
+	}
+
+	// 13. Выгрузка оставшихся спанов
+	if err := shutdownTracing(ctx); err != nil {
+		appLogger.Error(fmt.Sprintf("Ошибка при остановке трассировки: %v", err))
+	}
 
 	appLogger.Info("=== MicroBlog v1 успешно завершен ===")
 	fmt.Println("Приложение завершено")
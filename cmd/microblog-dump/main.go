@@ -0,0 +1,248 @@
+// Команда microblog-dump - офлайн-выгрузка и загрузка данных MicroBlog
+// в версионированном архиве NDJSON (формат пакета internal/dump).
+//
+// Хранилище (-store) - архив, с которым работает сервер: он загружает его
+// при запуске (storage.import_file) и выгружает при остановке
+// (storage.export_file). Команды работают с остановленным сервером.
+//
+//	microblog-dump export -store data.ndjson [-o out.ndjson]
+//	microblog-dump import -store data.ndjson [-conflict fail|skip|overwrite] [-dry-run] [-no-policy] seed.ndjson...
+//	microblog-dump validate [-no-policy] archive.ndjson...
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Cere6rum/MicroBlog2/internal/dump"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// errUsage - неверные аргументы; код выхода 2
+var errUsage = errors.New("неверные аргументы")
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run выполняет команду и возвращает код выхода
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var err error
+	if len(args) == 0 {
+		err = fmt.Errorf("%w: не указана команда", errUsage)
+	} else {
+		switch args[0] {
+		case "export":
+			err = exportCmd(ctx, args[1:], stdout, stderr)
+		case "import":
+			err = importCmd(ctx, args[1:], stdout, stderr)
+		case "validate":
+			err = validateCmd(ctx, args[1:], stdout, stderr)
+		case "-h", "-help", "--help", "help":
+			err = flag.ErrHelp
+		default:
+			err = fmt.Errorf("%w: неизвестная команда %q", errUsage, args[0])
+		}
+	}
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		usage(stderr)
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "microblog-dump: %v\n\n", err)
+		usage(stderr)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "microblog-dump: %v\n", err)
+		return 1
+	}
+}
+
+// store - репозитории, загруженные из архива-хранилища
+type store struct {
+	users      *repository.InMemoryUserRepo
+	posts      *repository.InMemoryPostRepo
+	lastUserID int
+	lastPostID int
+}
+
+// openStore загружает хранилище; отсутствующий файл - пустое хранилище
+func openStore(ctx context.Context, path string) (*store, error) {
+	s := &store{users: repository.NewInMemoryUserRepo(), posts: repository.NewInMemoryPostRepo()}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Хранилище записано самим сервером: имена и тексты уже прошли проверку
+	report, err := dump.Import(ctx, f, s.users, s.posts, dump.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("хранилище %s: %w", path, err)
+	}
+	s.lastUserID, s.lastPostID = report.LastUserID, report.LastPostID
+	return s, nil
+}
+
+// write выгружает хранилище в файл path
+func (s *store) write(ctx context.Context, path string) (*dump.Header, error) {
+	return dump.WriteFile(ctx, path, s.users, s.posts, dump.WithLastIDs(s.lastUserID, s.lastPostID))
+}
+
+func exportCmd(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	storePath := fs.String("store", "", "архив-хранилище")
+	out := fs.String("o", "-", "куда выгрузить архив (- стандартный вывод)")
+	if err := fs.Parse(args); err != nil {
+		return usageErr(err)
+	}
+	if *storePath == "" || fs.NArg() != 0 {
+		return fmt.Errorf("%w: export -store <файл> [-o <файл>]", errUsage)
+	}
+
+	s, err := openStore(ctx, *storePath)
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = dump.Export(ctx, stdout, s.users, s.posts, dump.WithLastIDs(s.lastUserID, s.lastPostID))
+		return err
+	}
+	h, err := s.write(ctx, *out)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Выгружено в %s: пользователей %d, постов %d, лайков %d\n", *out, h.Users, h.Posts, h.Likes)
+	return nil
+}
+
+func importCmd(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	storePath := fs.String("store", "", "архив-хранилище, в которое загружаются данные")
+	conflict := fs.String("conflict", string(dump.ConflictFail), "при совпадении записей: fail, skip или overwrite")
+	dryRun := fs.Bool("dry-run", false, "только проверить архивы и конфликты, ничего не записывая")
+	noPolicy := fs.Bool("no-policy", false, "не проверять имена и тексты правилами validation")
+	if err := fs.Parse(args); err != nil {
+		return usageErr(err)
+	}
+	if *storePath == "" || fs.NArg() == 0 {
+		return fmt.Errorf("%w: import -store <файл> [флаги] <архив>...", errUsage)
+	}
+	policy, err := dump.ParseConflictPolicy(*conflict)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	s, err := openStore(ctx, *storePath)
+	if err != nil {
+		return err
+	}
+	opts := dump.Options{Conflict: policy, DryRun: *dryRun}
+	if !*noPolicy {
+		opts.Policy = validation.DefaultPolicy()
+	}
+
+	reports := make(map[string]*dump.Report, fs.NArg())
+	for _, path := range fs.Args() {
+		report, err := importFile(ctx, path, s, opts)
+		if report != nil {
+			reports[path] = report
+		}
+		if err != nil {
+			_ = printReports(stdout, reports)
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		s.lastUserID = max(s.lastUserID, report.LastUserID)
+		s.lastPostID = max(s.lastPostID, report.LastPostID)
+	}
+	if err := printReports(stdout, reports); err != nil {
+		return err
+	}
+	if *dryRun {
+		return nil
+	}
+	_, err = s.write(ctx, *storePath)
+	return err
+}
+
+func validateCmd(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	noPolicy := fs.Bool("no-policy", false, "не проверять имена и тексты правилами validation")
+	if err := fs.Parse(args); err != nil {
+		return usageErr(err)
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("%w: validate <архив>...", errUsage)
+	}
+
+	opts := dump.Options{DryRun: true}
+	if !*noPolicy {
+		opts.Policy = validation.DefaultPolicy()
+	}
+	reports := make(map[string]*dump.Report, fs.NArg())
+	var errs []error
+	for _, path := range fs.Args() {
+		// Каждый архив проверяется сам по себе, без учета остальных
+		s := &store{users: repository.NewInMemoryUserRepo(), posts: repository.NewInMemoryPostRepo()}
+		report, err := importFile(ctx, path, s, opts)
+		if report != nil {
+			reports[path] = report
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	if err := printReports(stdout, reports); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+func importFile(ctx context.Context, path string, s *store, opts dump.Options) (*dump.Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return dump.Import(ctx, f, s.users, s.posts, opts)
+}
+
+func printReports(w io.Writer, reports map[string]*dump.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+func usageErr(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", errUsage, err)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Использование:
+  microblog-dump export -store <файл> [-o <файл>]
+  microblog-dump import -store <файл> [-conflict fail|skip|overwrite] [-dry-run] [-no-policy] <архив>...
+  microblog-dump validate [-no-policy] <архив>...
+
+Хранилище - архив, который сервер загружает при запуске (storage.import_file)
+и выгружает при остановке (storage.export_file). Импорт сохраняет ID записей;
+сервер продолжает счетчики ID после наибольших загруженных.
+`)
+}
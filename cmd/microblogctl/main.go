@@ -0,0 +1,465 @@
+// Команда microblogctl - административный клиент MicroBlog.
+//
+//	microblogctl [-profile имя] [-server URL] [-o table|json|yaml] <команда> ...
+//
+// Команды:
+//
+//	user create <имя>                      регистрация пользователя
+//	user get <имя>                         пользователь по имени
+//	user list                              все пользователи
+//	post create -user <имя> [-reply-to ID] [-quote ID] <текст>
+//	post list                              лента
+//	post delete -user <имя> <ID>           удаление поста (только автор)
+//	like -user <имя> <ID>                  лайк поста
+//	queue stats                            состояние очередей
+//	dlq replay <очередь>                   повтор недоставленных событий
+//	profile list | use <имя> | set <имя> -server URL [-token T] [-timeout D]
+//
+// Профили хранятся в $MICROBLOGCTL_CONFIG или ~/.config/microblogctl/config.yaml.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/pkg/client"
+)
+
+// errUsage - неверные аргументы; код выхода 2
+var errUsage = errors.New("неверные аргументы")
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// app - разобранные глобальные флаги и подключение к серверу
+type app struct {
+	profiles *Profiles
+	api      *client.Client
+	out      printer
+	stderr   io.Writer
+}
+
+// run выполняет команду и возвращает код выхода
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("microblogctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profileName := fs.String("profile", os.Getenv("MICROBLOGCTL_PROFILE"), "профиль из файла профилей")
+	server := fs.String("server", "", "адрес сервера (приоритетнее профиля)")
+	token := fs.String("token", "", "токен администратора (приоритетнее профиля)")
+	format := fs.String("o", formatTable, "формат вывода: table, json, yaml")
+	timeout := fs.Duration("timeout", 0, "таймаут запроса (0 - из профиля или 30s)")
+	fs.Usage = func() { usage(stderr) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	a := &app{out: printer{w: stdout, format: *format}, stderr: stderr}
+	err := a.init(*profileName, *server, *token, *timeout)
+	if err == nil {
+		err = a.dispatch(ctx, fs.Args())
+	}
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "microblogctl: %v\n\n", err)
+		}
+		usage(stderr)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "microblogctl: %v\n", err)
+		return 1
+	}
+}
+
+// init загружает профили и создает клиент API
+func (a *app) init(profileName, server, token string, timeout time.Duration) error {
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	if a.profiles, err = loadProfiles(path); err != nil {
+		return err
+	}
+	prof, err := a.profiles.Resolve(profileName)
+	if err != nil {
+		return err
+	}
+	if server != "" {
+		prof.Server = server
+	}
+	if token != "" {
+		prof.AdminToken = token
+	}
+	if timeout > 0 {
+		prof.Timeout = timeout
+	}
+	if prof.Timeout <= 0 {
+		prof.Timeout = 30 * time.Second
+	}
+
+	a.api = client.New(prof.Server,
+		client.WithHTTPClient(&http.Client{Timeout: prof.Timeout}),
+		client.WithAdminToken(prof.AdminToken))
+	return nil
+}
+
+// dispatch выбирает команду по первым аргументам
+func (a *app) dispatch(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: не указана команда", errUsage)
+	}
+	cmd, rest := args[0], args[1:]
+	if cmd == "like" {
+		return a.like(ctx, rest)
+	}
+	if len(rest) == 0 {
+		return fmt.Errorf("%w: не указана подкоманда %s", errUsage, cmd)
+	}
+	sub, rest := rest[0], rest[1:]
+
+	switch cmd + " " + sub {
+	case "user create":
+		return a.userCreate(ctx, rest)
+	case "user get":
+		return a.userGet(ctx, rest)
+	case "user list":
+		return a.userList(ctx, rest)
+	case "post create":
+		return a.postCreate(ctx, rest)
+	case "post list":
+		return a.postList(ctx, rest)
+	case "post delete":
+		return a.postDelete(ctx, rest)
+	case "queue stats":
+		return a.queueStats(ctx, rest)
+	case "dlq replay":
+		return a.dlqReplay(ctx, rest)
+	case "profile list":
+		return a.profileList(rest)
+	case "profile use":
+		return a.profileUse(rest)
+	case "profile set":
+		return a.profileSet(rest)
+	}
+	return fmt.Errorf("%w: неизвестная команда %q", errUsage, cmd+" "+sub)
+}
+
+func (a *app) userCreate(ctx context.Context, args []string) error {
+	name, err := oneArg(args, "имя пользователя")
+	if err != nil {
+		return err
+	}
+	user, err := a.api.RegisterUser(ctx, name)
+	if err != nil {
+		return err
+	}
+	return a.out.print(user, func() table { return usersTable(user) })
+}
+
+func (a *app) userGet(ctx context.Context, args []string) error {
+	name, err := oneArg(args, "имя пользователя")
+	if err != nil {
+		return err
+	}
+	user, err := a.api.GetUser(ctx, name)
+	if err != nil {
+		return err
+	}
+	return a.out.print(user, func() table { return usersTable(user.User) })
+}
+
+func (a *app) userList(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: user list не принимает аргументов", errUsage)
+	}
+	// Сервер отдает список страницами: собираем все
+	var users []*client.User
+	for {
+		page, err := a.api.ListUsers(ctx, client.ListUsersOptions{Offset: len(users)})
+		if err != nil {
+			return err
+		}
+		users = append(users, page.Users...)
+		if len(page.Users) == 0 || len(users) >= page.Total {
+			break
+		}
+	}
+	return a.out.print(users, func() table { return usersTable(users...) })
+}
+
+func (a *app) postCreate(ctx context.Context, args []string) error {
+	fs := subFlags("post create", a.stderr)
+	user := fs.String("user", "", "автор поста")
+	replyTo := fs.Int("reply-to", 0, "ID поста, на который дается ответ")
+	quote := fs.Int("quote", 0, "ID цитируемого поста")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if *user == "" || fs.NArg() == 0 {
+		return fmt.Errorf("%w: post create -user <имя> <текст>", errUsage)
+	}
+
+	post, err := a.api.CreatePost(ctx, client.CreatePostRequest{
+		Username:  *user,
+		Content:   strings.Join(fs.Args(), " "),
+		ReplyToID: *replyTo,
+		QuoteOfID: *quote,
+	})
+	if err != nil {
+		return err
+	}
+	return a.out.print(post, func() table { return postsTable(post) })
+}
+
+func (a *app) postList(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: post list не принимает аргументов", errUsage)
+	}
+	views, err := a.api.ListPosts(ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.print(views, func() table {
+		posts := make([]*client.Post, len(views))
+		for i, v := range views {
+			posts[i] = v.Post
+		}
+		return postsTable(posts...)
+	})
+}
+
+func (a *app) postDelete(ctx context.Context, args []string) error {
+	fs := subFlags("post delete", a.stderr)
+	user := fs.String("user", "", "автор поста")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	id, err := postID(fs.Args())
+	if err != nil || *user == "" {
+		return fmt.Errorf("%w: post delete -user <имя> <ID>", errUsage)
+	}
+	if err := a.api.DeletePost(ctx, id, *user); err != nil {
+		return err
+	}
+	return a.out.print(map[string]any{"deleted": id}, func() table {
+		return table{header: []string{"DELETED"}, rows: [][]string{{strconv.Itoa(id)}}}
+	})
+}
+
+func (a *app) like(ctx context.Context, args []string) error {
+	fs := subFlags("like", a.stderr)
+	user := fs.String("user", "", "кто ставит лайк")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	id, err := postID(fs.Args())
+	if err != nil || *user == "" {
+		return fmt.Errorf("%w: like -user <имя> <ID>", errUsage)
+	}
+	if err := a.api.LikePost(ctx, id, *user); err != nil {
+		return err
+	}
+	// Лайк обрабатывается асинхронно: сервер только поставил его в очередь
+	return a.out.print(map[string]any{"post_id": id, "username": *user, "queued": true}, func() table {
+		return table{header: []string{"POST", "USER", "STATUS"}, rows: [][]string{{strconv.Itoa(id), *user, "в очереди"}}}
+	})
+}
+
+func (a *app) queueStats(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: queue stats не принимает аргументов", errUsage)
+	}
+	stats, err := a.api.QueueStats(ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.print(stats, func() table {
+		t := table{header: []string{"QUEUE", "PENDING", "CAPACITY", "WORKERS", "RUNNING", "PROCESSED", "AVG_LATENCY", "DLQ", "DLQ_DROPPED"}}
+		for _, name := range sortedKeys(stats) {
+			st := stats[name]
+			t.add(name, st.Pending, st.Capacity, st.Workers, st.Running, st.Processed, st.AvgLatency, st.DeadLetters, st.DeadDropped)
+		}
+		return t
+	})
+}
+
+func (a *app) dlqReplay(ctx context.Context, args []string) error {
+	name, err := oneArg(args, "имя очереди")
+	if err != nil {
+		return err
+	}
+	n, err := a.api.ReplayDeadLetters(ctx, name)
+	if err != nil {
+		return err
+	}
+	return a.out.print(map[string]any{"queue": name, "replayed": n}, func() table {
+		return table{header: []string{"QUEUE", "REPLAYED"}, rows: [][]string{{name, strconv.Itoa(n)}}}
+	})
+}
+
+func (a *app) profileList(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: profile list не принимает аргументов", errUsage)
+	}
+	type row struct {
+		Name    string `json:"name"`
+		Server  string `json:"server"`
+		Current bool   `json:"current"`
+	}
+	rows := make([]row, 0, len(a.profiles.Profiles))
+	for _, name := range a.profiles.Names() {
+		rows = append(rows, row{name, a.profiles.Profiles[name].Server, name == a.profiles.Current})
+	}
+	return a.out.print(rows, func() table {
+		t := table{header: []string{"CURRENT", "NAME", "SERVER"}}
+		for _, r := range rows {
+			mark := ""
+			if r.Current {
+				mark = "*"
+			}
+			t.add(mark, r.Name, r.Server)
+		}
+		return t
+	})
+}
+
+func (a *app) profileUse(args []string) error {
+	name, err := oneArg(args, "имя профиля")
+	if err != nil {
+		return err
+	}
+	if _, ok := a.profiles.Profiles[name]; !ok {
+		return fmt.Errorf("профиль %q не найден", name)
+	}
+	a.profiles.Current = name
+	return a.profiles.Save()
+}
+
+func (a *app) profileSet(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("%w: profile set <имя> -server URL [-token T] [-timeout D]", errUsage)
+	}
+	name := args[0]
+	fs := subFlags("profile set", a.stderr)
+	server := fs.String("server", "", "адрес сервера")
+	token := fs.String("token", "", "токен администратора")
+	timeout := fs.Duration("timeout", 0, "таймаут запроса")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	prof, ok := a.profiles.Profiles[name]
+	if !ok {
+		prof = &Profile{}
+		a.profiles.Profiles[name] = prof
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			prof.Server = *server
+		case "token":
+			prof.AdminToken = *token
+		case "timeout":
+			prof.Timeout = *timeout
+		}
+	})
+	if prof.Server == "" {
+		return fmt.Errorf("%w: для нового профиля нужен -server", errUsage)
+	}
+	if a.profiles.Current == "" {
+		a.profiles.Current = name
+	}
+	return a.profiles.Save()
+}
+
+func usersTable(users ...*client.User) table {
+	t := table{header: []string{"ID", "USERNAME", "CREATED"}}
+	for _, u := range users {
+		t.add(u.ID, u.Username, u.CreatedAt.Format(time.RFC3339))
+	}
+	return t
+}
+
+func postsTable(posts ...*client.Post) table {
+	t := table{header: []string{"ID", "KIND", "AUTHOR", "LIKES", "CREATED", "CONTENT"}}
+	for _, p := range posts {
+		content := p.Content
+		if p.OriginalID != 0 && content == "" {
+			content = fmt.Sprintf("→ #%d", p.OriginalID)
+		}
+		t.add(p.ID, p.Kind, p.Author, len(p.Likes), p.CreatedAt.Format(time.RFC3339), short(content, 60))
+	}
+	return t
+}
+
+func subFlags(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+func oneArg(args []string, what string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", fmt.Errorf("%w: ожидается %s", errUsage, what)
+	}
+	return args[0], nil
+}
+
+func postID(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errUsage
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("неверный ID поста %q", args[0])
+	}
+	return id, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Использование: microblogctl [флаги] <команда> ...
+
+Флаги:
+  -profile имя    профиль из файла профилей (или MICROBLOGCTL_PROFILE)
+  -server URL     адрес сервера, приоритетнее профиля
+  -token T        токен администратора для queue и dlq
+  -o формат       table (по умолчанию), json или yaml
+  -timeout D      таймаут запроса
+
+Команды:
+  user create <имя>
+  user get <имя>
+  user list
+  post create -user <имя> [-reply-to ID] [-quote ID] <текст>
+  post list
+  post delete -user <имя> <ID>
+  like -user <имя> <ID>
+  queue stats
+  dlq replay <likes|index>
+  profile list
+  profile use <имя>
+  profile set <имя> -server URL [-token T] [-timeout D]
+`)
+}
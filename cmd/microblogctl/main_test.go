@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/internal/handlers"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestCommands проверяет команды против настоящего сервера и профили
+func TestCommands(t *testing.T) {
+	log, _ := logger.NewLogger(filepath.Join(t.TempDir(), "test.log"))
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	likeQueue := queue.NewLikeQueue(10, 1)
+	svc := service.NewMicroBlogService(log, likeQueue)
+	likeQueue.Start(svc.ProcessLikeEvent)
+	defer likeQueue.Stop()
+
+	mux := http.NewServeMux()
+	handlers.NewMicroBlogHandler(svc,
+		handlers.WithAdminToken("secret"),
+		handlers.WithAdminQueue("likes", likeQueue)).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv("MICROBLOGCTL_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("MICROBLOGCTL_PROFILE", "")
+
+	ctl := func(args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), args, &stdout, &stderr)
+		return stdout.String(), stderr.String(), code
+	}
+
+	// Тест 1: профиль становится текущим и используется по умолчанию
+	if _, errOut, code := ctl("profile", "set", "test", "-server", srv.URL, "-token", "secret"); code != 0 {
+		t.Fatalf("profile set: код %d: %s", code, errOut)
+	}
+	if out, _, _ := ctl("profile", "list"); !strings.Contains(out, "*") || !strings.Contains(out, srv.URL) {
+		t.Errorf("Ожидали текущий профиль test в списке, получили:\n%s", out)
+	}
+
+	// Тест 2: создание пользователя и поста, табличный и JSON-вывод
+	if out, errOut, code := ctl("user", "create", "alice"); code != 0 || !strings.Contains(out, "alice") {
+		t.Fatalf("user create: код %d: %s%s", code, out, errOut)
+	}
+	out, errOut, code := ctl("-o", "json", "post", "create", "-user", "alice", "привет", "#go")
+	if code != 0 {
+		t.Fatalf("post create: код %d: %s", code, errOut)
+	}
+	var post struct {
+		ID      int    `json:"id"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(out), &post); err != nil || post.Content != "привет #go" {
+		t.Fatalf("Неверный JSON поста %q: %v", out, err)
+	}
+
+	// Тест 3: YAML с именами полей как в API
+	if out, _, _ := ctl("-o", "yaml", "user", "get", "alice"); !strings.Contains(out, "username: alice") {
+		t.Errorf("Ожидали YAML с username: alice, получили:\n%s", out)
+	}
+
+	// Тест 4: ошибка сервера дает код 1, неверные аргументы - код 2
+	if _, errOut, code := ctl("user", "create", "alice"); code != 1 || !strings.Contains(errOut, "уже существует") {
+		t.Errorf("Ожидали код 1 и сообщение о дубликате, получили %d: %s", code, errOut)
+	}
+	if _, _, code := ctl("post", "delete", "abc"); code != 2 {
+		t.Errorf("Ожидали код 2 для неверных аргументов, получили %d", code)
+	}
+
+	// Тест 5: административные команды с токеном из профиля и без него
+	if out, errOut, code := ctl("queue", "stats"); code != 0 || !strings.Contains(out, "likes") {
+		t.Errorf("queue stats: код %d: %s%s", code, out, errOut)
+	}
+	if _, errOut, code := ctl("-token", "wrong", "queue", "stats"); code != 1 || !strings.Contains(errOut, "401") {
+		t.Errorf("Ожидали 401 с неверным токеном, получили %d: %s", code, errOut)
+	}
+	if out, errOut, code := ctl("dlq", "replay", "likes"); code != 0 || !strings.Contains(out, "0") {
+		t.Errorf("dlq replay: код %d: %s%s", code, out, errOut)
+	}
+
+	// Тест 6: удаление поста
+	if _, errOut, code := ctl("post", "delete", "-user", "alice", "1"); code != 0 {
+		t.Errorf("post delete: код %d: %s", code, errOut)
+	}
+	if out, _, _ := ctl("-o", "json", "post", "list"); strings.TrimSpace(out) != "[]" {
+		t.Errorf("Ожидали пустую ленту после удаления, получили %s", out)
+	}
+}
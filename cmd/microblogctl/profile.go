@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultServer - адрес сервера, если профиль не задан
+const defaultServer = "http://localhost:8080"
+
+// Profile - параметры подключения к одному серверу
+type Profile struct {
+	Server     string        `yaml:"server"`
+	AdminToken string        `yaml:"admin_token,omitempty"`
+	Timeout    time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Profiles - файл профилей:
+//
+//	current: prod
+//	profiles:
+//	  local: {server: "http://localhost:8080"}
+//	  prod:  {server: "https://blog.example.com", admin_token: "..."}
+type Profiles struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+
+	path string
+}
+
+// profilesPath возвращает путь к файлу профилей: MICROBLOGCTL_CONFIG
+// или microblogctl/config.yaml в каталоге настроек пользователя
+func profilesPath() (string, error) {
+	if p := os.Getenv("MICROBLOGCTL_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("не удалось определить каталог настроек: %w", err)
+	}
+	return filepath.Join(dir, "microblogctl", "config.yaml"), nil
+}
+
+// loadProfiles читает файл профилей; отсутствующий файл - пустой набор
+func loadProfiles(path string) (*Profiles, error) {
+	p := &Profiles{Profiles: make(map[string]*Profile), path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Profiles == nil {
+		p.Profiles = make(map[string]*Profile)
+	}
+	return p, nil
+}
+
+// Save записывает профили; файл доступен только владельцу, так как содержит токены
+func (p *Profiles) Save() error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, data, 0o600)
+}
+
+// Resolve возвращает профиль name (пустое имя - текущий профиль).
+// Без файла профилей используется сервер по умолчанию.
+func (p *Profiles) Resolve(name string) (*Profile, error) {
+	if name == "" {
+		name = p.Current
+	}
+	if name == "" {
+		return &Profile{Server: defaultServer}, nil
+	}
+	prof, ok := p.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("профиль %q не найден в %s", name, p.path)
+	}
+	out := *prof
+	if out.Server == "" {
+		out.Server = defaultServer
+	}
+	return &out, nil
+}
+
+// Names возвращает имена профилей по алфавиту
+func (p *Profiles) Names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
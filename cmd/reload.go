@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Cere6rum/MicroBlog2/internal/config"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/ratelimit"
+)
+
+// reloader перечитывает конфигурацию по SIGHUP и применяет изменяемые на лету настройки
+type reloader struct {
+	mu        sync.Mutex
+	args      []string
+	current   *config.Config
+	logger    *logger.Logger
+	limiter   *ratelimit.Limiter
+	likeQueue *queue.LikeQueue
+	// autoscaler может менять количество воркеров сам; queue.workers из
+	// конфигурации задает стартовое значение, которое он затем подстроит
+	autoscaler *queue.Autoscaler
+}
+
+// Reload загружает и валидирует новую конфигурацию. При любой ошибке
+// продолжает действовать прежняя конфигурация целиком.
+func (r *reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, _, err := config.Load(r.args)
+	if err != nil {
+		return err
+	}
+
+	changes := config.Diff(r.current, next)
+	if len(changes) == 0 {
+		r.logger.Info("Перезагрузка конфигурации: изменений нет")
+		return nil
+	}
+
+	var applied, skipped []string
+	for _, c := range changes {
+		if c.Reloadable {
+			applied = append(applied, c.String())
+		} else {
+			skipped = append(skipped, c.Key)
+		}
+	}
+
+	// Шаги, которые могут завершиться ошибкой, выполняем первыми,
+	// чтобы не оставить настройки примененными наполовину
+	if err := r.autoscaler.SetConfig(next.Queue.Autoscale.Setup()); err != nil {
+		return fmt.Errorf("некорректные параметры автомасштабирования: %w", err)
+	}
+	if next.Queue.Workers != r.current.Queue.Workers {
+		if err := r.likeQueue.Resize(next.Queue.Workers); err != nil {
+			_ = r.autoscaler.SetConfig(r.current.Queue.Autoscale.Setup())
+			return fmt.Errorf("не удалось изменить количество воркеров: %w", err)
+		}
+	}
+	if err := r.logger.SetLevel(next.Log.Level); err != nil {
+		return err
+	}
+	r.limiter.SetLimits(next.RateLimit.Enabled, next.RateLimit.RequestsPerSecond, next.RateLimit.Burst)
+
+	// Запоминаем только то, что действительно применили
+	updated := *r.current
+	updated.Log.Level = next.Log.Level
+	updated.RateLimit = next.RateLimit
+	updated.Queue.Workers = next.Queue.Workers
+	updated.Queue.Autoscale = next.Queue.Autoscale
+	r.current = &updated
+
+	if len(applied) > 0 {
+		r.logger.Info("Конфигурация перезагружена: " + strings.Join(applied, "; "))
+	}
+	if len(skipped) > 0 {
+		r.logger.Error("Изменения требуют перезапуска и не применены: " + strings.Join(skipped, ", "))
+	}
+	return nil
+}
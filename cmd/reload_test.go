@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/internal/config"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/ratelimit"
+)
+
+// newTestReloader собирает reloader поверх файла конфигурации во временном каталоге
+func newTestReloader(t *testing.T, yaml string) (*reloader, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("Ошибка записи конфигурации: %v", err)
+	}
+	args := []string{"-config", path}
+	cfg, _, err := config.Load(args)
+	if err != nil {
+		t.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+
+	log, err := logger.NewLogger(filepath.Join(dir, "test.log"))
+	if err != nil {
+		t.Fatalf("Ошибка создания логгера: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	})
+	if err := log.SetLevel(cfg.Log.Level); err != nil {
+		t.Fatalf("Ошибка установки уровня логирования: %v", err)
+	}
+
+	likeQueue := queue.NewLikeQueue(10, cfg.Queue.Workers)
+	t.Cleanup(likeQueue.Stop)
+	autoscaler, err := queue.NewAutoscaler(likeQueue, cfg.Queue.Autoscale.Setup(), log)
+	if err != nil {
+		t.Fatalf("Ошибка создания автомасштабирования: %v", err)
+	}
+
+	return &reloader{
+		args:       args,
+		current:    cfg,
+		logger:     log,
+		limiter:    ratelimit.NewLimiter(cfg.RateLimit.Enabled, cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst),
+		likeQueue:  likeQueue,
+		autoscaler: autoscaler,
+	}, path
+}
+
+// TestReload проверяет применение новой конфигурации и отказ от некорректной
+func TestReload(t *testing.T) {
+	r, path := newTestReloader(t, "log:\n  level: debug\nratelimit:\n  enabled: true\n  requests_per_second: 100\n  burst: 100\n")
+
+	// Тест 1: изменяемые на лету настройки применяются
+	next := "log:\n  level: error\nratelimit:\n  enabled: true\n  requests_per_second: 1\n  burst: 1\n"
+	if err := os.WriteFile(path, []byte(next), 0o644); err != nil {
+		t.Fatalf("Ошибка записи конфигурации: %v", err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Ошибка перезагрузки: %v", err)
+	}
+	if r.logger.Level() != "ERROR" || r.current.Log.Level != "error" {
+		t.Errorf("Ожидали уровень ERROR, получили %s/%s", r.logger.Level(), r.current.Log.Level)
+	}
+	if r.current.RateLimit.Burst != 1 {
+		t.Errorf("Ожидали burst 1, получили %d", r.current.RateLimit.Burst)
+	}
+	r.limiter.Allow("client")
+	if ok, _ := r.limiter.Allow("client"); ok {
+		t.Error("Новый лимит должен применяться к ограничителю")
+	}
+
+	// Тест 2: некорректная конфигурация отклоняется целиком
+	bad := "log:\n  level: bogus\nratelimit:\n  enabled: true\n  requests_per_second: 50\n  burst: 50\n"
+	if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+		t.Fatalf("Ошибка записи конфигурации: %v", err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("Ожидали ошибку для некорректного уровня логирования")
+	}
+	if r.logger.Level() != "ERROR" || r.current.Log.Level != "error" || r.current.RateLimit.Burst != 1 {
+		t.Errorf("Прежняя конфигурация должна остаться в силе, получили %+v", r.current)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/Cere6rum/MicroBlog2/internal/config"
+	"github.com/Cere6rum/MicroBlog2/internal/handlers"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+)
+
+// socketOptions собирает параметры GET /ws из конфигурации. Пользователь
+// соединения определяется по токенам websocket.tokens; без них все
+// соединения анонимные и лайки через WebSocket недоступны.
+func socketOptions(cfg config.WebSocketConfig, hub *pubsub.Hub) []handlers.Option {
+	return []handlers.Option{
+		handlers.WithWebSocket(hub, cfg.Heartbeat.Std()),
+		handlers.WithSocketOrigins(cfg.AllowedOrigins...),
+		handlers.WithSocketAuth(handlers.TokenSocketAuth(cfg.UserTokens())),
+	}
+}
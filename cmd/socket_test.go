@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/Cere6rum/MicroBlog2/internal/config"
+	"github.com/Cere6rum/MicroBlog2/internal/handlers"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestSocketOptions проверяет, что токены из websocket.tokens определяют
+// пользователя соединения и лайк через WebSocket проходит
+func TestSocketOptions(t *testing.T) {
+	cfg, _, err := config.Load([]string{"-websocket-tokens", "bob:bob-token"})
+	if err != nil {
+		t.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+
+	log, err := logger.NewLogger(filepath.Join(t.TempDir(), "test.log"))
+	if err != nil {
+		t.Fatalf("Ошибка создания логгера: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	})
+	ctx := context.Background()
+	hub := pubsub.NewHub(10, 0)
+	likeQueue := queue.NewLikeQueue(10, 1)
+	svc := service.NewMicroBlogService(log, likeQueue, service.WithEventHub(hub))
+	likeQueue.Start(svc.ProcessLikeEvent)
+	defer likeQueue.Stop()
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := svc.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации: %v", err)
+		}
+	}
+	post, err := svc.CreatePost(ctx, "alice", "Пост")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	handlers.NewMicroBlogHandler(svc, socketOptions(cfg.WebSocket, hub)...).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer hub.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + handlers.APIPrefix + "/ws"
+
+	dial := func(query, token string) (*websocket.Conn, error) {
+		wsConfig, err := websocket.NewConfig(wsURL+query, srv.URL)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			wsConfig.Header.Set("Authorization", "Bearer "+token)
+		}
+		return websocket.DialConfig(wsConfig)
+	}
+	type message struct {
+		Type   string `json:"type"`
+		ID     string `json:"id"`
+		User   string `json:"user"`
+		Status int    `json:"status"`
+	}
+	recv := func(ws *websocket.Conn) message {
+		for {
+			var msg message
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				t.Fatalf("Ошибка чтения: %v", err)
+			}
+			if msg.Type != "ping" && msg.Type != "event" {
+				return msg
+			}
+		}
+	}
+
+	// Тест 1: неизвестный токен отклоняется
+	if _, err := dial("", "wrong"); err == nil {
+		t.Error("Ожидали отказ для неизвестного токена")
+	}
+
+	// Тест 2: токен в заголовке и в параметре access_token определяет пользователя,
+	// лайк от его имени принимается
+	for _, tt := range []struct{ name, query, token string }{
+		{"заголовок", "", "bob-token"},
+		{"параметр", "?access_token=bob-token", ""},
+	} {
+		ws, err := dial(tt.query, tt.token)
+		if err != nil {
+			t.Fatalf("%s: ошибка подключения: %v", tt.name, err)
+		}
+		_ = ws.SetDeadline(time.Now().Add(5 * time.Second))
+		if msg := recv(ws); msg.Type != "hello" || msg.User != "bob" {
+			t.Errorf("%s: ожидали hello для bob, получили %+v", tt.name, msg)
+		}
+		if err := websocket.JSON.Send(ws, map[string]any{"type": "like", "id": "1", "post_id": post.ID}); err != nil {
+			t.Fatalf("%s: ошибка отправки: %v", tt.name, err)
+		}
+		if msg := recv(ws); msg.Type != "ack" || msg.ID != "1" {
+			t.Errorf("%s: ожидали ack лайка, получили %+v", tt.name, msg)
+		}
+		ws.Close()
+	}
+
+	// Тест 3: без токена соединение анонимное и лайк отклоняется
+	ws, err := dial("", "")
+	if err != nil {
+		t.Fatalf("Ошибка подключения: %v", err)
+	}
+	defer ws.Close()
+	_ = ws.SetDeadline(time.Now().Add(5 * time.Second))
+	if msg := recv(ws); msg.Type != "hello" || msg.User != "" {
+		t.Errorf("Ожидали анонимный hello, получили %+v", msg)
+	}
+	if err := websocket.JSON.Send(ws, map[string]any{"type": "like", "id": "2", "post_id": post.ID}); err != nil {
+		t.Fatalf("Ошибка отправки: %v", err)
+	}
+	if msg := recv(ws); msg.Type != "error" || msg.Status != http.StatusUnauthorized {
+		t.Errorf("Ожидали ошибку 401, получили %+v", msg)
+	}
+}
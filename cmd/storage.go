@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Cere6rum/MicroBlog2/internal/config"
+	"github.com/Cere6rum/MicroBlog2/internal/dump"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// importArchive загружает архив storage.import_file в пустые репозитории
+func importArchive(ctx context.Context, cfg config.StorageConfig, users repository.UserRepository, posts repository.PostRepository, policy *validation.Policy) (*dump.Report, error) {
+	f, err := os.Open(cfg.ImportFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conflict, err := dump.ParseConflictPolicy(cfg.ImportConflict)
+	if err != nil {
+		return nil, err
+	}
+	report, err := dump.Import(ctx, f, users, posts, dump.Options{Conflict: conflict, Policy: policy})
+	if err != nil {
+		return nil, fmt.Errorf("импорт %s: %w", cfg.ImportFile, err)
+	}
+	return report, nil
+}
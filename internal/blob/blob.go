@@ -0,0 +1,120 @@
+// Package blob хранит двоичные объекты (аватары, вложения) по ключу.
+// Ключ - относительный путь со слэшами, например "avatars/1/48.png".
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Ошибки, которые можно проверить через errors.Is
+var (
+	ErrNotFound   = errors.New("объект не найден")
+	ErrInvalidKey = errors.New("недопустимый ключ объекта")
+)
+
+// Info - сведения об объекте
+type Info struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Store - хранилище объектов
+type Store interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, Info, error)
+	// Delete удаляет объект; отсутствие объекта ошибкой не считается
+	Delete(ctx context.Context, key string) error
+}
+
+// FSStore хранит объекты файлами в каталоге на диске. Тип содержимого
+// не сохраняется, а определяется по расширению ключа.
+type FSStore struct {
+	root string
+}
+
+// NewFSStore создает хранилище в каталоге root, создавая его при необходимости
+func NewFSStore(root string) (*FSStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("создание каталога хранилища: %w", err)
+	}
+	return &FSStore{root: root}, nil
+}
+
+// Put записывает объект атомарно: во временный файл, затем переименованием,
+// поэтому читатель никогда не видит файл наполовину
+func (s *FSStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // после переименования ничего не удаляет
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *FSStore) Get(ctx context.Context, key string) ([]byte, Info, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Info{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+	info := Info{Key: key, Size: int64(len(data)), ContentType: mime.TypeByExtension(path.Ext(key))}
+	if info.ContentType == "" {
+		info.ContentType = http.DetectContentType(data)
+	}
+	if st, err := os.Stat(name); err == nil {
+		info.ModTime = st.ModTime()
+	}
+	return data, info, nil
+}
+
+func (s *FSStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path проверяет ключ и возвращает путь к файлу: ключ не может выйти
+// за пределы каталога хранилища
+func (s *FSStore) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
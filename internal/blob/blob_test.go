@@ -0,0 +1,62 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFSStore проверяет запись, чтение и удаление объектов
+func TestFSStore(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewFSStore(root)
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "avatars/1/48.png", "image/png", []byte("png")); err != nil {
+		t.Fatalf("Ошибка записи: %v", err)
+	}
+	data, info, err := store.Get(ctx, "avatars/1/48.png")
+	if err != nil || string(data) != "png" || info.Size != 3 || info.ContentType != "image/png" || info.ModTime.IsZero() {
+		t.Fatalf("Неверный объект: %q %+v (%v)", data, info, err)
+	}
+
+	// Перезапись заменяет объект и не оставляет временных файлов
+	if err := store.Put(ctx, "avatars/1/48.png", "image/png", []byte("новый")); err != nil {
+		t.Fatalf("Ошибка перезаписи: %v", err)
+	}
+	if data, _, _ := store.Get(ctx, "avatars/1/48.png"); string(data) != "новый" {
+		t.Errorf("Ожидали новое содержимое, получили %q", data)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "avatars", "1"))
+	if len(entries) != 1 {
+		t.Errorf("Ожидали один файл в каталоге, получили %d", len(entries))
+	}
+
+	if err := store.Delete(ctx, "avatars/1/48.png"); err != nil {
+		t.Fatalf("Ошибка удаления: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "avatars/1/48.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Ожидали ErrNotFound, получили %v", err)
+	}
+	if err := store.Delete(ctx, "avatars/1/48.png"); err != nil {
+		t.Errorf("Удаление отсутствующего объекта не должно быть ошибкой: %v", err)
+	}
+}
+
+// TestFSStoreInvalidKey проверяет, что ключ не выходит за пределы каталога
+func TestFSStoreInvalidKey(t *testing.T) {
+	store, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+	for _, key := range []string{"", ".", "../x", "a/../../x", "/etc/passwd", "a//b"} {
+		if err := store.Put(context.Background(), key, "", []byte("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Ключ %q: ожидали ErrInvalidKey, получили %v", key, err)
+		}
+	}
+}
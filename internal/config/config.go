@@ -0,0 +1,578 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/dump"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/tracing"
+	"github.com/Cere6rum/MicroBlog2/internal/trending"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+	"github.com/Cere6rum/MicroBlog2/internal/webhook"
+)
+
+// Config - полная конфигурация приложения
+type Config struct {
+	Server        ServerConfig        `yaml:"server" json:"server"`
+	Pprof         PprofConfig         `yaml:"pprof" json:"pprof"`
+	Queue         QueueConfig         `yaml:"queue" json:"queue"`
+	Search        SearchConfig        `yaml:"search" json:"search"`
+	Trending      TrendingConfig      `yaml:"trending" json:"trending"`
+	Validation    ValidationConfig    `yaml:"validation" json:"validation"`
+	Log           LogConfig           `yaml:"log" json:"log"`
+	Tracing       TracingConfig       `yaml:"tracing" json:"tracing"`
+	Health        HealthConfig        `yaml:"health" json:"health"`
+	RateLimit     RateLimitConfig     `yaml:"ratelimit" json:"ratelimit"`
+	Storage       StorageConfig       `yaml:"storage" json:"storage"`
+	Stream        StreamConfig        `yaml:"stream" json:"stream"`
+	WebSocket     WebSocketConfig     `yaml:"websocket" json:"websocket"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks" json:"webhooks"`
+	Notifications NotificationsConfig `yaml:"notifications" json:"notifications"`
+	Avatars       AvatarsConfig       `yaml:"avatars" json:"avatars"`
+	Media         MediaConfig         `yaml:"media" json:"media"`
+	Users         UsersConfig         `yaml:"users" json:"users"`
+}
+
+// ServerConfig - настройки основного HTTP-сервера
+type ServerConfig struct {
+	Addr            string   `yaml:"addr" json:"addr"`
+	ReadTimeout     Duration `yaml:"read_timeout" json:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout" json:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout" json:"idle_timeout"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+	MaxBodyBytes    int64    `yaml:"max_body_bytes" json:"max_body_bytes"` // предел тела JSON-запроса
+	// AdminToken - токен для /api/v1/admin/*, удаления аккаунта и смены имени;
+	// пустой - эти маршруты отключены
+	AdminToken string `yaml:"admin_token" json:"admin_token"`
+}
+
+// PprofConfig - настройки сервера профилирования
+type PprofConfig struct {
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	Addr    string `yaml:"addr" json:"addr"`
+}
+
+// QueueConfig - настройки очереди лайков
+type QueueConfig struct {
+	BufferSize int             `yaml:"buffer_size" json:"buffer_size"`
+	Workers    int             `yaml:"workers" json:"workers"`
+	Autoscale  AutoscaleConfig `yaml:"autoscale" json:"autoscale"`
+	// DeadLetterSize - сколько событий с ошибкой хранить для повтора (0 - не хранить)
+	DeadLetterSize int `yaml:"dead_letter_size" json:"dead_letter_size"`
+}
+
+// AutoscaleConfig - автомасштабирование воркеров очереди лайков
+type AutoscaleConfig struct {
+	Enabled              bool     `yaml:"enabled" json:"enabled"`
+	MinWorkers           int      `yaml:"min_workers" json:"min_workers"`
+	MaxWorkers           int      `yaml:"max_workers" json:"max_workers"`
+	Interval             Duration `yaml:"interval" json:"interval"`
+	ScaleUpUtilization   float64  `yaml:"scale_up_utilization" json:"scale_up_utilization"`
+	ScaleDownUtilization float64  `yaml:"scale_down_utilization" json:"scale_down_utilization"`
+	LatencyTarget        Duration `yaml:"latency_target" json:"latency_target"`
+	UpAfter              int      `yaml:"up_after" json:"up_after"`
+	DownAfter            int      `yaml:"down_after" json:"down_after"`
+	Cooldown             Duration `yaml:"cooldown" json:"cooldown"`
+}
+
+// Setup преобразует настройки в конфигурацию пакета queue
+func (c AutoscaleConfig) Setup() queue.AutoscaleConfig {
+	return queue.AutoscaleConfig{
+		Enabled:              c.Enabled,
+		MinWorkers:           c.MinWorkers,
+		MaxWorkers:           c.MaxWorkers,
+		Interval:             c.Interval.Std(),
+		ScaleUpUtilization:   c.ScaleUpUtilization,
+		ScaleDownUtilization: c.ScaleDownUtilization,
+		LatencyTarget:        c.LatencyTarget.Std(),
+		UpAfter:              c.UpAfter,
+		DownAfter:            c.DownAfter,
+		Cooldown:             c.Cooldown.Std(),
+	}
+}
+
+// SearchConfig - настройки очереди обновления поискового индекса
+type SearchConfig struct {
+	BufferSize     int `yaml:"buffer_size" json:"buffer_size"`
+	Workers        int `yaml:"workers" json:"workers"`
+	DeadLetterSize int `yaml:"dead_letter_size" json:"dead_letter_size"`
+}
+
+// NotificationsConfig - очередь создания уведомлений
+type NotificationsConfig struct {
+	BufferSize     int `yaml:"buffer_size" json:"buffer_size"`
+	Workers        int `yaml:"workers" json:"workers"`
+	DeadLetterSize int `yaml:"dead_letter_size" json:"dead_letter_size"`
+}
+
+// TrendingConfig - окно подсчета популярных постов и хэштегов
+type TrendingConfig struct {
+	Window   Duration `yaml:"window" json:"window"`
+	Bucket   Duration `yaml:"bucket" json:"bucket"`
+	HalfLife Duration `yaml:"half_life" json:"half_life"`
+}
+
+// Setup преобразует настройки в конфигурацию пакета trending
+func (c TrendingConfig) Setup() trending.Config {
+	return trending.Config{
+		Window:   c.Window.Std(),
+		Bucket:   c.Bucket.Std(),
+		HalfLife: c.HalfLife.Std(),
+	}
+}
+
+// ValidationConfig - ограничения на имена пользователей и текст постов
+type ValidationConfig struct {
+	MaxPostRunes      int      `yaml:"max_post_runes" json:"max_post_runes"`
+	UsernameMinRunes  int      `yaml:"username_min_runes" json:"username_min_runes"`
+	UsernameMaxRunes  int      `yaml:"username_max_runes" json:"username_max_runes"`
+	ReservedUsernames []string `yaml:"reserved_usernames" json:"reserved_usernames"`
+}
+
+// Setup преобразует настройки в правила пакета validation
+func (c ValidationConfig) Setup() (*validation.Policy, error) {
+	return validation.NewPolicy(validation.Policy{
+		MaxPostRunes:      c.MaxPostRunes,
+		UsernameMinRunes:  c.UsernameMinRunes,
+		UsernameMaxRunes:  c.UsernameMaxRunes,
+		ReservedUsernames: c.ReservedUsernames,
+	})
+}
+
+// LogConfig - настройки логгера
+type LogConfig struct {
+	File  string `yaml:"file" json:"file"`
+	Level string `yaml:"level" json:"level"` // debug, info, error
+}
+
+// RateLimitConfig - ограничение частоты запросов на один IP
+type RateLimitConfig struct {
+	Enabled           bool    `yaml:"enabled" json:"enabled"`
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	Burst             int     `yaml:"burst" json:"burst"`
+}
+
+// TracingConfig - настройки OpenTelemetry
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" json:"exporter"`
+	File         string  `yaml:"file" json:"file"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" json:"otlp_endpoint"`
+	Insecure     bool    `yaml:"insecure" json:"insecure"`
+	ServiceName  string  `yaml:"service_name" json:"service_name"`
+	SampleRatio  float64 `yaml:"sample_ratio" json:"sample_ratio"`
+}
+
+// HealthConfig - настройки проверок готовности
+type HealthConfig struct {
+	CheckTimeout Duration `yaml:"check_timeout" json:"check_timeout"`
+	// DrainDelay - пауза между провалом readiness и остановкой сервера
+	DrainDelay Duration `yaml:"drain_delay" json:"drain_delay"`
+}
+
+// StorageConfig - загрузка данных из архива microblog-dump при запуске
+// и выгрузка при остановке, каталог загруженных файлов
+type StorageConfig struct {
+	ImportFile     string `yaml:"import_file" json:"import_file"`
+	ImportConflict string `yaml:"import_conflict" json:"import_conflict"` // fail, skip, overwrite
+	ExportFile     string `yaml:"export_file" json:"export_file"`
+	BlobDir        string `yaml:"blob_dir" json:"blob_dir"` // пустой - загрузка файлов отключена
+}
+
+// AvatarsConfig - загрузка аватаров
+type AvatarsConfig struct {
+	MaxBytes int64 `yaml:"max_bytes" json:"max_bytes"`
+}
+
+// MediaConfig - загрузка изображений для постов
+type MediaConfig struct {
+	MaxBytes int64 `yaml:"max_bytes" json:"max_bytes"` // предел одного файла
+	// PendingTTL - сколько хранится изображение, не прикрепленное к посту (0 - бессрочно)
+	PendingTTL Duration `yaml:"pending_ttl" json:"pending_ttl"`
+}
+
+// UsersConfig - учетные записи пользователей
+type UsersConfig struct {
+	// NameCooldown - сколько имя после смены остается за прежним владельцем
+	NameCooldown Duration `yaml:"name_cooldown" json:"name_cooldown"`
+}
+
+// StreamConfig - поток событий GET /stream. Размеры буферов общие
+// для /stream и /ws.
+type StreamConfig struct {
+	Enabled      bool     `yaml:"enabled" json:"enabled"`
+	ClientBuffer int      `yaml:"client_buffer" json:"client_buffer"` // событий в буфере клиента до отключения
+	ReplaySize   int      `yaml:"replay_size" json:"replay_size"`     // событий для продолжения по Last-Event-ID
+	Heartbeat    Duration `yaml:"heartbeat" json:"heartbeat"`
+}
+
+// WebSocketConfig - WebSocket API GET /ws
+type WebSocketConfig struct {
+	Enabled   bool     `yaml:"enabled" json:"enabled"`
+	Heartbeat Duration `yaml:"heartbeat" json:"heartbeat"` // период ping; без ответа 2 периода - отключение
+	// AllowedOrigins - источники страниц, кроме собственного, которым можно
+	// подключаться (https://app.example.com); "*" - любые
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"`
+	// Tokens - учетные данные соединений в виде "имя:токен"; лайки из
+	// соединения ставятся от имени владельца токена. Без токена
+	// соединение анонимное и может только подписываться.
+	Tokens []string `yaml:"tokens" json:"tokens"`
+}
+
+// UserTokens возвращает пользователей WebSocket по токенам (токен -> имя)
+func (c WebSocketConfig) UserTokens() map[string]string {
+	users := make(map[string]string, len(c.Tokens))
+	for _, entry := range c.Tokens {
+		if name, token, ok := strings.Cut(entry, ":"); ok {
+			users[token] = name
+		}
+	}
+	return users
+}
+
+// WebhooksConfig - исходящие вебхуки
+type WebhooksConfig struct {
+	Enabled        bool     `yaml:"enabled" json:"enabled"`
+	BufferSize     int      `yaml:"buffer_size" json:"buffer_size"`
+	Workers        int      `yaml:"workers" json:"workers"`
+	DeadLetterSize int      `yaml:"dead_letter_size" json:"dead_letter_size"`
+	Timeout        Duration `yaml:"timeout" json:"timeout"` // таймаут одной попытки
+	MaxAttempts    int      `yaml:"max_attempts" json:"max_attempts"`
+	Backoff        Duration `yaml:"backoff" json:"backoff"` // пауза перед повтором, дальше удваивается
+	MaxBackoff     Duration `yaml:"max_backoff" json:"max_backoff"`
+	// DisableAfter - неудавшихся доставок подряд до отключения вебхука (0 - не отключать)
+	DisableAfter int `yaml:"disable_after" json:"disable_after"`
+	// AllowPrivateNetworks разрешает доставку на loopback и адреса внутренней сети
+	AllowPrivateNetworks bool `yaml:"allow_private_networks" json:"allow_private_networks"`
+}
+
+// Setup преобразует настройки в конфигурацию пакета webhook
+func (c WebhooksConfig) Setup() webhook.Config {
+	return webhook.Config{
+		Timeout:      c.Timeout.Std(),
+		MaxAttempts:  c.MaxAttempts,
+		Backoff:      c.Backoff.Std(),
+		MaxBackoff:   c.MaxBackoff.Std(),
+		DisableAfter: c.DisableAfter,
+		AllowPrivate: c.AllowPrivateNetworks,
+	}
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() *Config {
+	policy := validation.DefaultPolicy()
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(15 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(10 * time.Second),
+			MaxBodyBytes:    1 << 20,
+		},
+		Pprof: PprofConfig{
+			Enabled: true,
+			Addr:    ":6060",
+		},
+		Queue: QueueConfig{
+			BufferSize: 100,
+			Workers:    3,
+			Autoscale: AutoscaleConfig{
+				Enabled:              false,
+				MinWorkers:           1,
+				MaxWorkers:           16,
+				Interval:             Duration(time.Second),
+				ScaleUpUtilization:   0.5,
+				ScaleDownUtilization: 0.1,
+				LatencyTarget:        Duration(50 * time.Millisecond),
+				UpAfter:              2,
+				DownAfter:            10,
+				Cooldown:             Duration(5 * time.Second),
+			},
+			DeadLetterSize: 1000,
+		},
+		Search: SearchConfig{
+			BufferSize:     1000,
+			Workers:        2,
+			DeadLetterSize: 1000,
+		},
+		Trending: TrendingConfig{
+			Window:   Duration(24 * time.Hour),
+			Bucket:   Duration(time.Minute),
+			HalfLife: Duration(2 * time.Hour),
+		},
+		Validation: ValidationConfig{
+			MaxPostRunes:      policy.MaxPostRunes,
+			UsernameMinRunes:  policy.UsernameMinRunes,
+			UsernameMaxRunes:  policy.UsernameMaxRunes,
+			ReservedUsernames: policy.ReservedUsernames,
+		},
+		Log: LogConfig{
+			File:  "app.log",
+			Level: "debug",
+		},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			File:        "traces.json",
+			ServiceName: "microblog",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			CheckTimeout: Duration(2 * time.Second),
+			DrainDelay:   Duration(2 * time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:           false,
+			RequestsPerSecond: 10,
+			Burst:             20,
+		},
+		Storage: StorageConfig{
+			ImportConflict: string(dump.ConflictFail),
+			BlobDir:        "blobs",
+		},
+		Stream: StreamConfig{
+			Enabled:      true,
+			ClientBuffer: 64,
+			ReplaySize:   1000,
+			Heartbeat:    Duration(15 * time.Second),
+		},
+		WebSocket: WebSocketConfig{
+			Enabled:   true,
+			Heartbeat: Duration(30 * time.Second),
+		},
+		Webhooks: WebhooksConfig{
+			Enabled:        true,
+			BufferSize:     1000,
+			Workers:        2,
+			DeadLetterSize: 1000,
+			Timeout:        Duration(10 * time.Second),
+			MaxAttempts:    5,
+			Backoff:        Duration(time.Second),
+			MaxBackoff:     Duration(30 * time.Second),
+			DisableAfter:   10,
+		},
+		Notifications: NotificationsConfig{
+			BufferSize:     1000,
+			Workers:        2,
+			DeadLetterSize: 1000,
+		},
+		Avatars: AvatarsConfig{
+			MaxBytes: 5 << 20,
+		},
+		Media: MediaConfig{
+			MaxBytes:   10 << 20,
+			PendingTTL: Duration(24 * time.Hour),
+		},
+		Users: UsersConfig{
+			NameCooldown: Duration(30 * 24 * time.Hour),
+		},
+	}
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if err := validateAddr(c.Server.Addr); err != nil {
+		add("server.addr", "%v", err)
+	}
+	for _, d := range []struct {
+		key   string
+		value Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"health.check_timeout", c.Health.CheckTimeout},
+	} {
+		if d.value <= 0 {
+			add(d.key, "должно быть положительным, получено %s", d.value)
+		}
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		add("server.max_body_bytes", "должно быть больше 0, получено %d", c.Server.MaxBodyBytes)
+	}
+	if c.Health.DrainDelay < 0 {
+		add("health.drain_delay", "не может быть отрицательным")
+	}
+
+	if c.Pprof.Enabled {
+		if err := validateAddr(c.Pprof.Addr); err != nil {
+			add("pprof.addr", "%v", err)
+		} else if c.Pprof.Addr == c.Server.Addr {
+			add("pprof.addr", "совпадает с server.addr")
+		}
+	}
+
+	if c.Queue.BufferSize <= 0 {
+		add("queue.buffer_size", "должно быть больше 0, получено %d", c.Queue.BufferSize)
+	}
+	if c.Queue.Workers <= 0 {
+		add("queue.workers", "должно быть больше 0, получено %d", c.Queue.Workers)
+	}
+	if c.Queue.DeadLetterSize < 0 {
+		add("queue.dead_letter_size", "не может быть отрицательным")
+	}
+	if err := c.Queue.Autoscale.Setup().Validate(); err != nil {
+		add("queue.autoscale", "%v", err)
+	}
+
+	if c.Search.BufferSize <= 0 {
+		add("search.buffer_size", "должно быть больше 0, получено %d", c.Search.BufferSize)
+	}
+	if c.Search.Workers <= 0 {
+		add("search.workers", "должно быть больше 0, получено %d", c.Search.Workers)
+	}
+
+	if c.Search.DeadLetterSize < 0 {
+		add("search.dead_letter_size", "не может быть отрицательным")
+	}
+
+	if c.Notifications.BufferSize <= 0 {
+		add("notifications.buffer_size", "должно быть больше 0, получено %d", c.Notifications.BufferSize)
+	}
+	if c.Notifications.Workers <= 0 {
+		add("notifications.workers", "должно быть больше 0, получено %d", c.Notifications.Workers)
+	}
+	if c.Notifications.DeadLetterSize < 0 {
+		add("notifications.dead_letter_size", "не может быть отрицательным")
+	}
+
+	if c.Avatars.MaxBytes <= 0 {
+		add("avatars.max_bytes", "должно быть больше 0, получено %d", c.Avatars.MaxBytes)
+	}
+	if c.Media.MaxBytes <= 0 {
+		add("media.max_bytes", "должно быть больше 0, получено %d", c.Media.MaxBytes)
+	}
+	if c.Media.PendingTTL < 0 {
+		add("media.pending_ttl", "не может быть отрицательным")
+	}
+	if c.Users.NameCooldown < 0 {
+		add("users.name_cooldown", "не может быть отрицательным")
+	}
+
+	if err := c.Trending.Setup().Validate(); err != nil {
+		add("trending", "%v", err)
+	}
+
+	if _, err := c.Validation.Setup(); err != nil {
+		add("validation", "%v", err)
+	}
+
+	if c.Log.File == "" {
+		add("log.file", "не может быть пустым")
+	}
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		add("log.level", "%v", err)
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.RequestsPerSecond <= 0 {
+			add("ratelimit.requests_per_second", "должно быть больше 0, получено %g", c.RateLimit.RequestsPerSecond)
+		}
+		if c.RateLimit.Burst < 1 {
+			add("ratelimit.burst", "должно быть не меньше 1, получено %d", c.RateLimit.Burst)
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		if c.Tracing.File == "" {
+			add("tracing.file", "обязателен для экспортера file")
+		}
+	default:
+		add("tracing.exporter", "неизвестный экспортер %q (допустимо: none, stdout, file, otlp)", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio <= 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio", "должно быть в диапазоне (0, 1], получено %g", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.ServiceName == "" {
+		add("tracing.service_name", "не может быть пустым")
+	}
+
+	if _, err := dump.ParseConflictPolicy(c.Storage.ImportConflict); err != nil {
+		add("storage.import_conflict", "%v", err)
+	}
+
+	if c.Stream.Enabled || c.WebSocket.Enabled {
+		if c.Stream.ClientBuffer <= 0 {
+			add("stream.client_buffer", "должно быть больше 0, получено %d", c.Stream.ClientBuffer)
+		}
+		if c.Stream.ReplaySize < 0 {
+			add("stream.replay_size", "не может быть отрицательным")
+		}
+	}
+	if c.Stream.Enabled && c.Stream.Heartbeat <= 0 {
+		add("stream.heartbeat", "должно быть положительным, получено %s", c.Stream.Heartbeat)
+	}
+	if c.WebSocket.Enabled && c.WebSocket.Heartbeat <= 0 {
+		add("websocket.heartbeat", "должно быть положительным, получено %s", c.WebSocket.Heartbeat)
+	}
+	for _, origin := range c.WebSocket.AllowedOrigins {
+		if u, err := url.Parse(origin); origin != "*" && (err != nil || u.Scheme == "" || u.Host == "" || strings.Trim(u.Path, "/") != "") {
+			add("websocket.allowed_origins", "ожидается схема и хост (https://app.example.com) или *, получено %q", origin)
+		}
+	}
+	seenTokens := make(map[string]bool, len(c.WebSocket.Tokens))
+	for i, entry := range c.WebSocket.Tokens {
+		// Сам токен в сообщение не попадает
+		name, token, ok := strings.Cut(entry, ":")
+		switch {
+		case !ok || name == "" || token == "":
+			add("websocket.tokens", "запись %d: ожидается имя:токен", i+1)
+		case seenTokens[token]:
+			add("websocket.tokens", "запись %d (%s): токен уже выдан другой записи", i+1, name)
+		}
+		seenTokens[token] = true
+	}
+
+	if c.Webhooks.Enabled {
+		if c.Webhooks.BufferSize <= 0 {
+			add("webhooks.buffer_size", "должно быть больше 0, получено %d", c.Webhooks.BufferSize)
+		}
+		if c.Webhooks.Workers <= 0 {
+			add("webhooks.workers", "должно быть больше 0, получено %d", c.Webhooks.Workers)
+		}
+		if c.Webhooks.DeadLetterSize < 0 {
+			add("webhooks.dead_letter_size", "не может быть отрицательным")
+		}
+		if err := c.Webhooks.Setup().Validate(); err != nil {
+			add("webhooks", "%v", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// TracingSetup преобразует настройки в конфигурацию пакета tracing
+func (c *Config) TracingSetup() tracing.Config {
+	return tracing.Config{
+		Exporter:     c.Tracing.Exporter,
+		File:         c.Tracing.File,
+		OTLPEndpoint: c.Tracing.OTLPEndpoint,
+		Insecure:     c.Tracing.Insecure,
+		ServiceName:  c.Tracing.ServiceName,
+		SampleRatio:  c.Tracing.SampleRatio,
+	}
+}
+
+// validateAddr проверяет адрес вида host:port
+func validateAddr(addr string) error {
+	if addr == "" {
+		return errors.New("адрес не может быть пустым")
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("неверный адрес %q: %v", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func envFrom(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+// TestLoadPrecedence проверяет порядок источников: файл < окружение < флаги
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "server:\n  addr: \":9000\"\n  shutdown_timeout: 30s\nqueue:\n  workers: 5\n  buffer_size: 10\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Ошибка записи файла: %v", err)
+	}
+
+	env := envFrom(map[string]string{
+		"MICROBLOG_QUEUE_WORKERS": "7",
+		"MICROBLOG_SERVER_ADDR":   ":9100",
+	})
+	cfg, opts, err := load([]string{"-config", path, "-server-addr", ":9200"}, env)
+	if err != nil {
+		t.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+
+	if opts.ConfigPath != path {
+		t.Errorf("Ожидали путь %s, получили %s", path, opts.ConfigPath)
+	}
+	if cfg.Server.Addr != ":9200" {
+		t.Errorf("Флаг должен перекрывать окружение: ожидали :9200, получили %s", cfg.Server.Addr)
+	}
+	if cfg.Queue.Workers != 7 {
+		t.Errorf("Окружение должно перекрывать файл: ожидали 7, получили %d", cfg.Queue.Workers)
+	}
+	if cfg.Queue.BufferSize != 10 {
+		t.Errorf("Ожидали buffer_size из файла 10, получили %d", cfg.Queue.BufferSize)
+	}
+	if cfg.Server.ShutdownTimeout.Std() != 30*time.Second {
+		t.Errorf("Ожидали shutdown_timeout 30s, получили %s", cfg.Server.ShutdownTimeout)
+	}
+	if cfg.Server.ReadTimeout.Std() != 15*time.Second {
+		t.Errorf("Незаданное поле должно остаться по умолчанию, получили %s", cfg.Server.ReadTimeout)
+	}
+}
+
+// TestLoadJSONFile проверяет чтение JSON и отказ на неизвестных ключах
+func TestLoadJSONFile(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	if err := os.WriteFile(good, []byte(`{"server":{"idle_timeout":"2m"}}`), 0o644); err != nil {
+		t.Fatalf("Ошибка записи файла: %v", err)
+	}
+	cfg, _, err := load([]string{"-config", good}, envFrom(nil))
+	if err != nil {
+		t.Fatalf("Ошибка загрузки JSON: %v", err)
+	}
+	if cfg.Server.IdleTimeout.Std() != 2*time.Minute {
+		t.Errorf("Ожидали idle_timeout 2m, получили %s", cfg.Server.IdleTimeout)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"server":{"adr":":1"}}`), 0o644); err != nil {
+		t.Fatalf("Ошибка записи файла: %v", err)
+	}
+	if _, _, err := load([]string{"-config", bad}, envFrom(nil)); err == nil {
+		t.Error("Ожидали ошибку для неизвестного ключа")
+	}
+}
+
+// TestValidate проверяет отказ на некорректных значениях
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Конфигурация по умолчанию должна быть корректной: %v", err)
+	}
+
+	cases := map[string][]string{
+		"workers":  {"-queue-workers", "0"},
+		"addr":     {"-server-addr", "8080"},
+		"exporter": {"-tracing-exporter", "jaeger"},
+		"duration": {"-server-read-timeout", "15"},
+		"pprof":    {"-pprof-addr", ":8080"},
+		"tokens":   {"-websocket-tokens", "alice:t1,bob:t1"},
+		"token":    {"-websocket-tokens", "alice"},
+	}
+	for name, args := range cases {
+		if _, _, err := load(args, envFrom(nil)); err == nil {
+			t.Errorf("%s: ожидали ошибку для %v", name, args)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration - time.Duration, который читается и пишется строкой вида "15s"
+// и в YAML, и в JSON, и в переменных окружения
+type Duration time.Duration
+
+// Std возвращает значение как time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("неверная длительность %q: ожидается формат вида 10s, 1m30s", string(text))
+	}
+	*d = Duration(v)
+	return nil
+}
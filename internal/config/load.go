@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix - префикс переменных окружения: server.addr -> MICROBLOG_SERVER_ADDR
+const EnvPrefix = "MICROBLOG_"
+
+// Options - параметры запуска, которые не являются частью конфигурации
+type Options struct {
+	ConfigPath  string // путь к файлу конфигурации (-config или MICROBLOG_CONFIG)
+	PrintConfig bool   // вывести итоговую конфигурацию и выйти
+}
+
+// setting - одно поле конфигурации, доступное из файла, окружения и флагов
+type setting struct {
+	key   string        // server.read_timeout
+	value reflect.Value // адресуемое поле внутри Config
+}
+
+func (s setting) envName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// Load собирает конфигурацию из источников в порядке возрастания приоритета:
+// значения по умолчанию < файл < переменные окружения < флаги командной строки.
+// Результат проходит валидацию.
+func Load(args []string) (*Config, Options, error) {
+	return load(args, os.LookupEnv)
+}
+
+func load(args []string, lookupEnv func(string) (string, bool)) (*Config, Options, error) {
+	var opts Options
+
+	cfg := Default()
+	settings := collect(cfg)
+
+	// Флаги разбираем первыми, чтобы узнать путь к файлу, но применяем последними
+	fs := flag.NewFlagSet("microblog", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.ConfigPath, "config", "", "путь к файлу конфигурации (YAML или JSON)")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "вывести итоговую конфигурацию и выйти")
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		key := s.key
+		fs.Func(s.flagName(), "переопределяет "+key, func(v string) error {
+			flagValues[key] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, opts, fmt.Errorf("использование:\n%s", usage(fs))
+		}
+		return nil, opts, err
+	}
+	if fs.NArg() > 0 {
+		return nil, opts, fmt.Errorf("неожиданные аргументы: %v", fs.Args())
+	}
+
+	if opts.ConfigPath == "" {
+		opts.ConfigPath, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if opts.ConfigPath != "" {
+		if err := loadFile(opts.ConfigPath, cfg); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := lookupEnv(s.envName()); ok {
+			if err := setValue(s.value, v); err != nil {
+				return nil, opts, fmt.Errorf("переменная %s: %w", s.envName(), err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := flagValues[s.key]; ok {
+			if err := setValue(s.value, v); err != nil {
+				return nil, opts, fmt.Errorf("флаг -%s: %w", s.flagName(), err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, opts, fmt.Errorf("некорректная конфигурация:\n%w", err)
+	}
+	return cfg, opts, nil
+}
+
+// loadFile читает YAML или JSON (по расширению) поверх текущих значений.
+// Неизвестные ключи считаются ошибкой, чтобы опечатки не терялись молча.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать файл конфигурации: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("ошибка разбора %s: %w", path, err)
+		}
+	default:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("ошибка разбора %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Write выводит конфигурацию в формате yaml или json
+func (c *Config) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	case "yaml", "":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("неизвестный формат вывода: %q", format)
+	}
+}
+
+// collect обходит Config и возвращает все листовые поля с их ключами (по тегам yaml)
+func collect(cfg *Config) []setting {
+	var out []setting
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			key := name
+			if prefix != "" {
+				key = prefix + "." + name
+			}
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Struct {
+				walk(key, fv)
+				continue
+			}
+			out = append(out, setting{key: key, value: fv})
+		}
+	}
+	walk("", reflect.ValueOf(cfg).Elem())
+	return out
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setValue присваивает строковое значение полю с учетом его типа
+func setValue(v reflect.Value, raw string) error {
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("ожидается true или false, получено %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число, получено %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается число, получено %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("неподдерживаемый тип %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("неподдерживаемый тип %s", v.Type())
+	}
+	return nil
+}
+
+// usage формирует справку по флагам вместе с соответствующими переменными окружения
+func usage(fs *flag.FlagSet) string {
+	settings := collect(Default())
+	env := make(map[string]string, len(settings))
+	for _, s := range settings {
+		env[s.flagName()] = s.envName()
+	}
+
+	var lines []string
+	fs.VisitAll(func(f *flag.Flag) {
+		line := fmt.Sprintf("  -%s\t%s", f.Name, f.Usage)
+		if e, ok := env[f.Name]; ok {
+			line += " (" + e + ")"
+		}
+		lines = append(lines, line)
+	})
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
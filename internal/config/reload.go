@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// reloadable - префиксы ключей, которые можно менять без перезапуска (SIGHUP)
+var reloadable = []string{
+	"log.level",
+	"ratelimit.",
+	"queue.workers",
+	"queue.autoscale.",
+}
+
+// IsReloadable сообщает, применяется ли ключ без перезапуска процесса
+func IsReloadable(key string) bool {
+	for _, prefix := range reloadable {
+		if key == prefix || (strings.HasSuffix(prefix, ".") && strings.HasPrefix(key, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Change - изменение одного ключа между двумя конфигурациями
+type Change struct {
+	Key        string
+	Old, New   string
+	Reloadable bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+}
+
+// Diff возвращает ключи, значения которых отличаются в old и new
+func Diff(old, new *Config) []Change {
+	oldSettings := collect(old)
+	newSettings := collect(new)
+
+	var changes []Change
+	for i, s := range oldSettings {
+		ov := s.value.Interface()
+		nv := newSettings[i].value.Interface()
+		if reflect.DeepEqual(ov, nv) {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:        s.key,
+			Old:        fmt.Sprint(ov),
+			New:        fmt.Sprint(nv),
+			Reloadable: IsReloadable(s.key),
+		})
+	}
+	return changes
+}
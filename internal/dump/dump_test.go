@@ -0,0 +1,194 @@
+package dump
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// TestRoundTrip проверяет выгрузку и загрузку с сохранением ID и продолжение счетчиков
+func TestRoundTrip(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	ctx := context.Background()
+
+	likeQueue := queue.NewLikeQueue(10, 1)
+	users, posts := repository.NewInMemoryUserRepo(), repository.NewInMemoryPostRepo()
+	svc := service.NewMicroBlogServiceWithRepos(log, likeQueue, users, posts)
+	likeQueue.Start(svc.ProcessLikeEvent)
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := svc.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации: %v", err)
+		}
+	}
+	first, _ := svc.CreatePost(ctx, "alice", "Первый пост про #кошки")
+	reply, _ := svc.CreatePost(ctx, "bob", "Ответ", service.ReplyTo(first.ID))
+	last, _ := svc.CreatePost(ctx, "bob", "Удаляемый")
+	if err := svc.LikePost(ctx, first.ID, "bob"); err != nil {
+		t.Fatalf("Ошибка лайка: %v", err)
+	}
+	// Лайк обрабатывается асинхронно: ждем воркера, затем останавливаем очередь
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if p, _ := posts.GetByID(ctx, first.ID); len(p.LikeIDs) > 0 {
+			break
+		}
+	}
+	likeQueue.Stop()
+	if err := svc.DeletePost(ctx, last.ID, "bob"); err != nil {
+		t.Fatalf("Ошибка удаления: %v", err)
+	}
+
+	var buf bytes.Buffer
+	h, err := Export(ctx, &buf, users, posts, WithLastIDs(svc.LastIDs()))
+	if err != nil {
+		t.Fatalf("Ошибка выгрузки: %v", err)
+	}
+	if h.Users != 2 || h.Posts != 2 || h.Likes != 1 {
+		t.Errorf("Неверный заголовок: %+v", h)
+	}
+
+	// Тест 1: загрузка сохраняет ID, ответы и лайки
+	users2, posts2 := repository.NewInMemoryUserRepo(), repository.NewInMemoryPostRepo()
+	report, err := Import(ctx, bytes.NewReader(buf.Bytes()), users2, posts2, Options{Policy: validation.DefaultPolicy()})
+	if err != nil {
+		t.Fatalf("Ошибка загрузки: %v", err)
+	}
+	if report.Users.Created != 2 || report.Posts.Created != 2 || report.Likes.Created != 1 {
+		t.Errorf("Неверный отчет: %+v", report)
+	}
+	got, err := posts2.GetByID(ctx, reply.ID)
+	if err != nil || got.ReplyToID != first.ID {
+		t.Errorf("Ответ %d должен ссылаться на %d: %+v (%v)", reply.ID, first.ID, got, err)
+	}
+	if got, _ := posts2.GetByID(ctx, first.ID); len(got.LikeIDs) != 1 || got.LikeIDs[0] != 2 {
+		t.Errorf("Лайк не восстановлен: %+v", got)
+	}
+
+	// Тест 2: счетчики продолжаются после удаленного поста, индексы перестроены
+	if report.LastPostID != last.ID {
+		t.Errorf("Ожидали last_post_id %d, получили %d", last.ID, report.LastPostID)
+	}
+	svc2 := service.NewMicroBlogServiceWithRepos(log, queue.NewLikeQueue(10, 1), users2, posts2,
+		service.WithLastIDs(report.LastUserID, report.LastPostID))
+	if _, err := svc2.Reindex(ctx); err != nil {
+		t.Fatalf("Ошибка перестроения индексов: %v", err)
+	}
+	next, err := svc2.CreatePost(ctx, "alice", "Новый")
+	if err != nil || next.ID != last.ID+1 {
+		t.Errorf("Ожидали ID %d для нового поста, получили %+v (%v)", last.ID+1, next, err)
+	}
+	if res, err := svc2.Search(ctx, "кошки", "", 0, 0); err != nil || res.Total != 1 {
+		t.Errorf("Загруженный пост должен находиться поиском: %+v (%v)", res, err)
+	}
+	if tagged, _ := svc2.GetPostsByTag(ctx, "кошки"); len(tagged) != 1 {
+		t.Errorf("Загруженный пост должен находиться по хэштегу, получили %d", len(tagged))
+	}
+	if _, err := svc2.EditPost(ctx, first.ID, "alice", "Правка"); err != nil {
+		t.Errorf("Ошибка правки загруженного поста: %v", err)
+	}
+	if history, _ := svc2.GetPostHistory(ctx, first.ID); len(history) != 2 {
+		t.Errorf("Ожидали 2 версии после правки загруженного поста, получили %d", len(history))
+	}
+}
+
+// TestConflicts проверяет политики конфликтов и пробный прогон
+func TestConflicts(t *testing.T) {
+	ctx := context.Background()
+	archive := strings.Join([]string{
+		`{"format":"microblog-dump","version":1,"users":1,"posts":1,"likes":0}`,
+		`{"type":"user","data":{"id":1,"username":"alice"}}`,
+		`{"type":"post","data":{"id":1,"kind":"post","author_id":1,"author":"alice","content":"Из архива"}}`,
+	}, "\n")
+	setup := func() (*repository.InMemoryUserRepo, *repository.InMemoryPostRepo) {
+		users, posts := repository.NewInMemoryUserRepo(), repository.NewInMemoryPostRepo()
+		_ = users.Create(ctx, &models.User{ID: 1, Username: "alice"})
+		_ = posts.Create(ctx, &models.Post{ID: 1, Kind: models.PostKindPost, AuthorID: 1, Author: "alice", Content: "Исходный"})
+		return users, posts
+	}
+	content := func(posts *repository.InMemoryPostRepo) string {
+		p, _ := posts.GetByID(ctx, 1)
+		return p.Content
+	}
+
+	// Тест 1: fail ничего не меняет
+	users, posts := setup()
+	if _, err := Import(ctx, strings.NewReader(archive), users, posts, Options{}); err == nil {
+		t.Error("Ожидали ошибку конфликта")
+	}
+	if content(posts) != "Исходный" {
+		t.Error("Политика fail не должна менять данные")
+	}
+
+	// Тест 2: skip оставляет существующее
+	report, err := Import(ctx, strings.NewReader(archive), users, posts, Options{Conflict: ConflictSkip})
+	if err != nil || report.Posts.Skipped != 1 || content(posts) != "Исходный" {
+		t.Errorf("skip: %+v (%v), текст %q", report, err, content(posts))
+	}
+
+	// Тест 3: пробный прогон overwrite считает, но не пишет
+	report, err = Import(ctx, strings.NewReader(archive), users, posts, Options{Conflict: ConflictOverwrite, DryRun: true})
+	if err != nil || report.Posts.Overwritten != 1 || content(posts) != "Исходный" {
+		t.Errorf("dry-run: %+v (%v), текст %q", report, err, content(posts))
+	}
+
+	// Тест 4: overwrite заменяет
+	if _, err := Import(ctx, strings.NewReader(archive), users, posts, Options{Conflict: ConflictOverwrite}); err != nil || content(posts) != "Из архива" {
+		t.Errorf("overwrite: %v, текст %q", err, content(posts))
+	}
+}
+
+// TestImportValidation проверяет отказ от некорректных архивов
+func TestImportValidation(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name, archive, want string
+	}{
+		{"чужой формат", `{"format":"other","version":1}`, "не архив"},
+		{"новая версия", `{"format":"microblog-dump","version":99}`, "не поддерживается"},
+		{"неизвестный тип", `{"format":"microblog-dump","version":1}` + "\n" + `{"type":"comment","data":{}}`, "строка 2: неизвестный тип"},
+		{"автор не найден", `{"format":"microblog-dump","version":1}` + "\n" +
+			`{"type":"post","data":{"id":1,"author_id":1,"author":"ghost","content":"x"}}`, "автор ghost не найден"},
+		{"имя по правилам", `{"format":"microblog-dump","version":1}` + "\n" +
+			`{"type":"user","data":{"id":1,"username":"admin"}}`, "строка 2: пользователь 1"},
+		{"лайк без поста", `{"format":"microblog-dump","version":1}` + "\n" +
+			`{"type":"user","data":{"id":1,"username":"alice"}}` + "\n" +
+			`{"type":"like","data":{"post_id":7,"username":"alice"}}`, "пост 7 не найден"},
+		{"ID за last_post_id", `{"format":"microblog-dump","version":1,"last_post_id":2}` + "\n" +
+			`{"type":"user","data":{"id":1,"username":"alice"}}` + "\n" +
+			`{"type":"post","data":{"id":3,"author_id":1,"author":"alice","content":"x"}}`, "ID поста 3 больше допустимого 2"},
+		{"огромный ID", `{"format":"microblog-dump","version":1}` + "\n" +
+			`{"type":"user","data":{"id":1,"username":"alice"}}` + "\n" +
+			`{"type":"post","data":{"id":2000000000,"author_id":1,"author":"alice","content":"x"}}`, "больше допустимого"},
+		{"огромный last_post_id", `{"format":"microblog-dump","version":1,"last_post_id":2000000000}`, "недопустимые last_user_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, posts := repository.NewInMemoryUserRepo(), repository.NewInMemoryPostRepo()
+			_, err := Import(ctx, strings.NewReader(tt.archive), users, posts, Options{Policy: validation.DefaultPolicy()})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Ожидали ошибку с %q, получили %v", tt.want, err)
+			}
+			if len(users.List(ctx)) != 0 {
+				t.Error("При ошибке проверки репозитории не должны меняться")
+			}
+		})
+	}
+
+	if _, err := ParseConflictPolicy("merge"); err == nil || errors.Is(err, nil) {
+		t.Error("Ожидали ошибку для неизвестной политики")
+	}
+}
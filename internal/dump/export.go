@@ -0,0 +1,135 @@
+package dump
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+)
+
+// ExportOption - необязательный параметр выгрузки
+type ExportOption func(*Header)
+
+// WithLastIDs сообщает наибольшие выданные ID. Репозитории не помнят удаленные
+// записи, поэтому без этого параметра ID удаленных последними постов после
+// загрузки архива будут выданы повторно.
+func WithLastIDs(lastUserID, lastPostID int) ExportOption {
+	return func(h *Header) {
+		h.LastUserID = max(h.LastUserID, lastUserID)
+		h.LastPostID = max(h.LastPostID, lastPostID)
+	}
+}
+
+// Export выгружает всех пользователей, посты и лайки в w. Репозитории
+// ссылаются на пользователей по ID, а архив - по именам: так его можно
+// читать и править вручную.
+func Export(ctx context.Context, w io.Writer, users repository.UserRepository, posts repository.PostRepository, opts ...ExportOption) (*Header, error) {
+	userList := users.List(ctx)
+	postList := posts.List(ctx)
+	slices.SortFunc(postList, func(a, b *models.Post) int { return a.ID - b.ID })
+
+	names := make(map[int]string, len(userList))
+	for _, u := range userList {
+		names[u.ID] = u.Username
+	}
+	name := func(id int) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return models.AnonymousAuthor
+	}
+
+	h := &Header{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Users:     len(userList),
+		Posts:     len(postList),
+	}
+	for _, u := range userList {
+		h.LastUserID = max(h.LastUserID, u.ID)
+	}
+	for _, p := range postList {
+		h.Likes += len(p.LikeIDs)
+		h.LastPostID = max(h.LastPostID, p.ID, p.ReplyToID, p.OriginalID)
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(h); err != nil {
+		return nil, err
+	}
+	write := func(typ string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(Record{Type: typ, Data: data})
+	}
+
+	for _, u := range userList {
+		if err := write(TypeUser, u); err != nil {
+			return nil, fmt.Errorf("пользователь %d: %w", u.ID, err)
+		}
+	}
+	for _, p := range postList {
+		post := *p
+		post.Author = name(p.AuthorID)
+		post.Likes = []string{}
+		post.Mentions = nil
+		for _, id := range p.MentionIDs {
+			post.Mentions = append(post.Mentions, name(id))
+		}
+		post.Attachments = slices.Clone(p.Attachments)
+		for i := range post.Attachments {
+			post.Attachments[i].Owner = name(post.Attachments[i].OwnerID)
+		}
+		if err := write(TypePost, &post); err != nil {
+			return nil, fmt.Errorf("пост %d: %w", p.ID, err)
+		}
+	}
+	for _, p := range postList {
+		for _, id := range p.LikeIDs {
+			if err := write(TypeLike, Like{PostID: p.ID, Username: name(id)}); err != nil {
+				return nil, fmt.Errorf("лайк поста %d: %w", p.ID, err)
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return h, bw.Flush()
+}
+
+// WriteFile выгружает данные в файл path. Запись идет во временный файл
+// рядом, поэтому прежний архив не портится при сбое.
+func WriteFile(ctx context.Context, path string, users repository.UserRepository, posts repository.PostRepository, opts ...ExportOption) (*Header, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) // после переименования файла уже нет
+
+	h, err := Export(ctx, tmp, users, posts, opts...)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h, os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,57 @@
+// Package dump выгружает пользователей, посты и лайки из репозиториев
+// в архив NDJSON и загружает их обратно с сохранением ID.
+//
+// Первая строка архива - заголовок с форматом и версией, далее по одной
+// записи на строку: {"type":"user","data":{...}}. Записи идут в порядке
+// пользователи, посты, лайки, внутри типа - по возрастанию ID.
+//
+// Известные ограничения версии 1: в архив не входят псевдонимы прежних имен
+// пользователей, журнал аудита, вебхуки, подписки, уведомления и история
+// правок (после загрузки исходной версией поста становится его текущий
+// текст). Аватары и изображения попадают в архив только метаданными: сами
+// файлы хранятся в каталоге storage.blob_dir и переносятся его копированием.
+package dump
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Format - значение поля format в заголовке архива
+const Format = "microblog-dump"
+
+// Version - текущая версия формата. Архивы более новой версии не читаются.
+const Version = 1
+
+// Типы записей
+const (
+	TypeUser = "user"
+	TypePost = "post"
+	TypeLike = "like"
+)
+
+// Header - первая строка архива
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Users     int       `json:"users"`
+	Posts     int       `json:"posts"`
+	Likes     int       `json:"likes"`
+	// Наибольшие выданные ID, включая ID удаленных постов, на которые
+	// остались ссылки: счетчики после импорта не должны выдавать их повторно
+	LastUserID int `json:"last_user_id"`
+	LastPostID int `json:"last_post_id"`
+}
+
+// Record - строка архива после заголовка
+type Record struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Like - лайк пользователя; в постах архива список Likes пуст
+type Like struct {
+	PostID   int    `json:"post_id"`
+	Username string `json:"username"`
+}
@@ -0,0 +1,505 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// maxLineBytes - предел длины одной строки архива
+const maxLineBytes = 16 << 20
+
+// MaxPostID - предел ID поста в архиве. Посты хранятся по ID без пропусков
+// слотов, поэтому огромный ID заставил бы выделить память под все
+// промежуточные записи.
+const MaxPostID = 1 << 24
+
+// ConflictPolicy - что делать с записью, которая уже есть в репозитории
+type ConflictPolicy string
+
+// Политики конфликтов
+const (
+	ConflictFail      ConflictPolicy = "fail"      // прервать импорт, ничего не меняя
+	ConflictSkip      ConflictPolicy = "skip"      // оставить существующую запись
+	ConflictOverwrite ConflictPolicy = "overwrite" // заменить существующую запись
+)
+
+// ParseConflictPolicy разбирает название политики конфликтов
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictFail, ConflictSkip, ConflictOverwrite:
+		return p, nil
+	}
+	return "", fmt.Errorf("неизвестная политика конфликтов %q (допустимо: fail, skip, overwrite)", s)
+}
+
+// Options - параметры импорта
+type Options struct {
+	Conflict ConflictPolicy // пусто - ConflictFail
+	DryRun   bool           // только проверить архив и конфликты, ничего не записывая
+	// Policy проверяет имена пользователей и тексты постов; nil - без проверки
+	Policy *validation.Policy
+}
+
+// Counts - итог импорта по одному типу записей
+type Counts struct {
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+// Report - итог импорта. При DryRun счетчики показывают, что было бы сделано.
+type Report struct {
+	Header Header `json:"header"`
+	DryRun bool   `json:"dry_run"`
+	Users  Counts `json:"users"`
+	Posts  Counts `json:"posts"`
+	Likes  Counts `json:"likes"`
+	// Наибольшие ID после импорта: с них должны продолжаться счетчики сервиса
+	LastUserID int `json:"last_user_id"`
+	LastPostID int `json:"last_post_id"`
+	// Warnings - ссылки на посты, которых нет ни в архиве, ни в репозитории
+	// (например, ответы на удаленные посты)
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// archive - прочитанный архив; line* - номера строк записей для сообщений об ошибках
+type archive struct {
+	header    Header
+	users     []*models.User
+	posts     []*models.Post
+	likes     []Like
+	userLines map[*models.User]int
+	postLines map[*models.Post]int
+	likeLines []int
+}
+
+// Import загружает архив из r в репозитории. Архив сначала читается и
+// проверяется целиком: при любой ошибке проверки или конфликте с политикой
+// ConflictFail репозитории не меняются, а ошибка перечисляет все проблемы.
+func Import(ctx context.Context, r io.Reader, users repository.UserRepository, posts repository.PostRepository, opts Options) (*Report, error) {
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictFail
+	}
+	if _, err := ParseConflictPolicy(string(opts.Conflict)); err != nil {
+		return nil, err
+	}
+
+	a, err := read(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &planner{ctx: ctx, a: a, users: users, posts: posts, opts: opts}
+	p.plan()
+
+	report := &Report{Header: a.header, DryRun: opts.DryRun, Warnings: p.warnings}
+	if len(p.problems) > 0 {
+		return report, errors.Join(p.problems...)
+	}
+	if err := p.apply(report); err != nil {
+		return report, err
+	}
+
+	report.LastUserID, report.LastPostID = a.header.LastUserID, a.header.LastPostID
+	for _, u := range users.List(ctx) {
+		report.LastUserID = max(report.LastUserID, u.ID)
+	}
+	for _, u := range a.users {
+		report.LastUserID = max(report.LastUserID, u.ID)
+	}
+	for _, p := range posts.List(ctx) {
+		report.LastPostID = max(report.LastPostID, p.ID, p.ReplyToID, p.OriginalID)
+	}
+	for _, p := range a.posts {
+		report.LastPostID = max(report.LastPostID, p.ID, p.ReplyToID, p.OriginalID)
+	}
+	return report, ctx.Err()
+}
+
+// read разбирает заголовок и записи архива
+func read(r io.Reader) (*archive, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), maxLineBytes)
+
+	a := &archive{userLines: make(map[*models.User]int), postLines: make(map[*models.Post]int)}
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if a.header.Format == "" {
+			if err := json.Unmarshal(raw, &a.header); err != nil {
+				return nil, fmt.Errorf("строка %d: заголовок архива: %w", line, err)
+			}
+			if a.header.Format != Format {
+				return nil, fmt.Errorf("строка %d: это не архив %s (format=%q)", line, Format, a.header.Format)
+			}
+			if a.header.Version < 1 || a.header.Version > Version {
+				return nil, fmt.Errorf("строка %d: версия архива %d не поддерживается (поддерживается до %d)", line, a.header.Version, Version)
+			}
+			if a.header.LastPostID < 0 || a.header.LastPostID > MaxPostID || a.header.LastUserID < 0 {
+				return nil, fmt.Errorf("строка %d: недопустимые last_user_id %d или last_post_id %d (предел ID поста %d)",
+					line, a.header.LastUserID, a.header.LastPostID, MaxPostID)
+			}
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+		var err error
+		switch rec.Type {
+		case TypeUser:
+			u := new(models.User)
+			if err = json.Unmarshal(rec.Data, u); err == nil {
+				a.users = append(a.users, u)
+				a.userLines[u] = line
+			}
+		case TypePost:
+			p := new(models.Post)
+			if err = json.Unmarshal(rec.Data, p); err == nil {
+				a.posts = append(a.posts, p)
+				a.postLines[p] = line
+			}
+		case TypeLike:
+			var l Like
+			if err = json.Unmarshal(rec.Data, &l); err == nil {
+				a.likes = append(a.likes, l)
+				a.likeLines = append(a.likeLines, line)
+			}
+		default:
+			err = fmt.Errorf("неизвестный тип записи %q", rec.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("строка %d: %w", line+1, err)
+	}
+	if a.header.Format == "" {
+		return nil, errors.New("архив пуст: нет заголовка")
+	}
+	return a, nil
+}
+
+// action - решение по одной записи
+type action int
+
+const (
+	actCreate action = iota
+	actOverwrite
+	actSkip
+)
+
+// planner проверяет архив относительно репозиториев и решает, что делать с каждой записью
+type planner struct {
+	ctx   context.Context
+	a     *archive
+	users repository.UserRepository
+	posts repository.PostRepository
+	opts  Options
+
+	userAct  map[*models.User]action
+	postAct  map[*models.Post]action
+	byName   map[string]*models.User // пользователи архива и репозитория по имени
+	postByID map[int]*models.Post    // посты архива по ID
+
+	problems []error
+	warnings []string
+}
+
+func (p *planner) problem(line int, format string, args ...any) {
+	p.problems = append(p.problems, fmt.Errorf("строка %d: %s", line, fmt.Sprintf(format, args...)))
+}
+
+// conflict применяет политику к записи, которая уже есть в репозитории
+func (p *planner) conflict(line int, what string) action {
+	switch p.opts.Conflict {
+	case ConflictSkip:
+		return actSkip
+	case ConflictOverwrite:
+		return actOverwrite
+	}
+	p.problem(line, "%s уже существует (политика конфликтов fail)", what)
+	return actSkip
+}
+
+func (p *planner) plan() {
+	p.planUsers()
+	p.planPosts()
+	p.planLikes()
+}
+
+func (p *planner) planUsers() {
+	p.userAct = make(map[*models.User]action)
+	p.byName = make(map[string]*models.User)
+	existingByID := make(map[int]*models.User)
+	for _, u := range p.users.List(p.ctx) {
+		existingByID[u.ID] = u
+		p.byName[u.Username] = u
+	}
+
+	seenID := make(map[int]bool)
+	seenName := make(map[string]bool)
+	seenCanonical := make(map[string]bool)
+	for _, u := range p.a.users {
+		line := p.a.userLines[u]
+		if u.ID <= 0 {
+			p.problem(line, "неверный ID пользователя %d", u.ID)
+			continue
+		}
+		if seenID[u.ID] || seenName[u.Username] {
+			p.problem(line, "пользователь %d (%s) встречается в архиве дважды", u.ID, u.Username)
+			continue
+		}
+		seenID[u.ID], seenName[u.Username] = true, true
+
+		if p.opts.Policy != nil {
+			if name, err := p.opts.Policy.Username(u.Username); err != nil {
+				p.problem(line, "пользователь %d: %v", u.ID, err)
+			} else if name != u.Username {
+				p.problem(line, "пользователь %d: имя %q не нормализовано (ожидалось %q)", u.ID, u.Username, name)
+			}
+		} else if u.Username == "" {
+			p.problem(line, "пользователь %d: пустое имя", u.ID)
+		}
+		u.CanonicalName = validation.CanonicalUsername(u.Username)
+		if seenCanonical[u.CanonicalName] {
+			p.problem(line, "пользователь %s неотличим от другого пользователя архива", u.Username)
+		}
+		seenCanonical[u.CanonicalName] = true
+
+		existing, err := p.users.GetByUsername(p.ctx, u.Username)
+		switch {
+		case err == nil && existing.ID != u.ID:
+			p.problem(line, "имя %s занято пользователем с другим ID (%d, в архиве %d)", u.Username, existing.ID, u.ID)
+		case err == nil:
+			p.userAct[u] = p.conflict(line, fmt.Sprintf("пользователь %s", u.Username))
+		case existingByID[u.ID] != nil:
+			p.problem(line, "ID %d занят пользователем %s", u.ID, existingByID[u.ID].Username)
+		case p.users.ExistsCanonical(p.ctx, u.CanonicalName):
+			p.problem(line, "пользователь %s неотличим от существующего пользователя", u.Username)
+		default:
+			p.userAct[u] = actCreate
+		}
+		if p.userAct[u] != actSkip {
+			p.byName[u.Username] = u
+		}
+	}
+}
+
+func (p *planner) planPosts() {
+	p.postAct = make(map[*models.Post]action)
+	p.postByID = make(map[int]*models.Post)
+	for _, post := range p.a.posts {
+		line := p.a.postLines[post]
+		if post.ID <= 0 {
+			p.problem(line, "неверный ID поста %d", post.ID)
+			continue
+		}
+		if limit := p.postIDLimit(); post.ID > limit {
+			p.problem(line, "ID поста %d больше допустимого %d", post.ID, limit)
+			continue
+		}
+		if p.postByID[post.ID] != nil {
+			p.problem(line, "пост %d встречается в архиве дважды", post.ID)
+			continue
+		}
+		p.postByID[post.ID] = post
+	}
+
+	for _, post := range p.a.posts {
+		line := p.a.postLines[post]
+		if post.ID <= 0 || p.postByID[post.ID] != post {
+			continue
+		}
+		p.checkPost(line, post)
+
+		if _, err := p.posts.GetByID(p.ctx, post.ID); err == nil {
+			p.postAct[post] = p.conflict(line, fmt.Sprintf("пост %d", post.ID))
+		} else {
+			p.postAct[post] = actCreate
+		}
+	}
+}
+
+// postIDLimit - наибольший допустимый ID поста: last_post_id заголовка,
+// а в архивах без него - MaxPostID
+func (p *planner) postIDLimit() int {
+	if p.a.header.LastPostID > 0 {
+		return p.a.header.LastPostID
+	}
+	return MaxPostID
+}
+
+// checkPost проверяет вид, автора, ссылки и текст поста
+func (p *planner) checkPost(line int, post *models.Post) {
+	if post.Kind == "" {
+		post.Kind = models.PostKindPost
+	}
+	switch post.Kind {
+	case models.PostKindPost:
+	case models.PostKindRepost, models.PostKindQuote:
+		if post.OriginalID <= 0 {
+			p.problem(line, "пост %d: у %s нет original_id", post.ID, post.Kind)
+		}
+	default:
+		p.problem(line, "пост %d: неизвестный вид %q", post.ID, post.Kind)
+	}
+
+	if author := p.byName[post.Author]; author == nil {
+		p.problem(line, "пост %d: автор %s не найден", post.ID, post.Author)
+	} else if author.ID != post.AuthorID {
+		p.problem(line, "пост %d: author_id %d не совпадает с ID пользователя %s (%d)", post.ID, post.AuthorID, post.Author, author.ID)
+	}
+
+	if p.opts.Policy != nil && post.Kind != models.PostKindRepost {
+		if content, err := p.opts.Policy.PostContent(post.Content); err != nil {
+			p.problem(line, "пост %d: %v", post.ID, err)
+		} else if content != post.Content {
+			p.problem(line, "пост %d: текст не нормализован", post.ID)
+		}
+	}
+
+	for _, ref := range []int{post.ReplyToID, post.OriginalID} {
+		if ref < 0 || ref > p.postIDLimit() {
+			p.problem(line, "пост %d ссылается на недопустимый ID %d", post.ID, ref)
+			continue
+		}
+		if ref != 0 && p.postByID[ref] == nil {
+			if _, err := p.posts.GetByID(p.ctx, ref); err != nil {
+				p.warnings = append(p.warnings, fmt.Sprintf("строка %d: пост %d ссылается на отсутствующий пост %d", line, post.ID, ref))
+			}
+		}
+	}
+}
+
+func (p *planner) planLikes() {
+	for i, l := range p.a.likes {
+		line := p.a.likeLines[i]
+		if p.byName[l.Username] == nil {
+			p.problem(line, "лайк поста %d: пользователь %s не найден", l.PostID, l.Username)
+		}
+		if p.postByID[l.PostID] == nil {
+			if _, err := p.posts.GetByID(p.ctx, l.PostID); err != nil {
+				p.problem(line, "лайк пользователя %s: пост %d не найден", l.Username, l.PostID)
+			}
+		}
+	}
+}
+
+// apply записывает запланированные изменения (при DryRun только считает их)
+func (p *planner) apply(report *Report) error {
+	count := func(c *Counts, act action) {
+		switch act {
+		case actCreate:
+			c.Created++
+		case actOverwrite:
+			c.Overwritten++
+		case actSkip:
+			c.Skipped++
+		}
+	}
+
+	for _, u := range p.a.users {
+		act := p.userAct[u]
+		count(&report.Users, act)
+		if p.opts.DryRun {
+			continue
+		}
+		var err error
+		switch act {
+		case actCreate:
+			err = p.users.Create(p.ctx, u)
+		case actOverwrite:
+			err = p.users.Update(p.ctx, u)
+		}
+		if err != nil {
+			return fmt.Errorf("пользователь %s: %w", u.Username, err)
+		}
+	}
+
+	// Лайки собираются в посты до записи; лайки к пропущенным постам не применяются.
+	// В репозитории посты ссылаются на пользователей по ID.
+	likes := make(map[int][]int)
+	for _, l := range p.a.likes {
+		if post := p.postByID[l.PostID]; post != nil && p.postAct[post] == actSkip {
+			report.Likes.Skipped++
+			continue
+		}
+		if id := p.byName[l.Username].ID; !slices.Contains(likes[l.PostID], id) {
+			likes[l.PostID] = append(likes[l.PostID], id)
+		}
+	}
+
+	posts := slices.Clone(p.a.posts)
+	slices.SortFunc(posts, func(a, b *models.Post) int { return a.ID - b.ID })
+	for _, post := range posts {
+		act := p.postAct[post]
+		count(&report.Posts, act)
+		if act == actSkip {
+			continue
+		}
+		post.LikeIDs = slices.Clone(likes[post.ID])
+		post.MentionIDs = nil
+		for _, name := range post.Mentions {
+			if u := p.byName[name]; u != nil {
+				post.MentionIDs = append(post.MentionIDs, u.ID)
+			}
+		}
+		post.Author, post.Likes, post.Mentions = "", nil, nil
+		report.Likes.Created += len(post.LikeIDs)
+		delete(likes, post.ID)
+		if p.opts.DryRun {
+			continue
+		}
+		var err error
+		if act == actCreate {
+			err = p.posts.Create(p.ctx, post)
+		} else {
+			err = p.posts.Update(p.ctx, post)
+		}
+		if err != nil {
+			return fmt.Errorf("пост %d: %w", post.ID, err)
+		}
+	}
+
+	// Лайки к постам, которые уже есть в репозитории и не входят в архив
+	for postID, ids := range likes {
+		existing, err := p.posts.GetByID(p.ctx, postID)
+		if err != nil {
+			return fmt.Errorf("пост %d: %w", postID, err)
+		}
+		var added []int
+		for _, id := range ids {
+			if slices.Contains(existing.LikeIDs, id) {
+				report.Likes.Skipped++
+			} else {
+				added = append(added, id)
+			}
+		}
+		report.Likes.Created += len(added)
+		if p.opts.DryRun || len(added) == 0 {
+			continue
+		}
+		updated := *existing
+		updated.LikeIDs = append(slices.Clone(existing.LikeIDs), added...)
+		if err := p.posts.Update(p.ctx, &updated); err != nil {
+			return fmt.Errorf("пост %d: %w", postID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package entities извлекает из текста поста хэштеги (#тег) и упоминания (@имя).
+package entities
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Entities - сущности, найденные в тексте поста
+type Entities struct {
+	Hashtags []string // нормализованные (в нижнем регистре), без '#'
+	Mentions []string // имена пользователей как написаны, без '@'
+}
+
+// Extract разбирает текст и возвращает хэштеги и упоминания без повторов,
+// в порядке первого появления. Сущность начинается с '#' или '@' в начале
+// текста или после символа, не входящего в слово (поэтому адрес почты
+// user@mail.ru не считается упоминанием), и продолжается, пока идут буквы
+// любого алфавита, цифры и '_'. Хэштег должен содержать хотя бы одну букву.
+func Extract(text string) Entities {
+	var res Entities
+	seenTags := make(map[string]bool)
+	seenMentions := make(map[string]bool)
+
+	prev := ' '
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if (r == '#' || r == '@') && !isWordRune(prev) {
+			word := scanWord(text[i+size:])
+			switch {
+			case word == "":
+			case r == '#' && strings.IndexFunc(word, unicode.IsLetter) >= 0:
+				tag := NormalizeTag(word)
+				if !seenTags[tag] {
+					seenTags[tag] = true
+					res.Hashtags = append(res.Hashtags, tag)
+				}
+			case r == '@':
+				if !seenMentions[word] {
+					seenMentions[word] = true
+					res.Mentions = append(res.Mentions, word)
+				}
+			}
+			if word != "" {
+				i += size + len(word)
+				prev, _ = utf8.DecodeLastRuneInString(word)
+				continue
+			}
+		}
+		prev = r
+		i += size
+	}
+	return res
+}
+
+// NormalizeTag приводит хэштег к виду, в котором он хранится в индексе
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// scanWord возвращает начало s, состоящее из символов слова
+func scanWord(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return !isWordRune(r) })
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestExtract проверяет разбор хэштегов и упоминаний, в том числе на кириллице
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		tags     []string
+		mentions []string
+	}{
+		{"латиница", "Hello #Go and @alice!", []string{"go"}, []string{"alice"}},
+		{"кириллица", "Привет, @Вася! #Новости_дня и #новости_ДНЯ", []string{"новости_дня"}, []string{"Вася"}},
+		{"почта не упоминание", "пишите на user@mail.ru", nil, nil},
+		{"тег без букв", "номер #123 и #", nil, nil},
+		{"тег внутри слова", "abc#tag #a#b", []string{"a"}, nil},
+		{"повторы", "@bob @bob #x #X", []string{"x"}, []string{"bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.text)
+			if !reflect.DeepEqual(got.Hashtags, tt.tags) {
+				t.Errorf("Хэштеги: ожидали %v, получили %v", tt.tags, got.Hashtags)
+			}
+			if !reflect.DeepEqual(got.Mentions, tt.mentions) {
+				t.Errorf("Упоминания: ожидали %v, получили %v", tt.mentions, got.Mentions)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// RenameUser обрабатывает POST /users/{name}/rename. Владение аккаунтом
+// API не проверяет, поэтому маршрут доступен только с токеном администратора.
+func (h *MicroBlogHandler) RenameUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.service.RenameUser(r.Context(), r.PathValue("name"), req.Username)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// followRename перенаправляет запрос по прежнему имени пользователя на
+// тот же маршрут с текущим именем, сохраняя метод и тело (307). Редирект
+// временный: после карантина имя может занять другой пользователь.
+// Запросы по текущему или неизвестному имени проходят дальше как есть.
+func (h *MicroBlogHandler) followRename(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		current, err := h.service.ResolveUsername(r.Context(), name)
+		if err != nil || current == name {
+			next(w, r)
+			return
+		}
+
+		// Собираем путь из шаблона маршрута, подставляя значения остальных
+		// параметров ({id} и т.п.) и новое имя вместо {name}
+		_, pattern, _ := strings.Cut(r.Pattern, " ")
+		segments := strings.Split(pattern, "/")
+		for i, seg := range segments {
+			param, ok := strings.CutPrefix(seg, "{")
+			if !ok {
+				continue
+			}
+			param = strings.TrimSuffix(param, "}")
+			if param == "name" {
+				segments[i] = url.PathEscape(current)
+			} else {
+				segments[i] = url.PathEscape(r.PathValue(param))
+			}
+		}
+		target := strings.Join(segments, "/")
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+	}
+}
+
+// DeleteUser обрабатывает DELETE /users/{name}?posts=delete|anonymize:
+// удаляет аккаунт, посты удаляются (по умолчанию) или обезличиваются.
+// В ответе - запись журнала аудита. Как и смена имени, требует токен
+// администратора.
+func (h *MicroBlogHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("posts")
+	if mode == "" {
+		mode = models.DeletePosts
+	}
+
+	entry, err := h.service.DeleteUser(r.Context(), r.PathValue("name"), mode)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entry); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// ListAudit обрабатывает GET /admin/audit?limit=&offset=
+func (h *MicroBlogHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	var limit, offset int
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		if *p.dst, err = queryInt(r, p.name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	list, err := h.service.ListAudit(r.Context(), limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestRenameAndDeleteUser проверяет смену имени с редиректом по прежнему
+// имени, поиск по ID и удаление аккаунта с записью в журнал аудита;
+// обе операции требуют токен администратора
+func TestRenameAndDeleteUser(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	svc := service.NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	mux := http.NewServeMux()
+	NewMicroBlogHandler(svc, WithAdminToken("secret")).RegisterRoutes(mux)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, APIPrefix+path, bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if w := do(http.MethodPost, "/register", `{"username":"`+name+`"}`); w.Code != http.StatusCreated {
+			t.Fatalf("Ошибка регистрации: %d %s", w.Code, w.Body)
+		}
+	}
+
+	// Тест 1: смена имени и удаление без токена администратора запрещены
+	for _, rq := range []struct{ method, path, body string }{
+		{http.MethodPost, "/users/alice/rename", `{"username":"mallory"}`},
+		{http.MethodDelete, "/users/alice", ""},
+	} {
+		r := httptest.NewRequest(rq.method, APIPrefix+rq.path, bytes.NewBufferString(rq.body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s без токена: ожидали 401, получили %d", rq.method, rq.path, w.Code)
+		}
+	}
+
+	// Тест 2: смена имени, прежнее имя перенаправляет на тот же маршрут
+	if w := do(http.MethodPost, "/users/alice/rename", `{"username":"alicia"}`); w.Code != http.StatusOK {
+		t.Fatalf("Ошибка смены имени: %d %s", w.Code, w.Body)
+	}
+	w := do(http.MethodGet, "/users/alice/mentions?x=1", "")
+	if w.Code != http.StatusTemporaryRedirect || w.Header().Get("Location") != APIPrefix+"/users/alicia/mentions?x=1" {
+		t.Errorf("Ожидали редирект на alicia, получили %d %q", w.Code, w.Header().Get("Location"))
+	}
+	// Редирект действует для любого метода и подставляет остальные параметры
+	for _, rq := range []struct{ method, path, location string }{
+		{http.MethodPatch, "/users/alice", "/users/alicia"},
+		{http.MethodPost, "/users/alice/follow", "/users/alicia/follow"},
+		{http.MethodDelete, "/users/alice/webhooks/7", "/users/alicia/webhooks/7"},
+	} {
+		w := do(rq.method, rq.path, "{}")
+		if w.Code != http.StatusTemporaryRedirect || w.Header().Get("Location") != APIPrefix+rq.location {
+			t.Errorf("%s %s: ожидали редирект на %s, получили %d %q", rq.method, rq.path, rq.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+	if w := do(http.MethodGet, "/users/nobody", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Неизвестное имя: ожидали 404, получили %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/register", `{"username":"alice"}`); w.Code != http.StatusConflict {
+		t.Errorf("Имя на карантине: ожидали 409, получили %d %s", w.Code, w.Body)
+	}
+
+	// Тест 3: пользователь по ID со счетчиками
+	w = do(http.MethodGet, "/users/1", "")
+	var view models.UserView
+	if err := json.NewDecoder(w.Body).Decode(&view); err != nil || view.Username != "alicia" {
+		t.Errorf("Ожидали alicia по ID 1, получили %+v (%v)", view, err)
+	}
+
+	// Тест 4: удаление аккаунта и журнал аудита
+	if w := do(http.MethodDelete, "/users/bob?posts=archive", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Неизвестный режим: ожидали 400, получили %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/users/bob", ""); w.Code != http.StatusOK {
+		t.Fatalf("Ошибка удаления: %d %s", w.Code, w.Body)
+	}
+	var audit models.AuditList
+	w = do(http.MethodGet, "/admin/audit", "")
+	if err := json.NewDecoder(w.Body).Decode(&audit); err != nil || audit.Total != 1 ||
+		audit.Entries[0].UserID != 2 || audit.Entries[0].Mode != models.DeletePosts {
+		t.Errorf("Неверный журнал аудита: %+v (%v)", audit, err)
+	}
+	var users models.UserList
+	w = do(http.MethodGet, "/users?limit=10", "")
+	if err := json.NewDecoder(w.Body).Decode(&users); err != nil || users.Total != 1 || users.Limit != 10 {
+		t.Errorf("Неверный список пользователей: %+v (%v)", users, err)
+	}
+}
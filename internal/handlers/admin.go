@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+)
+
+// AdminQueue - очередь, которой можно управлять через административный API
+type AdminQueue interface {
+	Stats() queue.Stats
+	ReplayDeadLetters() (int, error)
+}
+
+// WithAdminQueue делает очередь доступной в /admin/queues под именем name
+func WithAdminQueue(name string, q AdminQueue) Option {
+	return func(h *MicroBlogHandler) {
+		h.queues[name] = q
+	}
+}
+
+// WithAdminToken требует заголовок Authorization: Bearer <token> для /admin/*,
+// удаления аккаунта и смены имени. Без токена эти маршруты отключены.
+func WithAdminToken(token string) Option {
+	return func(h *MicroBlogHandler) {
+		h.adminToken = token
+	}
+}
+
+// requireAdmin проверяет токен административного API. Если токен не
+// настроен, маршрут недоступен: открытый по ошибке конфигурации
+// административный API хуже отключенного.
+func (h *MicroBlogHandler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.adminToken == "" {
+			http.Error(w, "Административный API отключен", http.StatusNotFound)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="microblog-admin"`)
+			http.Error(w, "Требуется токен администратора", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// QueueStats обрабатывает GET /admin/queues
+func (h *MicroBlogHandler) QueueStats(w http.ResponseWriter, r *http.Request) {
+	stats := make(map[string]queue.Stats, len(h.queues))
+	for name, q := range h.queues {
+		stats[name] = q.Stats()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// ReplayDeadLetters обрабатывает POST /admin/queues/{name}/dlq/replay
+func (h *MicroBlogHandler) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	q, ok := h.queues[name]
+	if !ok {
+		http.Error(w, "Очередь не найдена", http.StatusNotFound)
+		return
+	}
+
+	n, err := q.ReplayDeadLetters()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	resp := struct {
+		Queue     string `json:"queue"`
+		Replayed  int    `json:"replayed"`
+		Remaining int    `json:"remaining"` // не поместились в буфер и остались в DLQ
+	}{name, n, q.Stats().DeadLetters}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
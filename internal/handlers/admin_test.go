@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestRequireAdmin проверяет, что административный API без настроенного
+// токена отключен, а с токеном требует его в каждом запросе
+func TestRequireAdmin(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	likeQueue := queue.NewLikeQueue(10, 1)
+	svc := service.NewMicroBlogService(log, likeQueue)
+
+	do := func(token, auth string) int {
+		mux := http.NewServeMux()
+		NewMicroBlogHandler(svc, WithAdminToken(token), WithAdminQueue("likes", likeQueue)).RegisterRoutes(mux)
+		r := httptest.NewRequest(http.MethodGet, APIPrefix+"/admin/queues", nil)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	tests := []struct {
+		name, token, auth string
+		want              int
+	}{
+		{"токен не настроен", "", "", http.StatusNotFound},
+		{"токен не настроен, заголовок передан", "", "Bearer ", http.StatusNotFound},
+		{"без заголовка", "secret", "", http.StatusUnauthorized},
+		{"неверный токен", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"верный токен", "secret", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := do(tt.token, tt.auth); got != tt.want {
+			t.Errorf("%s: ожидали %d, получили %d", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodyBytes - ограничение размера тела запроса по умолчанию (1 МБ)
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// requestError - ошибка разбора запроса с кодом ответа
+type requestError struct {
+	status int
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// decodeJSON читает тело запроса в dst: требует Content-Type application/json,
+// ограничивает размер тела, отклоняет неизвестные поля и данные после объекта.
+// При ошибке сам отправляет ответ и возвращает false.
+func (h *MicroBlogHandler) decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := decodeJSONBody(w, r, dst, h.maxBodyBytes); err != nil {
+		http.Error(w, err.msg, err.status)
+		return false
+	}
+	return true
+}
+
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) *requestError {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return &requestError{http.StatusUnsupportedMediaType, "не указан Content-Type: ожидается application/json"}
+	}
+	if mediaType, _, err := mime.ParseMediaType(ct); err != nil || mediaType != "application/json" {
+		return &requestError{http.StatusUnsupportedMediaType, fmt.Sprintf("Content-Type %q не поддерживается: ожидается application/json", ct)}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return describeDecodeError(err, maxBytes)
+	}
+	// После объекта допустимы только пробельные символы
+	offset := dec.InputOffset()
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return describeDecodeError(err, maxBytes)
+		}
+		return &requestError{http.StatusBadRequest, fmt.Sprintf("лишние данные после JSON-объекта (смещение %d)", offset)}
+	}
+	return nil
+}
+
+// describeDecodeError превращает ошибку encoding/json в понятное сообщение с полем и позицией
+func describeDecodeError(err error, maxBytes int64) *requestError {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		maxErr    *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &maxErr):
+		return &requestError{http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("тело запроса больше %d байт", maxBytes)}
+	case errors.As(err, &syntaxErr):
+		return &requestError{http.StatusBadRequest,
+			fmt.Sprintf("синтаксическая ошибка JSON на смещении %d: %v", syntaxErr.Offset, syntaxErr)}
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			return &requestError{http.StatusBadRequest,
+				fmt.Sprintf("ожидается JSON-объект, получено %s (смещение %d)", typeErr.Value, typeErr.Offset)}
+		}
+		return &requestError{http.StatusBadRequest,
+			fmt.Sprintf("поле %q: ожидается %s, получено %s (смещение %d)", field, typeErr.Type, typeErr.Value, typeErr.Offset)}
+	case errors.Is(err, io.EOF):
+		return &requestError{http.StatusBadRequest, "тело запроса пустое"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &requestError{http.StatusBadRequest, "JSON обрывается до конца объекта"}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json не экспортирует тип этой ошибки
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return &requestError{http.StatusBadRequest, fmt.Sprintf("неизвестное поле %s", field)}
+	}
+	return &requestError{http.StatusBadRequest, "неверный формат JSON: " + err.Error()}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestDecodeJSONBody проверяет строгий разбор тела запроса и тексты ошибок
+func TestDecodeJSONBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int    // 0 - разбор успешен
+		msg         string // ожидаемый фрагмент сообщения
+	}{
+		{"успех", "application/json; charset=utf-8", `{"username":"alice"}  `, 0, ""},
+		{"без Content-Type", "", `{"username":"alice"}`, http.StatusUnsupportedMediaType, "Content-Type"},
+		{"чужой Content-Type", "text/plain", `{"username":"alice"}`, http.StatusUnsupportedMediaType, "text/plain"},
+		{"неизвестное поле", "application/json", `{"username":"alice","admin":true}`, http.StatusBadRequest, `"admin"`},
+		{"неверный тип", "application/json", `{"username":42}`, http.StatusBadRequest, `поле "username"`},
+		{"синтаксис", "application/json", `{"username":}`, http.StatusBadRequest, "смещении 13"},
+		{"обрыв", "application/json", `{"username":"al`, http.StatusBadRequest, "обрывается"},
+		{"пустое тело", "application/json", ``, http.StatusBadRequest, "пустое"},
+		{"лишние данные", "application/json", `{"username":"alice"}{}`, http.StatusBadRequest, "смещение 20"},
+		{"слишком большое", "application/json", `{"username":"` + strings.Repeat("a", 100) + `"}`, http.StatusRequestEntityTooLarge, "64 байт"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			var dst struct {
+				Username string `json:"username"`
+			}
+			err := decodeJSONBody(httptest.NewRecorder(), r, &dst, 64)
+			if tt.status == 0 {
+				if err != nil || dst.Username != "alice" {
+					t.Errorf("Ожидали успешный разбор, получили %v (%+v)", err, dst)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Ожидали ошибку %d, разбор прошел успешно", tt.status)
+			}
+			if err.status != tt.status || !strings.Contains(err.msg, tt.msg) {
+				t.Errorf("Ожидали %d с %q, получили %d: %s", tt.status, tt.msg, err.status, err.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// statusFromError сопоставляет ошибку сервиса с кодом HTTP-ответа
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrPostNotFound),
+		errors.Is(err, service.ErrOriginalNotFound), errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrAvatarNotFound), errors.Is(err, service.ErrMediaNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUserExists), errors.Is(err, service.ErrAlreadyReposted),
+		errors.Is(err, service.ErrTooManyWebhooks), errors.Is(err, service.ErrMediaAttached),
+		errors.Is(err, service.ErrTooManyPendingMedia), errors.Is(err, service.ErrUsernameReserved):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrWebhooksDisabled), errors.Is(err, service.ErrUploadsDisabled),
+		errors.Is(err, service.ErrShuttingDown):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrUnsupportedImage):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// writeServiceError отправляет ошибку сервиса с подходящим кодом ответа.
+// Ошибки проверки ввода отдаются в JSON с подробностями по каждому полю.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		resp := struct {
+			Error  string            `json:"error"`
+			Fields validation.Errors `json:"fields"`
+		}{Error: err.Error(), Fields: fields}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("ошибка кодирования JSON: %v", err)
+		}
+		return
+	}
+	http.Error(w, err.Error(), statusFromError(err))
+}
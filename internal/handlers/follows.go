@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// Follow обрабатывает POST /users/{name}/follow: подписка пользователя
+// из тела запроса на {name}
+func (h *MicroBlogHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.service.Follow(r.Context(), req.Username, r.PathValue("name")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unfollow обрабатывает DELETE /users/{name}/follow?username=
+func (h *MicroBlogHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		http.Error(w, "Не указан параметр username", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Unfollow(r.Context(), username, r.PathValue("name")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListFollowers обрабатывает GET /users/{name}/followers?limit=&offset=
+func (h *MicroBlogHandler) ListFollowers(w http.ResponseWriter, r *http.Request) {
+	h.listFollows(w, r, h.service.ListFollowers)
+}
+
+// ListFollowing обрабатывает GET /users/{name}/following?limit=&offset=
+func (h *MicroBlogHandler) ListFollowing(w http.ResponseWriter, r *http.Request) {
+	h.listFollows(w, r, h.service.ListFollowing)
+}
+
+// listFollows отдает страницу подписчиков или подписок пользователя {name}
+func (h *MicroBlogHandler) listFollows(w http.ResponseWriter, r *http.Request,
+	list func(ctx context.Context, username string, limit, offset int) (*models.UserList, error)) {
+	var limit, offset int
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		if *p.dst, err = queryInt(r, p.name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	users, err := list(r.Context(), r.PathValue("name"), limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(users); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
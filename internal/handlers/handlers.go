@@ -5,49 +5,78 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
 	"github.com/Cere6rum/MicroBlog2/internal/service"
 )
 
 // MicroBlogHandler - обработчик HTTP-запросов
 type MicroBlogHandler struct {
-	service *service.MicroBlogService
+	service      *service.MicroBlogService
+	maxBodyBytes int64
+	queues       map[string]AdminQueue // очереди, доступные через /admin/queues
+	adminToken   string                // пустой - административный API отключен
+	events       *pubsub.Hub           // nil - GET /stream отключен
+	heartbeat    time.Duration
+
+	sockets         *pubsub.Hub // nil - GET /ws отключен
+	socketHeartbeat time.Duration
+	socketAuth      SocketAuth // nil - только анонимные соединения
+	socketOrigins   []string   // разрешенные Origin кроме собственного; "*" - любые
+	// socketConns - открытые соединения /ws: http.Server.Shutdown не ждет
+	// захваченных соединений, их ждет WaitSockets
+	socketConns sync.WaitGroup
+
+	maxAvatarBytes int64
+	maxMediaBytes  int64
 }
 
-// NewMicroBlogHandler создает новый обработчик
-func NewMicroBlogHandler(svc *service.MicroBlogService) *MicroBlogHandler {
-	return &MicroBlogHandler{
-		service: svc,
+// Option - необязательный параметр обработчика
+type Option func(*MicroBlogHandler)
+
+// WithMaxBodyBytes ограничивает размер тела JSON-запросов
+func WithMaxBodyBytes(n int64) Option {
+	return func(h *MicroBlogHandler) {
+		h.maxBodyBytes = n
 	}
 }
 
-// RegisterRoutes регистрирует все маршруты
-func (h *MicroBlogHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/register", h.RegisterUser)
-	mux.HandleFunc("/posts", h.PostsHandler)
-	mux.HandleFunc("/posts/", h.LikePostHandler) // /posts/{id}/like
+// NewMicroBlogHandler создает новый обработчик
+func NewMicroBlogHandler(svc *service.MicroBlogService, opts ...Option) *MicroBlogHandler {
+	h := &MicroBlogHandler{
+		service:      svc,
+		maxBodyBytes: DefaultMaxBodyBytes,
+		queues:       make(map[string]AdminQueue),
+		heartbeat:    DefaultHeartbeat,
+
+		socketHeartbeat: DefaultHeartbeat,
+		maxAvatarBytes:  DefaultMaxAvatarBytes,
+		maxMediaBytes:   DefaultMaxMediaBytes,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // RegisterUser обрабатывает POST /register
 func (h *MicroBlogHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// Парсим JSON
 	var req struct {
 		Username string `json:"username"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Регистрируем пользователя
-	user, err := h.service.RegisterUser(req.Username)
+	user, err := h.service.RegisterUser(r.Context(), req.Username)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -59,21 +88,9 @@ func (h *MicroBlogHandler) RegisterUser(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// PostsHandler обрабатывает GET /posts и POST /posts
-func (h *MicroBlogHandler) PostsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		h.GetAllPosts(w, r)
-	case http.MethodPost:
-		h.CreatePost(w, r)
-	default:
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-	}
-}
-
-// GetAllPosts обрабатывает GET /posts
+// GetAllPosts обрабатывает GET /posts: репосты и цитаты отдаются со встроенным оригиналом
 func (h *MicroBlogHandler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
-	posts, _ := h.service.GetAllPosts()
+	posts, _ := h.service.GetTimeline(r.Context())
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(posts); err != nil {
@@ -84,17 +101,29 @@ func (h *MicroBlogHandler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
 // CreatePost обрабатывает POST /posts
 func (h *MicroBlogHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Username string `json:"username"`
-		Content  string `json:"content"`
+		Username  string `json:"username"`
+		Content   string `json:"content"`
+		ReplyToID int    `json:"reply_to_id"`
+		QuoteOfID int    `json:"quote_of_id"`
+		MediaIDs  []int  `json:"media_ids"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
-	post, err := h.service.CreatePost(req.Username, req.Content)
+	var opts []service.PostOption
+	if req.ReplyToID != 0 {
+		opts = append(opts, service.ReplyTo(req.ReplyToID))
+	}
+	if req.QuoteOfID != 0 {
+		opts = append(opts, service.Quote(req.QuoteOfID))
+	}
+	if len(req.MediaIDs) > 0 {
+		opts = append(opts, service.Attach(req.MediaIDs...))
+	}
+	post, err := h.service.CreatePost(r.Context(), req.Username, req.Content, opts...)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -105,19 +134,11 @@ func (h *MicroBlogHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// LikePostHandler обрабатывает POST /posts/{id}/like
-func (h *MicroBlogHandler) LikePostHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Парсим ID из URL (например, /posts/1/like)
-	// Простой парсинг без использования gorilla/mux
-	var postID int
-	_, err := fmt.Sscanf(r.URL.Path, "/posts/%d/like", &postID)
+// LikePost обрабатывает POST /posts/{id}/like
+func (h *MicroBlogHandler) LikePost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Неверный формат URL", http.StatusBadRequest)
+		http.Error(w, "Неверный ID поста", http.StatusBadRequest)
 		return
 	}
 
@@ -125,20 +146,284 @@ func (h *MicroBlogHandler) LikePostHandler(w http.ResponseWriter, r *http.Reques
 	var req struct {
 		Username string `json:"username"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Добавляем лайк
-	if err := h.service.LikePost(postID, req.Username); err != nil {
+	if err := h.service.LikePost(r.Context(), postID, req.Username); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write([]byte(`{"message":"Лайк успешно добавлен"}`)); err != nil {
 		log.Printf("Ошибка при отправке ответа: %v", err)
 	}
 }
+
+// Repost обрабатывает POST /posts/{id}/repost
+func (h *MicroBlogHandler) Repost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID поста", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	repost, err := h.service.Repost(r.Context(), req.Username, postID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(repost); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// EditPost обрабатывает PATCH /posts/{id}
+func (h *MicroBlogHandler) EditPost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID поста", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Content  string `json:"content"`
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	post, err := h.service.EditPost(r.Context(), postID, req.Username, req.Content)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(post); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// DeletePost обрабатывает DELETE /posts/{id}
+func (h *MicroBlogHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID поста", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.service.DeletePost(r.Context(), postID, req.Username); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPostHistory обрабатывает GET /posts/{id}/history
+func (h *MicroBlogHandler) GetPostHistory(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID поста", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.GetPostHistory(r.Context(), postID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetThread обрабатывает GET /posts/{id}/thread?depth=&limit=&offset=
+func (h *MicroBlogHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID поста", http.StatusBadRequest)
+		return
+	}
+
+	var depth, limit, offset int
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"depth", &depth}, {"limit", &limit}, {"offset", &offset}} {
+		if *p.dst, err = queryInt(r, p.name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	thread, err := h.service.GetThread(r.Context(), postID, depth, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(thread); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetPostsByTag обрабатывает GET /tags/{tag}/posts
+func (h *MicroBlogHandler) GetPostsByTag(w http.ResponseWriter, r *http.Request) {
+	posts, err := h.service.GetPostsByTag(r.Context(), r.PathValue("tag"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(posts); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetMentions обрабатывает GET /users/{name}/mentions
+func (h *MicroBlogHandler) GetMentions(w http.ResponseWriter, r *http.Request) {
+	posts, err := h.service.GetMentions(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(posts); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// ListUsers обрабатывает GET /users?limit=&offset=
+func (h *MicroBlogHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	var limit, offset int
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		if *p.dst, err = queryInt(r, p.name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	users, err := h.service.ListUsers(r.Context(), limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(users); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetUser обрабатывает GET /users/{name} со счетчиками постов и лайков.
+// Имя из одних цифр считается ID: имена пользователей начинаются с буквы.
+func (h *MicroBlogHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var user *models.User
+	var err error
+	if id, convErr := strconv.Atoi(name); convErr == nil && id > 0 {
+		user, err = h.service.GetUserByID(r.Context(), id)
+	} else {
+		user, err = h.service.GetUserByUsername(r.Context(), name)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.service.GetUserView(r.Context(), user)); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// Search обрабатывает GET /search?q=&author=&limit=&offset=
+func (h *MicroBlogHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var limit, offset int
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		if *p.dst, err = queryInt(r, p.name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	query := r.URL.Query()
+	result, err := h.service.Search(r.Context(), query.Get("q"), query.Get("author"), limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetTrending обрабатывает GET /trending?limit=
+func (h *MicroBlogHandler) GetTrending(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.GetTrending(r.Context(), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// queryInt читает неотрицательный целочисленный параметр запроса (0, если не задан)
+func queryInt(r *http.Request, name string) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("параметр %s должен быть неотрицательным целым числом", name)
+	}
+	return v, nil
+}
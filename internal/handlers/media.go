@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// DefaultMaxMediaBytes - ограничение размера одного загружаемого изображения по умолчанию (10 МБ)
+const DefaultMaxMediaBytes int64 = 10 << 20
+
+// mediaField - имя поля формы multipart/form-data с файлами изображений
+const mediaField = "file"
+
+// WithMaxMediaBytes ограничивает размер одного загружаемого изображения
+func WithMaxMediaBytes(n int64) Option {
+	return func(h *MicroBlogHandler) {
+		h.maxMediaBytes = n
+	}
+}
+
+// UploadMedia обрабатывает POST /media?username=. Изображения передаются
+// полями file формы multipart/form-data (не больше service.MaxAttachments)
+// или одно - телом запроса. Возвращает сведения о загруженных изображениях;
+// их ID передаются в media_ids при создании поста.
+func (h *MicroBlogHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		http.Error(w, "не указан параметр username", http.StatusBadRequest)
+		return
+	}
+	files, reqErr := h.readUploads(w, r, mediaField, h.maxMediaBytes, service.MaxAttachments)
+	if reqErr != nil {
+		http.Error(w, reqErr.msg, reqErr.status)
+		return
+	}
+
+	media, err := h.service.UploadMedia(r.Context(), username, files...)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(media); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetMedia обрабатывает GET /media/{id}?key=. Изображение, еще не
+// прикрепленное к посту, отдается только по ключу доступа access_key из
+// ответа на загрузку.
+func (h *MicroBlogHandler) GetMedia(w http.ResponseWriter, r *http.Request) {
+	h.serveMedia(w, r, false)
+}
+
+// GetMediaThumbnail обрабатывает GET /media/{id}/thumbnail?key=
+func (h *MicroBlogHandler) GetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	h.serveMedia(w, r, true)
+}
+
+// serveMedia отдает файл изображения. Содержимое под одним ID никогда не
+// меняется, поэтому ответ кэшируется надолго - но только после прикрепления
+// к посту: до этого изображение доступно только по ключу загрузившего.
+func (h *MicroBlogHandler) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID изображения", http.StatusBadRequest)
+		return
+	}
+
+	data, media, err := h.service.GetMedia(r.Context(), id, r.URL.Query().Get("key"), thumbnail)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	variant := "original"
+	if thumbnail {
+		variant = "thumbnail"
+	}
+	w.Header().Set("Content-Type", media.ContentType)
+	w.Header().Set("ETag", fmt.Sprintf(`"m%d-%s"`, media.ID, variant))
+	if media.PostID == 0 {
+		w.Header().Set("Cache-Control", "private, no-store")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", media.CreatedAt, bytes.NewReader(data))
+}
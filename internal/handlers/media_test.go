@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/internal/blob"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestMediaUpload проверяет загрузку нескольких изображений формой,
+// прикрепление к посту и заголовки кэширования
+func TestMediaUpload(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	store, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+	svc := service.NewMicroBlogService(log, queue.NewLikeQueue(10, 1), service.WithBlobStore(store))
+	mux := http.NewServeMux()
+	NewMicroBlogHandler(svc).RegisterRoutes(mux)
+
+	do := func(method, path, contentType string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, APIPrefix+path, bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	if w := do(http.MethodPost, "/register", "application/json", []byte(`{"username":"alice"}`), nil); w.Code != http.StatusCreated {
+		t.Fatalf("Ошибка регистрации: %d %s", w.Code, w.Body)
+	}
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatalf("Ошибка кодирования PNG: %v", err)
+	}
+	form := func(files int) (string, []byte) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for i := 0; i < files; i++ {
+			fw, _ := mw.CreateFormFile("file", fmt.Sprintf("%d.png", i))
+			fw.Write(img.Bytes())
+		}
+		mw.Close()
+		return mw.FormDataContentType(), buf.Bytes()
+	}
+
+	// Тест 1: загрузка двух файлов
+	ct, body := form(2)
+	w := do(http.MethodPost, "/media?username=alice", ct, body, nil)
+	var media []models.Media
+	if w.Code != http.StatusCreated || json.NewDecoder(w.Body).Decode(&media) != nil || len(media) != 2 {
+		t.Fatalf("Ожидали 201 с двумя изображениями, получили %d %s", w.Code, w.Body)
+	}
+	ct, body = form(service.MaxAttachments + 1)
+	if w := do(http.MethodPost, "/media?username=alice", ct, body, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидали 400 для лишних файлов, получили %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/media", "image/png", img.Bytes(), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидали 400 без username, получили %d", w.Code)
+	}
+
+	// Тест 2: до прикрепления файл отдается только по ключу из ответа на
+	// загрузку, и он не кэшируется; имени владельца для доступа недостаточно
+	thumb := fmt.Sprintf("/media/%d/thumbnail", media[0].ID)
+	if media[0].AccessKey == "" || media[0].AccessKey == media[1].AccessKey {
+		t.Fatalf("Ожидали разные ключи доступа, получили %q и %q", media[0].AccessKey, media[1].AccessKey)
+	}
+	for _, query := range []string{"", "?username=alice", "?key=" + media[1].AccessKey} {
+		if w := do(http.MethodGet, thumb+query, "", nil, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%q: ожидали 404 без ключа, получили %d", query, w.Code)
+		}
+	}
+	w = do(http.MethodGet, thumb+"?key="+media[0].AccessKey, "", nil, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "private, no-store" {
+		t.Errorf("Ожидали 200 без кэширования, получили %d %v", w.Code, w.Header())
+	}
+	if w := do(http.MethodGet, "/media/999", "", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидали 404, получили %d", w.Code)
+	}
+
+	// Тест 3: прикрепление к посту
+	req := fmt.Sprintf(`{"username":"alice","content":"Два фото","media_ids":[%d,%d]}`, media[0].ID, media[1].ID)
+	w = do(http.MethodPost, "/posts", "application/json", []byte(req), nil)
+	var post models.Post
+	if w.Code != http.StatusCreated || json.NewDecoder(w.Body).Decode(&post) != nil || len(post.Attachments) != 2 {
+		t.Fatalf("Ожидали пост с двумя вложениями, получили %d %s", w.Code, w.Body)
+	}
+	if post.Attachments[0].AccessKey != "" {
+		t.Error("Ключ доступа не должен попадать во вложения поста")
+	}
+	if w := do(http.MethodPost, "/posts", "application/json", []byte(req), nil); w.Code != http.StatusConflict {
+		t.Errorf("Ожидали 409 при повторном прикреплении, получили %d", w.Code)
+	}
+
+	// Тест 4: прикрепленный файл отдается всем с долгим кэшированием и условными запросами
+	w = do(http.MethodGet, thumb, "", nil, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" ||
+		!strings.Contains(w.Header().Get("Cache-Control"), "immutable") || w.Header().Get("ETag") == "" {
+		t.Fatalf("Неверный ответ: %d %v", w.Code, w.Header())
+	}
+	etag := w.Header().Get("ETag")
+	if w := do(http.MethodGet, thumb, "", nil, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("Ожидали 304, получили %d", w.Code)
+	}
+}
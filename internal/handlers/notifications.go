@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// ListNotifications обрабатывает GET /notifications?username=&unread=&limit=&offset=
+func (h *MicroBlogHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	username := query.Get("username")
+	if username == "" {
+		http.Error(w, "Не указан параметр username", http.StatusBadRequest)
+		return
+	}
+	var unreadOnly bool
+	if raw := query.Get("unread"); raw != "" {
+		var err error
+		if unreadOnly, err = strconv.ParseBool(raw); err != nil {
+			http.Error(w, "параметр unread должен быть true или false", http.StatusBadRequest)
+			return
+		}
+	}
+	var limit, offset int
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		if *p.dst, err = queryInt(r, p.name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	list, err := h.service.ListNotifications(r.Context(), username, unreadOnly, limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// MarkNotificationsRead обрабатывает POST /notifications/read
+func (h *MicroBlogHandler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		IDs      []int  `json:"ids"` // пусто - все уведомления
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	unread, err := h.service.MarkNotificationsRead(r.Context(), req.Username, req.IDs)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int{"unread": unread}); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetNotificationPreferences обрабатывает GET /notifications/preferences?username=
+func (h *MicroBlogHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		http.Error(w, "Не указан параметр username", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.service.NotificationPreferences(r.Context(), username)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(prefs); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// UpdateNotificationPreferences обрабатывает PATCH /notifications/preferences
+func (h *MicroBlogHandler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username    string          `json:"username"`
+		Preferences map[string]bool `json:"preferences"` // тип уведомлений -> включен
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	prefs, err := h.service.SetNotificationPreferences(r.Context(), req.Username, req.Preferences)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(prefs); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/api"
+)
+
+// TestOpenAPIMatchesRoutes проверяет, что документ OpenAPI описывает ровно
+// те маршруты, которые зарегистрированы в таблице
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	var spec struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(api.OpenAPI, &spec); err != nil {
+		t.Fatalf("Ошибка разбора openapi.json: %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != APIPrefix {
+		t.Errorf("Ожидали сервер %s, получили %+v", APIPrefix, spec.Servers)
+	}
+
+	documented := make(map[string]bool)
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var missing []string
+	h := NewMicroBlogHandler(nil)
+	for _, rt := range h.Routes() {
+		key := rt.Pattern("")
+		if !documented[key] {
+			missing = append(missing, key)
+		}
+		delete(documented, key)
+	}
+	var extra []string
+	for key := range documented {
+		extra = append(extra, key)
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+
+	if len(missing) > 0 {
+		t.Errorf("Маршруты без описания в openapi.json: %v", missing)
+	}
+	if len(extra) > 0 {
+		t.Errorf("В openapi.json описаны несуществующие маршруты: %v", extra)
+	}
+}
+
+// TestOpenAPIServed проверяет выдачу документа на /openapi.json
+func TestOpenAPIServed(t *testing.T) {
+	mux := http.NewServeMux()
+	NewMicroBlogHandler(nil).RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Ожидали 200 application/json, получили %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !json.Valid(w.Body.Bytes()) {
+		t.Error("Ответ не является корректным JSON")
+	}
+}
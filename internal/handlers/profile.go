@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// DefaultMaxAvatarBytes - ограничение размера загружаемого аватара по умолчанию (5 МБ)
+const DefaultMaxAvatarBytes int64 = 5 << 20
+
+// avatarField - имя поля формы multipart/form-data с файлом аватара
+const avatarField = "avatar"
+
+// WithMaxAvatarBytes ограничивает размер загружаемого аватара
+func WithMaxAvatarBytes(n int64) Option {
+	return func(h *MicroBlogHandler) {
+		h.maxAvatarBytes = n
+	}
+}
+
+// UpdateProfile обрабатывает PATCH /users/{name}: переданные поля заменяются,
+// пустая строка очищает поле
+func (h *MicroBlogHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.service.UpdateProfile(r.Context(), r.PathValue("name"), service.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		Location:    req.Location,
+		Website:     req.Website,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// UploadAvatar обрабатывает PUT /users/{name}/avatar. Изображение передается
+// телом запроса или полем avatar формы multipart/form-data; формат
+// определяется по содержимому, а не по заявленному типу.
+func (h *MicroBlogHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	files, reqErr := h.readUploads(w, r, avatarField, h.maxAvatarBytes, 1)
+	if reqErr != nil {
+		http.Error(w, reqErr.msg, reqErr.status)
+		return
+	}
+
+	user, err := h.service.SetAvatar(r.Context(), r.PathValue("name"), files[0])
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// GetAvatar обрабатывает GET /users/{name}/avatar?size=: отдает копию
+// не меньше size пикселей. Каждая загрузка получает новый ETag, поэтому
+// кэши проверяют актуальность условными запросами.
+func (h *MicroBlogHandler) GetAvatar(w http.ResponseWriter, r *http.Request) {
+	size, err := queryInt(r, "size")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, avatar, chosen, err := h.service.GetAvatar(r.Context(), r.PathValue("name"), size)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", avatar.ContentType)
+	w.Header().Set("ETag", fmt.Sprintf(`"v%d-%d"`, avatar.Version, chosen))
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", avatar.UpdatedAt, bytes.NewReader(data))
+}
+
+// DeleteAvatar обрабатывает DELETE /users/{name}/avatar
+func (h *MicroBlogHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteAvatar(r.Context(), r.PathValue("name")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readUploads читает загружаемые файлы: тело запроса целиком (один файл)
+// или поля field формы multipart/form-data, не больше maxFiles. Размер
+// каждого файла ограничен maxBytes.
+func (h *MicroBlogHandler) readUploads(w http.ResponseWriter, r *http.Request, field string, maxBytes int64, maxFiles int) ([][]byte, *requestError) {
+	tooLarge := &requestError{http.StatusRequestEntityTooLarge, fmt.Sprintf("файл больше %d байт", maxBytes)}
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != "multipart/form-data" {
+		// Запас в один байт отличает файл ровно в maxBytes от слишком большого
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+		if err != nil {
+			return nil, &requestError{http.StatusBadRequest, "ошибка чтения тела запроса: " + err.Error()}
+		}
+		if int64(len(data)) > maxBytes {
+			return nil, tooLarge
+		}
+		if len(data) == 0 {
+			return nil, &requestError{http.StatusBadRequest, "тело запроса пустое"}
+		}
+		return [][]byte{data}, nil
+	}
+
+	if params["boundary"] == "" {
+		return nil, &requestError{http.StatusBadRequest, "в Content-Type не указан boundary"}
+	}
+	// Заголовки частей формы тоже занимают место: даем запас сверх размера файлов
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxFiles)*maxBytes+64<<10)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, &requestError{http.StatusBadRequest, "неверная форма multipart: " + err.Error()}
+	}
+	var files [][]byte
+	for {
+		part, err := mr.NextPart()
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, io.EOF):
+			if len(files) == 0 {
+				return nil, &requestError{http.StatusBadRequest, fmt.Sprintf("в форме нет поля %q", field)}
+			}
+			return files, nil
+		case errors.As(err, &maxErr):
+			return nil, tooLarge
+		case err != nil:
+			return nil, &requestError{http.StatusBadRequest, "неверная форма multipart: " + err.Error()}
+		}
+		if part.FormName() != field {
+			part.Close()
+			continue
+		}
+		if len(files) == maxFiles {
+			part.Close()
+			return nil, &requestError{http.StatusBadRequest, fmt.Sprintf("в форме больше %d файлов %q", maxFiles, field)}
+		}
+		data, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+		part.Close()
+		switch {
+		case errors.As(err, &maxErr), err == nil && int64(len(data)) > maxBytes:
+			return nil, tooLarge
+		case err != nil:
+			return nil, &requestError{http.StatusBadRequest, "ошибка чтения формы: " + err.Error()}
+		case len(data) == 0:
+			return nil, &requestError{http.StatusBadRequest, fmt.Sprintf("поле %q пустое", field)}
+		}
+		files = append(files, data)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/internal/blob"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestAvatarUpload проверяет загрузку формой и телом, предел размера
+// и условные запросы к аватару
+func TestAvatarUpload(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	store, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+	svc := service.NewMicroBlogService(log, queue.NewLikeQueue(10, 1), service.WithBlobStore(store))
+	mux := http.NewServeMux()
+	NewMicroBlogHandler(svc, WithMaxAvatarBytes(64<<10)).RegisterRoutes(mux)
+
+	do := func(method, path, contentType string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, APIPrefix+path, bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	if w := do(http.MethodPost, "/register", "application/json", []byte(`{"username":"alice"}`), nil); w.Code != http.StatusCreated {
+		t.Fatalf("Ошибка регистрации: %d %s", w.Code, w.Body)
+	}
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatalf("Ошибка кодирования PNG: %v", err)
+	}
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	mw.WriteField("comment", "поле до файла пропускается")
+	fw, _ := mw.CreateFormFile("avatar", "photo.jpg") // имя и тип файла не важны
+	fw.Write(img.Bytes())
+	mw.Close()
+
+	// Тест 1: загрузка формой
+	if w := do(http.MethodPut, "/users/alice/avatar", mw.FormDataContentType(), form.Bytes(), nil); w.Code != http.StatusOK ||
+		!strings.Contains(w.Body.String(), `"avatar"`) {
+		t.Fatalf("Ожидали 200 с аватаром, получили %d %s", w.Code, w.Body)
+	}
+
+	// Тест 2: чтение и условный запрос по ETag
+	w := do(http.MethodGet, "/users/alice/avatar?size=100", "", nil, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" || !strings.HasSuffix(etag, `-128"`) ||
+		w.Header().Get("Cache-Control") == "" {
+		t.Fatalf("Неверный ответ: %d %v", w.Code, w.Header())
+	}
+	if w := do(http.MethodGet, "/users/alice/avatar?size=100", "", nil, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("Ожидали 304, получили %d", w.Code)
+	}
+
+	// Тест 3: тело запроса, неподдерживаемый формат и предел размера
+	if w := do(http.MethodPut, "/users/alice/avatar", "image/png", img.Bytes(), nil); w.Code != http.StatusOK {
+		t.Errorf("Ожидали 200 для загрузки телом, получили %d %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodGet, "/users/alice/avatar?size=100", "", nil, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusOK {
+		t.Errorf("После новой загрузки ETag должен смениться, получили %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/users/alice/avatar", "image/png", []byte("GIF89a не совсем"), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидали 400 для поврежденного изображения, получили %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/users/alice/avatar", "image/svg+xml", []byte("<svg/>"), nil); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Ожидали 415, получили %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/users/alice/avatar", "image/png", make([]byte, 64<<10+1), nil); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Ожидали 413, получили %d", w.Code)
+	}
+
+	// Тест 4: удаление
+	if w := do(http.MethodDelete, "/users/alice/avatar", "", nil, nil); w.Code != http.StatusNoContent {
+		t.Errorf("Ожидали 204, получили %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/users/alice/avatar", "", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидали 404 после удаления, получили %d", w.Code)
+	}
+}
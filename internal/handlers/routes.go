@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Cere6rum/MicroBlog2/api"
+)
+
+// APIPrefix - префикс текущей версии API
+const APIPrefix = "/api/v1"
+
+// Route - один маршрут API. Таблица маршрутов используется и для
+// регистрации в ServeMux, и для выдачи списка через GET /api/v1/routes.
+type Route struct {
+	Method  string           `json:"method"`
+	Path    string           `json:"path"` // шаблон ServeMux без префикса версии
+	Summary string           `json:"summary"`
+	Handler http.HandlerFunc `json:"-"`
+}
+
+// Pattern возвращает шаблон ServeMux с префиксом prefix
+func (rt Route) Pattern(prefix string) string {
+	return rt.Method + " " + prefix + rt.Path
+}
+
+// Routes возвращает таблицу маршрутов API. Маршруты /users/{name}/...
+// обернуты в followRename: запрос по прежнему имени перенаправляется на
+// текущее.
+func (h *MicroBlogHandler) Routes() []Route {
+	routes := []Route{
+		{http.MethodPost, "/register", "Регистрация пользователя", h.RegisterUser},
+		{http.MethodGet, "/posts", "Лента постов", h.GetAllPosts},
+		{http.MethodPost, "/posts", "Создание поста, ответа или цитаты", h.CreatePost},
+		{http.MethodPatch, "/posts/{id}", "Правка поста", h.EditPost},
+		{http.MethodDelete, "/posts/{id}", "Удаление поста вместе с репостами", h.DeletePost},
+		{http.MethodPost, "/posts/{id}/like", "Лайк поста", h.LikePost},
+		{http.MethodPost, "/posts/{id}/repost", "Репост", h.Repost},
+		{http.MethodGet, "/posts/{id}/history", "История правок поста", h.GetPostHistory},
+		{http.MethodGet, "/posts/{id}/thread", "Обсуждение поста", h.GetThread},
+		{http.MethodGet, "/tags/{tag}/posts", "Посты с хэштегом", h.GetPostsByTag},
+		{http.MethodPost, "/media", "Загрузка изображений для поста", h.UploadMedia},
+		{http.MethodGet, "/media/{id}", "Изображение", h.GetMedia},
+		{http.MethodGet, "/media/{id}/thumbnail", "Миниатюра изображения", h.GetMediaThumbnail},
+		{http.MethodGet, "/users", "Список пользователей", h.ListUsers},
+		{http.MethodGet, "/users/{name}", "Пользователь по имени", h.GetUser},
+		{http.MethodPatch, "/users/{name}", "Изменение профиля", h.UpdateProfile},
+		{http.MethodDelete, "/users/{name}", "Удаление аккаунта", h.requireAdmin(h.DeleteUser)},
+		{http.MethodPost, "/users/{name}/rename", "Смена имени пользователя", h.requireAdmin(h.RenameUser)},
+		{http.MethodPut, "/users/{name}/avatar", "Загрузка аватара", h.UploadAvatar},
+		{http.MethodGet, "/users/{name}/avatar", "Аватар пользователя", h.GetAvatar},
+		{http.MethodDelete, "/users/{name}/avatar", "Удаление аватара", h.DeleteAvatar},
+		{http.MethodGet, "/users/{name}/mentions", "Посты с упоминанием пользователя", h.GetMentions},
+		{http.MethodPost, "/users/{name}/follow", "Подписка на пользователя", h.Follow},
+		{http.MethodDelete, "/users/{name}/follow", "Отмена подписки", h.Unfollow},
+		{http.MethodGet, "/users/{name}/followers", "Подписчики пользователя", h.ListFollowers},
+		{http.MethodGet, "/users/{name}/following", "Подписки пользователя", h.ListFollowing},
+		{http.MethodPost, "/users/{name}/webhooks", "Регистрация вебхука", h.CreateWebhook},
+		{http.MethodGet, "/users/{name}/webhooks", "Вебхуки пользователя", h.ListWebhooks},
+		{http.MethodDelete, "/users/{name}/webhooks/{id}", "Удаление вебхука", h.DeleteWebhook},
+		{http.MethodPost, "/users/{name}/webhooks/{id}/enable", "Включение отключенного вебхука", h.EnableWebhook},
+		{http.MethodGet, "/users/{name}/webhooks/{id}/deliveries", "Журнал доставок вебхука", h.ListWebhookDeliveries},
+		{http.MethodGet, "/notifications", "Уведомления пользователя", h.ListNotifications},
+		{http.MethodPost, "/notifications/read", "Отметка уведомлений прочитанными", h.MarkNotificationsRead},
+		{http.MethodGet, "/notifications/preferences", "Настройки уведомлений", h.GetNotificationPreferences},
+		{http.MethodPatch, "/notifications/preferences", "Изменение настроек уведомлений", h.UpdateNotificationPreferences},
+		{http.MethodGet, "/search", "Полнотекстовый поиск", h.Search},
+		{http.MethodGet, "/trending", "Популярные хэштеги и посты", h.GetTrending},
+		{http.MethodGet, "/stream", "Поток событий о постах и лайках (SSE)", h.Stream},
+		{http.MethodGet, "/ws", "WebSocket: подписки на события и лайки", h.Socket},
+		{http.MethodGet, "/routes", "Список маршрутов API", h.ListRoutes},
+		{http.MethodGet, "/admin/queues", "Состояние очередей", h.requireAdmin(h.QueueStats)},
+		{http.MethodPost, "/admin/queues/{name}/dlq/replay", "Повтор недоставленных событий очереди", h.requireAdmin(h.ReplayDeadLetters)},
+		{http.MethodGet, "/admin/audit", "Журнал аудита", h.requireAdmin(h.ListAudit)},
+	}
+	for i, rt := range routes {
+		if rt.Path == "/users/{name}" || strings.HasPrefix(rt.Path, "/users/{name}/") {
+			routes[i].Handler = h.followRename(rt.Handler)
+		}
+	}
+	return routes
+}
+
+// RegisterRoutes регистрирует маршруты с префиксом APIPrefix. Для старых
+// клиентов те же маршруты доступны без префикса с заголовком Deprecation.
+// Запрос с неподходящим методом ServeMux отклоняет сам: 405 и заголовок Allow.
+// Описание API в формате OpenAPI доступно на /openapi.json.
+func (h *MicroBlogHandler) RegisterRoutes(mux *http.ServeMux) {
+	for _, rt := range h.Routes() {
+		mux.HandleFunc(rt.Pattern(APIPrefix), rt.Handler)
+		mux.Handle(rt.Pattern(""), deprecated(rt.Handler))
+	}
+	mux.HandleFunc("GET /openapi.json", h.OpenAPISpec)
+}
+
+// ListRoutes обрабатывает GET /routes
+func (h *MicroBlogHandler) ListRoutes(w http.ResponseWriter, r *http.Request) {
+	routes := h.Routes()
+	for i := range routes {
+		routes[i].Path = APIPrefix + routes[i].Path
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(routes); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// OpenAPISpec обрабатывает GET /openapi.json
+func (h *MicroBlogHandler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(api.OpenAPI); err != nil {
+		log.Printf("Ошибка при отправке ответа: %v", err)
+	}
+}
+
+// deprecated помечает ответ маршрута без версии как устаревший и указывает замену
+func deprecated(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+APIPrefix+r.URL.Path+`>; rel="successor-version"`)
+		next(w, r)
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestRoutes проверяет сопоставление путей, 405 с Allow и таблицу маршрутов
+func TestRoutes(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	mux := http.NewServeMux()
+	NewMicroBlogHandler(service.NewMicroBlogService(log, queue.NewLikeQueue(10, 1))).RegisterRoutes(mux)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	// Тест 1: лишние символы после /like больше не считаются лайком
+	if w := do(http.MethodPost, APIPrefix+"/posts/1/likexyz", `{"username":"alice"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидали 404 для /posts/1/likexyz, получили %d", w.Code)
+	}
+
+	// Тест 2: неподдерживаемый метод
+	w := do(http.MethodDelete, APIPrefix+"/posts", "")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Ожидали 405, получили %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); !strings.Contains(allow, "GET") || !strings.Contains(allow, "POST") {
+		t.Errorf("Заголовок Allow должен содержать GET и POST, получили %q", allow)
+	}
+
+	// Тест 3: маршрут без версии работает, но помечен устаревшим
+	if w := do(http.MethodPost, "/register", `{"username":"alice"}`); w.Code != http.StatusCreated || w.Header().Get("Deprecation") != "true" {
+		t.Errorf("Ожидали 201 с Deprecation, получили %d %q", w.Code, w.Header().Get("Deprecation"))
+	}
+	if w := do(http.MethodPost, APIPrefix+"/posts/abc/like", `{"username":"alice"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидали 400 для нечислового ID, получили %d", w.Code)
+	}
+
+	// Тест 4: таблица маршрутов
+	w = do(http.MethodGet, APIPrefix+"/routes", "")
+	var routes []Route
+	if err := json.NewDecoder(w.Body).Decode(&routes); err != nil {
+		t.Fatalf("Ошибка разбора списка маршрутов: %v", err)
+	}
+	found := false
+	for _, rt := range routes {
+		if rt.Method == http.MethodPost && rt.Path == APIPrefix+"/posts/{id}/like" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("В списке нет POST %s/posts/{id}/like: %+v", APIPrefix, routes)
+	}
+}
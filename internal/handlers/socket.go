@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+)
+
+// Типы сообщений WebSocket API (GET /ws). Обе стороны обмениваются
+// JSON-объектами с полем type.
+const (
+	socketSubscribe   = "subscribe"   // клиент: подписка на пользователей, хэштеги, посты
+	socketUnsubscribe = "unsubscribe" // клиент: отмена подписки
+	socketLike        = "like"        // клиент: лайк поста от имени пользователя соединения
+	socketPing        = "ping"        // обе стороны: проверка связи
+	socketPong        = "pong"        // обе стороны: ответ на ping
+	socketHello       = "hello"       // сервер: соединение установлено
+	socketEvent       = "event"       // сервер: событие по подписке
+	socketAck         = "ack"         // сервер: запрос клиента выполнен
+	socketError       = "error"       // сервер: запрос клиента отклонен
+)
+
+const (
+	// maxSocketMessage - предел размера одного сообщения клиента
+	maxSocketMessage = 64 << 10
+	// maxSocketTopics - предел подписок на одно соединение
+	maxSocketTopics = 256
+	// socketSendBuffer - ответов клиенту в очереди записи; клиент, который
+	// не забирает ответы, отключается
+	socketSendBuffer = 16
+)
+
+// SocketAuth определяет пользователя WebSocket-соединения по учетным данным
+// запроса рукопожатия (токен, cookie сессии). Пустое имя - анонимное
+// соединение: подписки без лайков. Ошибка отклоняет соединение с кодом 401.
+type SocketAuth func(r *http.Request) (string, error)
+
+// TokenSocketAuth определяет пользователя по статическому токену: токен
+// передается в заголовке Authorization: Bearer <token> или в параметре
+// access_token (браузерный WebSocket не умеет задавать заголовки).
+// users - имя пользователя по токену. Без токена соединение анонимное,
+// неизвестный токен отклоняется.
+func TokenSocketAuth(users map[string]string) SocketAuth {
+	return func(r *http.Request) (string, error) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("access_token")
+		}
+		if token == "" {
+			return "", nil
+		}
+		for known, username := range users {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+				return username, nil
+			}
+		}
+		return "", errors.New("неизвестный токен")
+	}
+}
+
+// WithWebSocket включает GET /ws: события читаются из хаба, сервер шлет
+// ping каждые heartbeat и закрывает соединение, если от клиента ничего
+// не приходило два периода подряд
+func WithWebSocket(hub *pubsub.Hub, heartbeat time.Duration) Option {
+	return func(h *MicroBlogHandler) {
+		h.sockets = hub
+		if heartbeat > 0 {
+			h.socketHeartbeat = heartbeat
+		}
+	}
+}
+
+// WithSocketAuth задает проверку учетных данных WebSocket-соединения. Без нее
+// все соединения анонимные: имя пользователя, которое клиент назвал сам,
+// не дает права ставить лайки от этого имени.
+func WithSocketAuth(auth SocketAuth) Option {
+	return func(h *MicroBlogHandler) {
+		h.socketAuth = auth
+	}
+}
+
+// WithSocketOrigins разрешает подключение к GET /ws со страниц других
+// источников (например, https://app.example.com); "*" разрешает любые.
+// Собственный источник сервера и клиенты без заголовка Origin разрешены всегда.
+func WithSocketOrigins(origins ...string) Option {
+	return func(h *MicroBlogHandler) {
+		h.socketOrigins = origins
+	}
+}
+
+// socketRequest - сообщение клиента
+type socketRequest struct {
+	Type   string   `json:"type"`
+	ID     string   `json:"id,omitempty"` // возвращается в ack или error
+	Users  []string `json:"users,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Posts  []int    `json:"posts,omitempty"`
+	PostID int      `json:"post_id,omitempty"`
+}
+
+// socketMessage - сообщение сервера
+type socketMessage struct {
+	Type        string          `json:"type"`
+	ID          string          `json:"id,omitempty"`
+	User        string          `json:"user,omitempty"`         // hello: пользователь соединения
+	HeartbeatMS int64           `json:"heartbeat_ms,omitempty"` // hello: период ping
+	EventID     uint64          `json:"event_id,omitempty"`
+	Event       string          `json:"event,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Topics      []string        `json:"topics,omitempty"` // ack подписки: текущие подписки
+	Error       string          `json:"error,omitempty"`
+	Status      int             `json:"status,omitempty"` // код HTTP, соответствующий ошибке
+}
+
+// Socket обрабатывает GET /ws. Пользователь определяется один раз при
+// рукопожатии через SocketAuth, лайки из соединения ставятся только от его имени.
+func (h *MicroBlogHandler) Socket(w http.ResponseWriter, r *http.Request) {
+	if h.sockets == nil {
+		http.Error(w, "WebSocket API отключен", http.StatusServiceUnavailable)
+		return
+	}
+	// Браузер подключается к WebSocket с любой страницы и передает cookie,
+	// поэтому чужие источники отклоняются до проверки учетных данных
+	if !h.socketOriginAllowed(r) {
+		http.Error(w, "Подключение с этого источника не разрешено", http.StatusForbidden)
+		return
+	}
+
+	var username string
+	if h.socketAuth != nil {
+		var err error
+		if username, err = h.socketAuth(r); err != nil {
+			http.Error(w, "Не удалось определить пользователя: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	h.socketConns.Add(1)
+	defer h.socketConns.Done()
+	websocket.Server{
+		// Origin уже проверен; стандартная проверка отклонила бы клиентов без него
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.serveSocket(ws, username)
+		},
+	}.ServeHTTP(w, r)
+}
+
+// WaitSockets ждет завершения обработчиков /ws, в том числе начатых ими
+// лайков. Вызывается после закрытия хаба событий и до остановки очередей:
+// иначе обработчик может отправить лайк в уже остановленную очередь.
+// Возвращает ошибку контекста, если соединения не закрылись вовремя.
+func (h *MicroBlogHandler) WaitSockets(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.socketConns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// socketOriginAllowed разрешает запросы без Origin (не из браузера),
+// с собственного источника сервера и из списка WithSocketOrigins
+func (h *MicroBlogHandler) socketOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range h.socketOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// socketConn - состояние одного WebSocket-соединения
+type socketConn struct {
+	ws   *websocket.Conn
+	user string
+	out  chan socketMessage // ответы на запросы клиента, отправляет только writeSocket
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+// serveSocket обслуживает соединение: чтение запросов идет в отдельной
+// горутине, вся запись - в текущей
+func (h *MicroBlogHandler) serveSocket(ws *websocket.Conn, username string) {
+	defer ws.Close()
+
+	// Дедлайны HTTP-сервера остаются на захваченном соединении, дальше
+	// ими управляют циклы чтения и записи
+	_ = ws.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = maxSocketMessage
+
+	sub, err := h.sockets.Subscribe(0)
+	if err != nil {
+		return
+	}
+	defer sub.Close()
+
+	c := &socketConn{
+		ws:     ws,
+		user:   username,
+		out:    make(chan socketMessage, socketSendBuffer),
+		topics: make(map[string]bool),
+	}
+	// Запрос рукопожатия уже завершен, но его трассировка нужна для лайков
+	ctx := context.WithoutCancel(ws.Request().Context())
+
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		h.readSocket(ctx, c)
+	}()
+
+	h.writeSocket(c, sub, readDone)
+	_ = ws.Close()
+	<-readDone
+}
+
+// readSocket читает запросы клиента, пока соединение открыто
+func (h *MicroBlogHandler) readSocket(ctx context.Context, c *socketConn) {
+	for {
+		_ = c.ws.SetReadDeadline(time.Now().Add(2 * h.socketHeartbeat))
+
+		var req socketRequest
+		err := websocket.JSON.Receive(c.ws, &req)
+		var reply *socketMessage
+		switch {
+		case err == nil:
+			reply = h.handleSocketRequest(ctx, c, req)
+		case errors.Is(err, websocket.ErrFrameTooLarge):
+			reply = socketFailure(req.ID, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("сообщение больше %d байт", maxSocketMessage))
+		case isJSONError(err):
+			reply = socketFailure("", http.StatusBadRequest, "неверный JSON: "+err.Error())
+		default:
+			// Соединение закрыто клиентом, сервером или по таймауту
+			return
+		}
+
+		if reply == nil {
+			continue
+		}
+		select {
+		case c.out <- *reply:
+		default:
+			// Клиент не забирает ответы: закрываем соединение, а не копим их
+			_ = c.ws.Close()
+			return
+		}
+	}
+}
+
+// writeSocket отправляет клиенту события по подпискам, ответы и ping
+func (h *MicroBlogHandler) writeSocket(c *socketConn, sub *pubsub.Subscription, readDone <-chan struct{}) {
+	write := func(msg socketMessage) bool {
+		_ = c.ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return websocket.JSON.Send(c.ws, msg) == nil
+	}
+
+	if !write(socketMessage{Type: socketHello, User: c.user, HeartbeatMS: h.socketHeartbeat.Milliseconds()}) {
+		return
+	}
+
+	ping := time.NewTicker(h.socketHeartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				if err := sub.Err(); errors.Is(err, pubsub.ErrSlowConsumer) {
+					write(*socketFailure("", http.StatusServiceUnavailable, err.Error()))
+				}
+				return
+			}
+			if !c.matches(event) {
+				continue
+			}
+			if !write(socketMessage{Type: socketEvent, EventID: event.ID, Event: event.Type, Data: event.Data}) {
+				return
+			}
+
+		case msg := <-c.out:
+			if !write(msg) {
+				return
+			}
+
+		case <-ping.C:
+			if !write(socketMessage{Type: socketPing}) {
+				return
+			}
+
+		case <-readDone:
+			return
+		}
+	}
+}
+
+// handleSocketRequest выполняет запрос клиента и возвращает ответ (nil - без ответа)
+func (h *MicroBlogHandler) handleSocketRequest(ctx context.Context, c *socketConn, req socketRequest) *socketMessage {
+	switch req.Type {
+	case socketSubscribe, socketUnsubscribe:
+		topics := requestTopics(req)
+		if len(topics) == 0 {
+			return socketFailure(req.ID, http.StatusBadRequest, "не указаны users, tags или posts")
+		}
+		current, err := c.subscribe(topics, req.Type == socketSubscribe)
+		if err != nil {
+			return socketFailure(req.ID, http.StatusBadRequest, err.Error())
+		}
+		return &socketMessage{Type: socketAck, ID: req.ID, Topics: current}
+
+	case socketLike:
+		if c.user == "" {
+			return socketFailure(req.ID, http.StatusUnauthorized, "лайк недоступен анонимному соединению")
+		}
+		if req.PostID <= 0 {
+			return socketFailure(req.ID, http.StatusBadRequest, "неверный post_id")
+		}
+		if err := h.service.LikePost(ctx, req.PostID, c.user); err != nil {
+			return socketFailure(req.ID, statusFromError(err), err.Error())
+		}
+		return &socketMessage{Type: socketAck, ID: req.ID}
+
+	case socketPing:
+		return &socketMessage{Type: socketPong, ID: req.ID}
+
+	case socketPong:
+		// Ответ на ping сервера: дедлайн чтения уже продлен
+		return nil
+
+	default:
+		return socketFailure(req.ID, http.StatusBadRequest, fmt.Sprintf("неизвестный тип сообщения %q", req.Type))
+	}
+}
+
+// requestTopics собирает темы из запроса подписки
+func requestTopics(req socketRequest) []string {
+	var topics []string
+	for _, name := range req.Users {
+		if name != "" {
+			topics = append(topics, pubsub.UserTopic(name))
+		}
+	}
+	for _, tag := range req.Tags {
+		// Хэштеги в постах хранятся в нижнем регистре, без '#'
+		if tag = strings.ToLower(strings.TrimPrefix(tag, "#")); tag != "" {
+			topics = append(topics, pubsub.TagTopic(tag))
+		}
+	}
+	for _, id := range req.Posts {
+		if id > 0 {
+			topics = append(topics, pubsub.PostTopic(id))
+		}
+	}
+	return topics
+}
+
+// subscribe добавляет (add) или убирает темы и возвращает текущие подписки
+func (c *socketConn) subscribe(topics []string, add bool) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if add {
+		added := 0
+		for _, t := range topics {
+			if !c.topics[t] {
+				added++
+			}
+		}
+		if len(c.topics)+added > maxSocketTopics {
+			return nil, fmt.Errorf("не больше %d подписок на соединение", maxSocketTopics)
+		}
+	}
+	for _, t := range topics {
+		if add {
+			c.topics[t] = true
+		} else {
+			delete(c.topics, t)
+		}
+	}
+
+	current := make([]string, 0, len(c.topics))
+	for t := range c.topics {
+		current = append(current, t)
+	}
+	sort.Strings(current)
+	return current, nil
+}
+
+// matches проверяет, подписан ли клиент на одну из тем события
+func (c *socketConn) matches(event pubsub.Event) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, t := range event.Topics {
+		if c.topics[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// socketFailure формирует сообщение об ошибке запроса id
+func socketFailure(id string, status int, msg string) *socketMessage {
+	return &socketMessage{Type: socketError, ID: id, Error: msg, Status: status}
+}
+
+// isJSONError отличает ошибку разбора сообщения от ошибки соединения
+func isJSONError(err error) bool {
+	var syntax *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntax) || errors.As(err, &typeErr)
+}
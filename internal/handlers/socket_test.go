@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestSocket проверяет подписки, лайк из соединения, аутентификацию и Origin
+func TestSocket(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	ctx := context.Background()
+	hub := pubsub.NewHub(10, 0)
+	likeQueue := queue.NewLikeQueue(10, 1)
+	svc := service.NewMicroBlogService(log, likeQueue, service.WithEventHub(hub))
+	likeQueue.Start(svc.ProcessLikeEvent)
+	defer likeQueue.Stop()
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := svc.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	// Токен из параметра token; без токена соединение анонимное
+	auth := func(r *http.Request) (string, error) {
+		switch token := r.URL.Query().Get("token"); token {
+		case "":
+			return "", nil
+		case "bob-token":
+			return "bob", nil
+		default:
+			return "", errors.New("неверный токен")
+		}
+	}
+	handler := NewMicroBlogHandler(svc,
+		WithWebSocket(hub, time.Minute),
+		WithSocketAuth(auth),
+		WithSocketOrigins("https://app.example.com"),
+	)
+	handler.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer hub.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + APIPrefix + "/ws"
+	dial := func(query string) *websocket.Conn {
+		ws, err := websocket.Dial(wsURL+query, "", srv.URL)
+		if err != nil {
+			t.Fatalf("Ошибка подключения: %v", err)
+		}
+		_ = ws.SetDeadline(time.Now().Add(5 * time.Second))
+		return ws
+	}
+	send := func(ws *websocket.Conn, req socketRequest) {
+		if err := websocket.JSON.Send(ws, req); err != nil {
+			t.Fatalf("Ошибка отправки: %v", err)
+		}
+	}
+	// recv читает сообщения до первого, не являющегося ping
+	recv := func(ws *websocket.Conn) socketMessage {
+		for {
+			var msg socketMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				t.Fatalf("Ошибка чтения: %v", err)
+			}
+			if msg.Type != socketPing {
+				return msg
+			}
+		}
+	}
+
+	// Тест 1: неверные учетные данные и чужой источник отклоняются
+	if _, err := websocket.Dial(wsURL+"?token=wrong", "", srv.URL); err == nil {
+		t.Error("Ожидали отказ для неверного токена")
+	}
+	if _, err := websocket.Dial(wsURL+"?token=bob-token", "", "http://evil.example"); err == nil {
+		t.Error("Ожидали отказ для чужого Origin")
+	}
+	if ws, err := websocket.Dial(wsURL, "", "https://app.example.com"); err != nil {
+		t.Errorf("Ожидали подключение с разрешенного Origin, получили %v", err)
+	} else {
+		ws.Close()
+	}
+
+	// Тест 2: подписка на хэштег - приходят только подходящие посты
+	bob := dial("?token=bob-token")
+	defer bob.Close()
+	if msg := recv(bob); msg.Type != socketHello || msg.User != "bob" {
+		t.Fatalf("Ожидали hello для bob, получили %+v", msg)
+	}
+	send(bob, socketRequest{Type: socketSubscribe, ID: "1", Tags: []string{"#Go"}})
+	if msg := recv(bob); msg.Type != socketAck || msg.ID != "1" || len(msg.Topics) != 1 || msg.Topics[0] != "tag:go" {
+		t.Fatalf("Ожидали ack с tag:go, получили %+v", msg)
+	}
+	if _, err := svc.CreatePost(ctx, "alice", "Без хэштега"); err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	post, err := svc.CreatePost(ctx, "alice", "Пишем на #go")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if msg := recv(bob); msg.Type != socketEvent || msg.Event != pubsub.EventPostCreated || !strings.Contains(string(msg.Data), "#go") {
+		t.Fatalf("Ожидали событие о посте с #go, получили %+v", msg)
+	}
+
+	// Тест 3: лайк из соединения ставится от имени bob и приходит событием
+	send(bob, socketRequest{Type: socketLike, ID: "2", PostID: post.ID})
+	if msg := recv(bob); msg.Type != socketAck || msg.ID != "2" {
+		t.Fatalf("Ожидали ack лайка, получили %+v", msg)
+	}
+	if msg := recv(bob); msg.Event != pubsub.EventPostLiked || !strings.Contains(string(msg.Data), `"username":"bob"`) {
+		t.Errorf("Ожидали post.liked от bob, получили %+v", msg)
+	}
+
+	// Тест 4: ошибки запросов возвращаются с кодом и не закрывают соединение
+	send(bob, socketRequest{Type: socketLike, ID: "3", PostID: 999})
+	if msg := recv(bob); msg.Type != socketError || msg.ID != "3" || msg.Status != http.StatusNotFound {
+		t.Errorf("Ожидали ошибку 404, получили %+v", msg)
+	}
+	send(bob, socketRequest{Type: socketPing, ID: "4"})
+	if msg := recv(bob); msg.Type != socketPong || msg.ID != "4" {
+		t.Errorf("Ожидали pong, получили %+v", msg)
+	}
+
+	// Тест 5: имя без учетных данных не дает прав - соединение анонимное
+	// и не может ставить лайки
+	anon := dial("?username=bob")
+	defer anon.Close()
+	if msg := recv(anon); msg.Type != socketHello || msg.User != "" {
+		t.Fatalf("Ожидали анонимный hello, получили %+v", msg)
+	}
+	send(anon, socketRequest{Type: socketLike, ID: "5", PostID: post.ID})
+	if msg := recv(anon); msg.Type != socketError || msg.Status != http.StatusUnauthorized {
+		t.Errorf("Ожидали ошибку 401, получили %+v", msg)
+	}
+
+	// Тест 6: WaitSockets ждет открытые соединения, пока хаб их не закроет
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := handler.WaitSockets(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Ожидали таймаут при открытых соединениях, получили %v", err)
+	}
+	hub.Close()
+	waitCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := handler.WaitSockets(waitCtx); err != nil {
+		t.Errorf("Ожидали закрытия соединений после закрытия хаба, получили %v", err)
+	}
+}
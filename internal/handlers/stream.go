@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+)
+
+// DefaultHeartbeat - период комментариев-пингов в GET /stream
+const DefaultHeartbeat = 15 * time.Second
+
+// streamWriteTimeout ограничивает запись одного события: клиент, который
+// не принимает данные, отключается, а не держит обработчик бесконечно
+const streamWriteTimeout = 10 * time.Second
+
+// eventStreamReset - служебное событие: часть пропущенных событий недоступна,
+// клиенту нужно перечитать ленту через GET /posts
+const eventStreamReset = "stream.reset"
+
+// WithEventStream включает GET /stream: события читаются из хаба, пустая
+// строка-комментарий отправляется каждые heartbeat, чтобы прокси не закрывали
+// простаивающее соединение
+func WithEventStream(hub *pubsub.Hub, heartbeat time.Duration) Option {
+	return func(h *MicroBlogHandler) {
+		h.events = hub
+		if heartbeat > 0 {
+			h.heartbeat = heartbeat
+		}
+	}
+}
+
+// Stream обрабатывает GET /stream (Server-Sent Events). Заголовок
+// Last-Event-ID продолжает чтение с места обрыва, если события еще в буфере.
+func (h *MicroBlogHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		http.Error(w, "Поток событий отключен", http.StatusServiceUnavailable)
+		return
+	}
+
+	var lastEventID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Неверный заголовок Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastEventID = id
+	}
+
+	sub, err := h.events.Subscribe(lastEventID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	send := func(write func() error) bool {
+		// Дедлайн записи сервера рассчитан на обычные запросы, поток живет дольше
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := write(); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !send(func() error {
+		if sub.Gap {
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventStreamReset); err != nil {
+				return err
+			}
+		}
+		for _, event := range sub.Replay {
+			if err := writeEvent(w, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}) {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				if err := sub.Err(); errors.Is(err, pubsub.ErrSlowConsumer) {
+					log.Printf("Поток событий: клиент %s отключен: %v", r.RemoteAddr, err)
+				}
+				return
+			}
+			if !send(func() error { return writeEvent(w, event) }) {
+				return
+			}
+
+		case <-heartbeat.C:
+			if !send(func() error {
+				_, err := io.WriteString(w, ": ping\n\n")
+				return err
+			}) {
+				return
+			}
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent записывает событие в формате text/event-stream.
+// Данные - однострочный JSON, поэтому хватает одного поля data.
+func writeEvent(w io.Writer, event pubsub.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestStream проверяет доставку событий через SSE и продолжение по Last-Event-ID
+func TestStream(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	ctx := context.Background()
+	hub := pubsub.NewHub(10, 10)
+	likeQueue := queue.NewLikeQueue(10, 1)
+	svc := service.NewMicroBlogService(log, likeQueue, service.WithEventHub(hub))
+	likeQueue.Start(svc.ProcessLikeEvent)
+	defer likeQueue.Stop()
+
+	mux := http.NewServeMux()
+	NewMicroBlogHandler(svc, WithEventStream(hub, time.Minute)).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer hub.Close() // иначе srv.Close ждет открытые потоки
+
+	open := func(lastEventID string) (*bufio.Reader, func()) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+APIPrefix+"/stream", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Ошибка подключения: %v", err)
+		}
+		if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+			t.Fatalf("Ожидали 200 text/event-stream, получили %d %q", resp.StatusCode, ct)
+		}
+		return bufio.NewReader(resp.Body), func() { _ = resp.Body.Close() }
+	}
+	// next читает одно событие и возвращает его поля id и event
+	next := func(r *bufio.Reader) (id, typ string) {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Ошибка чтения потока: %v", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return id, typ
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				typ = strings.TrimPrefix(line, "event: ")
+			}
+		}
+	}
+
+	// Тест 1: создание, лайк и удаление поста приходят по порядку
+	stream, closeStream := open("")
+	if _, err := svc.RegisterUser(ctx, "alice"); err != nil {
+		t.Fatalf("Ошибка регистрации: %v", err)
+	}
+	post, err := svc.CreatePost(ctx, "alice", "Привет")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if err := svc.LikePost(ctx, post.ID, "alice"); err != nil {
+		t.Fatalf("Ошибка лайка: %v", err)
+	}
+	want := []string{pubsub.EventPostCreated, pubsub.EventPostLiked}
+	for i, typ := range want {
+		if id, got := next(stream); got != typ || id != strconv.Itoa(i+1) {
+			t.Errorf("Событие %d: ожидали %s, получили %s (id %s)", i+1, typ, got, id)
+		}
+	}
+	closeStream()
+
+	// Тест 2: после переподключения пропущенное удаление приходит из буфера
+	if err := svc.DeletePost(ctx, post.ID, "alice"); err != nil {
+		t.Fatalf("Ошибка удаления: %v", err)
+	}
+	stream, closeStream = open("2")
+	defer closeStream()
+	if id, typ := next(stream); typ != pubsub.EventPostDeleted || id != "3" {
+		t.Errorf("Ожидали post.deleted с id 3, получили %s (id %s)", typ, id)
+	}
+
+	// Тест 3: ID из прошлого запуска - клиенту нужно перечитать ленту
+	stream2, closeStream2 := open("100")
+	defer closeStream2()
+	if _, typ := next(stream2); typ != eventStreamReset {
+		t.Errorf("Ожидали %s, получили %s", eventStreamReset, typ)
+	}
+}
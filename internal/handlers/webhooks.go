@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// CreateWebhook обрабатывает POST /users/{name}/webhooks
+func (h *MicroBlogHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Users  []string `json:"users"`
+		Tags   []string `json:"tags"`
+		Secret string   `json:"secret"`
+	}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	hook, err := h.service.CreateWebhook(r.Context(), r.PathValue("name"), service.WebhookSpec{
+		URL:    req.URL,
+		Events: req.Events,
+		Users:  req.Users,
+		Tags:   req.Tags,
+		Secret: req.Secret,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(hook); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// ListWebhooks обрабатывает GET /users/{name}/webhooks
+func (h *MicroBlogHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.service.ListWebhooks(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hooks); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// DeleteWebhook обрабатывает DELETE /users/{name}/webhooks/{id}
+func (h *MicroBlogHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID вебхука", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), r.PathValue("name"), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EnableWebhook обрабатывает POST /users/{name}/webhooks/{id}/enable
+func (h *MicroBlogHandler) EnableWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID вебхука", http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.service.EnableWebhook(r.Context(), r.PathValue("name"), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hook); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
+
+// ListWebhookDeliveries обрабатывает GET /users/{name}/webhooks/{id}/deliveries
+func (h *MicroBlogHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Неверный ID вебхука", http.StatusBadRequest)
+		return
+	}
+
+	attempts, err := h.service.ListWebhookDeliveries(r.Context(), r.PathValue("name"), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(attempts); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
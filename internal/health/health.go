@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check - проверка одной зависимости; nil означает, что зависимость в порядке
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker отвечает на запросы liveness/readiness оркестратора
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []namedCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// Report - тело ответа /readyz
+type Report struct {
+	Status string            `json:"status"` // ok или fail
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// NewChecker создает проверку готовности с таймаутом на весь набор проверок
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add регистрирует проверку под именем name
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown переводит readiness в состояние отказа.
+// Вызывается перед остановкой сервера, чтобы балансировщик успел снять трафик.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready выполняет все проверки и возвращает отчет
+func (c *Checker) Ready(ctx context.Context) (Report, bool) {
+	report := Report{Status: "ok", Checks: make(map[string]string)}
+	ok := true
+
+	if c.shuttingDown.Load() {
+		report.Checks["shutdown"] = "сервер завершает работу"
+		ok = false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.mu.RLock()
+	checks := make([]namedCheck, len(c.checks))
+	copy(checks, c.checks)
+	c.mu.RUnlock()
+
+	for _, nc := range checks {
+		if err := nc.check(ctx); err != nil {
+			report.Checks[nc.name] = err.Error()
+			ok = false
+			continue
+		}
+		report.Checks[nc.name] = "ok"
+	}
+
+	if !ok {
+		report.Status = "fail"
+	}
+	return report, ok
+}
+
+// RegisterRoutes регистрирует /healthz и /readyz
+func (c *Checker) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.LivenessHandler)
+	mux.HandleFunc("/readyz", c.ReadinessHandler)
+}
+
+// LivenessHandler обрабатывает GET /healthz: процесс жив, пока отвечает
+func (c *Checker) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: "ok"})
+}
+
+// ReadinessHandler обрабатывает GET /readyz
+func (c *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report, ok := c.Ready(r.Context())
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("ошибка кодирования JSON: %v", err)
+	}
+}
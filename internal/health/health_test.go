@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// get выполняет запрос к маршрутам проверки и разбирает отчет
+func get(t *testing.T, c *Checker, path string) (int, Report) {
+	t.Helper()
+	mux := http.NewServeMux()
+	c.RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("%s: ожидали Cache-Control no-store, получили %q", path, cc)
+	}
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("%s: ошибка разбора ответа: %v", path, err)
+	}
+	return w.Code, report
+}
+
+// TestLiveness проверяет, что /healthz не зависит от проверок зависимостей
+func TestLiveness(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("db", func(ctx context.Context) error { return errors.New("недоступна") })
+	c.SetShuttingDown()
+
+	if code, report := get(t, c, "/healthz"); code != http.StatusOK || report.Status != "ok" {
+		t.Errorf("Ожидали 200 ok, получили %d %+v", code, report)
+	}
+}
+
+// TestReadiness проверяет отчет по зависимостям, таймаут и отказ при остановке
+func TestReadiness(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	var dbErr error
+	c.Add("db", func(ctx context.Context) error { return dbErr })
+
+	// Тест 1: все проверки прошли
+	code, report := get(t, c, "/readyz")
+	if code != http.StatusOK || report.Status != "ok" || report.Checks["db"] != "ok" {
+		t.Errorf("Ожидали 200 ok, получили %d %+v", code, report)
+	}
+
+	// Тест 2: ошибка зависимости попадает в отчет
+	dbErr = errors.New("соединение отклонено")
+	code, report = get(t, c, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != "fail" || report.Checks["db"] != "соединение отклонено" {
+		t.Errorf("Ожидали 503 с ошибкой db, получили %d %+v", code, report)
+	}
+	dbErr = nil
+
+	// Тест 3: зависшая проверка прерывается таймаутом
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	start := time.Now()
+	code, report = get(t, c, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Checks["slow"] != context.DeadlineExceeded.Error() {
+		t.Errorf("Ожидали отказ slow по таймауту, получили %d %+v", code, report)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Проверка должна прерываться через 50 мс, прошло %v", elapsed)
+	}
+}
+
+// TestReadinessShutdown проверяет, что перед остановкой readiness отказывает,
+// даже если все зависимости в порядке
+func TestReadinessShutdown(t *testing.T) {
+	c := NewChecker(time.Second)
+	c.Add("db", func(ctx context.Context) error { return nil })
+	if code, _ := get(t, c, "/readyz"); code != http.StatusOK {
+		t.Fatalf("До остановки ожидали 200, получили %d", code)
+	}
+
+	c.SetShuttingDown()
+	code, report := get(t, c, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != "fail" || report.Checks["shutdown"] == "" || report.Checks["db"] != "ok" {
+		t.Errorf("Ожидали 503 с признаком остановки, получили %d %+v", code, report)
+	}
+}
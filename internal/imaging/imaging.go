@@ -0,0 +1,173 @@
+// Package imaging проверяет загруженные изображения по содержимому и
+// уменьшает их стандартными пакетами image/*. Результат всегда
+// перекодируется, поэтому метаданные исходного файла в него не попадают.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Ограничения на размеры исходного изображения: проверяются по заголовку
+// до декодирования, чтобы маленький файл не раскрылся в гигабайты пикселей
+const (
+	MaxDimension = 8192
+	MaxPixels    = 40_000_000
+)
+
+// Ошибки, которые можно проверить через errors.Is
+var (
+	ErrUnsupported = errors.New("формат изображения не поддерживается: ожидается JPEG, PNG или GIF")
+	ErrTooLarge    = fmt.Errorf("изображение больше %d×%d или %d пикселей", MaxDimension, MaxDimension, MaxPixels)
+	ErrCorrupt     = errors.New("изображение повреждено")
+)
+
+// Форматы изображений
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+)
+
+// formats - поддерживаемые типы содержимого
+var formats = map[string]string{
+	"image/jpeg": FormatJPEG,
+	"image/png":  FormatPNG,
+	"image/gif":  FormatGIF,
+}
+
+// Sniff определяет формат по первым байтам данных. Заявленный клиентом
+// Content-Type и расширение имени файла не учитываются.
+func Sniff(data []byte) (string, error) {
+	format, ok := formats[http.DetectContentType(data)]
+	if !ok {
+		return "", ErrUnsupported
+	}
+	return format, nil
+}
+
+// Decode проверяет формат и размеры изображения и декодирует его.
+// У GIF берется первый кадр.
+func Decode(data []byte) (image.Image, string, error) {
+	format, err := Sniff(data)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, "", ErrCorrupt
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	var img image.Image
+	switch format {
+	case FormatJPEG:
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case FormatPNG:
+		img, err = png.Decode(bytes.NewReader(data))
+	case FormatGIF:
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return img, format, nil
+}
+
+// Square вырезает центральный квадрат изображения и приводит его к size×size
+func Square(img image.Image, size int) *image.NRGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	return resize(img, image.Rect(x0, y0, x0+side, y0+side), size, size)
+}
+
+// Fit уменьшает изображение с сохранением пропорций так, чтобы обе стороны
+// были не больше limit. Изображение меньше предела не увеличивается.
+func Fit(img image.Image, limit int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > limit || h > limit {
+		if w >= h {
+			w, h = limit, max(1, h*limit/w)
+		} else {
+			w, h = max(1, w*limit/h), limit
+		}
+	}
+	return resize(img, b, w, h)
+}
+
+// Encode кодирует изображение: фотографии (исходный формат JPEG) - в JPEG,
+// остальное - в PNG, чтобы сохранить прозрачность. Возвращает данные и
+// тип содержимого.
+func Encode(img image.Image, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if format == FormatJPEG {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// Extension возвращает расширение файла для типа содержимого из Encode
+func Extension(contentType string) string {
+	if contentType == "image/jpeg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// resize приводит область src изображения к w×h усреднением по площади:
+// каждый пиксель результата - среднее покрытых им исходных пикселей.
+// Цвета усредняются с учетом прозрачности.
+func resize(img image.Image, src image.Rectangle, w, h int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	sw, sh := src.Dx(), src.Dy()
+	for y := 0; y < h; y++ {
+		y0 := src.Min.Y + y*sh/h
+		y1 := max(src.Min.Y+(y+1)*sh/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := src.Min.X + x*sw/w
+			x1 := max(src.Min.X+(x+1)*sw/w, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// RGBA возвращает цвета, уже умноженные на альфу
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			c := color.NRGBA{}
+			if a > 0 {
+				c = color.NRGBA{
+					R: uint8(r * 0xff / a),
+					G: uint8(g * 0xff / a),
+					B: uint8(b * 0xff / a),
+					A: uint8(a / n >> 8),
+				}
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,108 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Ошибка кодирования PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestDecode проверяет определение формата по содержимому и ограничения размеров
+func TestDecode(t *testing.T) {
+	img, format, err := Decode(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 10, 20))))
+	if err != nil || format != FormatPNG || img.Bounds().Dx() != 10 || img.Bounds().Dy() != 20 {
+		t.Fatalf("Ожидали PNG 10×20, получили %s %v (%v)", format, img.Bounds(), err)
+	}
+
+	for name, tc := range map[string]struct {
+		data []byte
+		want error
+	}{
+		"текст":           {[]byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), ErrUnsupported},
+		"пусто":           {nil, ErrUnsupported},
+		"обрезанный PNG":  {encodePNG(t, image.NewGray(image.Rect(0, 0, 50, 50)))[:40], ErrCorrupt},
+		"слишком широкий": {encodePNG(t, image.NewGray(image.Rect(0, 0, MaxDimension+1, 1))), ErrTooLarge},
+	} {
+		if _, _, err := Decode(tc.data); !errors.Is(err, tc.want) {
+			t.Errorf("%s: ожидали %v, получили %v", name, tc.want, err)
+		}
+	}
+}
+
+// TestResize проверяет размеры результатов Square и Fit и усреднение цвета
+func TestResize(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 200; y++ {
+			c := color.NRGBA{A: 0xff}
+			if x%2 == 0 {
+				c.R = 0xff
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	sq := Square(src, 50)
+	if sq.Bounds().Dx() != 50 || sq.Bounds().Dy() != 50 {
+		t.Errorf("Square: ожидали 50×50, получили %v", sq.Bounds())
+	}
+	// Чередование красных и черных столбцов дает при уменьшении средний цвет
+	if c := sq.NRGBAAt(25, 25); c.R < 0x70 || c.R > 0x90 || c.A != 0xff {
+		t.Errorf("Ожидали усредненный цвет, получили %+v", c)
+	}
+
+	for _, tc := range []struct{ limit, w, h int }{{100, 100, 50}, {1000, 400, 200}} {
+		if b := Fit(src, tc.limit).Bounds(); b.Dx() != tc.w || b.Dy() != tc.h {
+			t.Errorf("Fit(%d): ожидали %d×%d, получили %v", tc.limit, tc.w, tc.h, b)
+		}
+	}
+}
+
+// TestEncode проверяет выбор формата и то, что результат декодируется
+func TestEncode(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for _, tc := range []struct{ format, contentType, ext string }{
+		{FormatJPEG, "image/jpeg", ".jpg"},
+		{FormatPNG, "image/png", ".png"},
+		{FormatGIF, "image/png", ".png"},
+	} {
+		data, contentType, err := Encode(img, tc.format)
+		if err != nil || contentType != tc.contentType || Extension(contentType) != tc.ext {
+			t.Errorf("%s: ожидали %s, получили %s (%v)", tc.format, tc.contentType, contentType, err)
+			continue
+		}
+		if _, format, err := Decode(data); err != nil || (tc.format == FormatJPEG) != (format == FormatJPEG) {
+			t.Errorf("%s: результат не декодируется: %s %v", tc.format, format, err)
+		}
+	}
+
+	// Перекодирование не переносит метаданные: в JPEG нет сегмента APP1 (EXIF)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Ошибка кодирования JPEG: %v", err)
+	}
+	withExif := append([]byte{0xff, 0xd8, 0xff, 0xe1, 0x00, 0x08, 'E', 'x', 'i', 'f', 0, 0}, buf.Bytes()[2:]...)
+	decoded, format, err := Decode(withExif)
+	if err != nil {
+		t.Fatalf("Ошибка декодирования JPEG с EXIF: %v", err)
+	}
+	out, _, err := Encode(decoded, format)
+	if err != nil {
+		t.Fatalf("Ошибка кодирования: %v", err)
+	}
+	if bytes.Contains(out, []byte("Exif")) {
+		t.Error("Результат не должен содержать EXIF")
+	}
+}
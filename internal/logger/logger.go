@@ -1,19 +1,47 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Cere6rum/MicroBlog2/internal/models"
 )
 
+// Уровни логирования в порядке возрастания важности
+const (
+	LevelDebug = "DEBUG"
+	LevelInfo  = "INFO"
+	LevelError = "ERROR"
+)
+
+var levelRank = map[string]int32{
+	LevelDebug: 0,
+	LevelInfo:  1,
+	LevelError: 2,
+}
+
+// ParseLevel проверяет имя уровня (без учета регистра) и возвращает его каноническую форму
+func ParseLevel(level string) (string, error) {
+	l := strings.ToUpper(strings.TrimSpace(level))
+	if _, ok := levelRank[l]; !ok {
+		return "", fmt.Errorf("неизвестный уровень логирования %q (допустимо: debug, info, error)", level)
+	}
+	return l, nil
+}
+
 // Logger - структура логгера с каналом
 type Logger struct {
-	logChan chan models.LogEvent
-	done    chan struct{}
-	file    *os.File
+	logChan  chan models.LogEvent
+	done     chan struct{}
+	file     *os.File
+	closed   atomic.Bool
+	minLevel atomic.Int32 // события ниже этого уровня отбрасываются
+	discard  bool         // логгер-заглушка: все события отбрасываются
 }
 
 // NewLogger создает новый логгер
@@ -36,6 +64,12 @@ func NewLogger(logFile string) (*Logger, error) {
 	return logger, nil
 }
 
+// Discard возвращает логгер, который отбрасывает все события. Используется
+// по умолчанию там, где логгер не передан.
+func Discard() *Logger {
+	return &Logger{done: make(chan struct{}), discard: true}
+}
+
 // processLogs обрабатывает события логирования из канала
 func (l *Logger) processLogs() {
 	for {
@@ -60,8 +94,35 @@ func (l *Logger) processLogs() {
 	}
 }
 
+// SetLevel меняет минимальный уровень логирования; безопасен для конкурентного вызова
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.minLevel.Store(levelRank[parsed])
+	return nil
+}
+
+// Level возвращает текущий минимальный уровень логирования
+func (l *Logger) Level() string {
+	rank := l.minLevel.Load()
+	for name, r := range levelRank {
+		if r == rank {
+			return name
+		}
+	}
+	return LevelDebug
+}
+
 // Log отправляет событие в канал логирования
 func (l *Logger) Log(level, message string) {
+	if l.discard {
+		return
+	}
+	if rank, ok := levelRank[level]; ok && rank < l.minLevel.Load() {
+		return
+	}
 	l.logChan <- models.LogEvent{
 		Level:   level,
 		Message: message,
@@ -83,9 +144,28 @@ func (l *Logger) Debug(message string) {
 	l.Log("DEBUG", message)
 }
 
+// Healthy проверяет, что логгер принимает события: он не закрыт
+// и буфер канала не переполнен (иначе Log заблокирует вызывающего)
+func (l *Logger) Healthy() error {
+	if l.closed.Load() {
+		return errors.New("логгер закрыт")
+	}
+	if l.discard {
+		return nil
+	}
+	if len(l.logChan) == cap(l.logChan) {
+		return errors.New("буфер логгера переполнен")
+	}
+	return nil
+}
+
 // Close закрывает логгер и освобождает ресурсы
 func (l *Logger) Close() error {
+	l.closed.Store(true)
 	close(l.done)
+	if l.discard {
+		return nil
+	}
 	time.Sleep(100 * time.Millisecond) // Даем время обработать оставшиеся логи
 	return l.file.Close()
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Media - загруженное изображение. Файлы лежат в хранилище блобов:
+// уменьшенная копия оригинала и миниатюра, обе перекодированы без метаданных.
+type Media struct {
+	ID          int       `json:"id"`
+	OwnerID     int       `json:"owner_id"`
+	Owner       string    `json:"owner"`             // имя владельца по OwnerID, подставляется при чтении
+	PostID      int       `json:"post_id,omitempty"` // 0 - еще не прикреплено к посту
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int       `json:"size"` // размер сохраненного файла в байтах
+	ThumbWidth  int       `json:"thumb_width"`
+	ThumbHeight int       `json:"thumb_height"`
+	CreatedAt   time.Time `json:"created_at"`
+	// AccessKey - ключ доступа к файлу, пока изображение не прикреплено к
+	// посту. Выдается только в ответе на загрузку и при прикреплении стирается.
+	AccessKey string `json:"access_key,omitempty"`
+}
@@ -4,6 +4,26 @@ package models
 type LikeEvent struct {
 	PostID   int
 	Username string
+	// TraceContext - контекст трассировки запроса, поставившего лайк в очередь
+	// (заголовки W3C traceparent/tracestate)
+	TraceContext map[string]string
+}
+
+// Операции над поисковым индексом
+const (
+	IndexOpUpsert = "upsert" // пост создан или изменен
+	IndexOpDelete = "delete" // пост удален
+)
+
+// IndexEvent - изменение поста для асинхронного обновления поискового индекса
+type IndexEvent struct {
+	Op       string
+	PostID   int
+	AuthorID int
+	Content  string
+	Revision int // номер версии текста: устаревшие события не перезаписывают более новые
+	// TraceContext - контекст трассировки запроса, изменившего пост
+	TraceContext map[string]string
 }
 
 // LogEvent представляет событие для логирования
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Типы уведомлений
+const (
+	NotificationLike    = "like"    // пост пользователя лайкнули
+	NotificationMention = "mention" // пользователя упомянули в посте
+	NotificationFollow  = "follow"  // на пользователя подписались
+)
+
+// NotificationTypes - все типы уведомлений, они же ключи настроек
+var NotificationTypes = []string{NotificationLike, NotificationMention, NotificationFollow}
+
+// NotificationEvent - повод для уведомления (элемент очереди уведомлений)
+type NotificationEvent struct {
+	Type        string
+	RecipientID int // ID получателя
+	ActorID     int // кто лайкнул, упомянул или подписался
+	PostID      int // 0 - уведомление о подписке
+	// TraceContext - контекст трассировки запроса, породившего уведомление
+	TraceContext map[string]string
+}
+
+// Notification - уведомление пользователя. Лайки одного поста, пришедшие
+// до прочтения уведомления, собираются в одно. Участники хранятся по ID,
+// их имена и текст уведомления подставляются при чтении.
+type Notification struct {
+	ID       int      `json:"id"`
+	UserID   int      `json:"user_id"`
+	Type     string   `json:"type"`
+	PostID   int      `json:"post_id"` // 0 - уведомление о подписке
+	ActorIDs []int    `json:"-"`       // последние участники, новые первыми
+	Actors   []string `json:"actors"`  // их имена, в порядке ActorIDs
+	Count    int      `json:"count"`   // всего участников
+	Text     string   `json:"text"`
+	Read     bool     `json:"read"`
+	// CreatedAt - первое событие, UpdatedAt - последнее
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationList - страница уведомлений, новые первыми
+type NotificationList struct {
+	Unread        int             `json:"unread"` // непрочитанных всего, независимо от страницы
+	Total         int             `json:"total"`
+	Limit         int             `json:"limit"`
+	Offset        int             `json:"offset"`
+	Notifications []*Notification `json:"notifications"`
+}
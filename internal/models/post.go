@@ -1,10 +1,81 @@
 package models
 
-// Post представляет пост в микроблоге
+import "time"
+
+// Виды постов
+const (
+	PostKindPost   = "post"   // обычный пост
+	PostKindRepost = "repost" // репост без собственного текста
+	PostKindQuote  = "quote"  // цитата: собственный текст плюс ссылка на оригинал
+)
+
+// Post представляет пост в микроблоге. Пользователи хранятся только по ID;
+// имена (Author, Likes, Mentions, владельцы вложений) сервис подставляет
+// при чтении, поэтому смена имени не меняет посты.
 type Post struct {
-	ID       int      `json:"id"`
-	AuthorID int      `json:"author_id"`
-	Author   string   `json:"author"`
-	Content  string   `json:"content"`
-	Likes    []string `json:"likes"` // Список пользователей, лайкнувших пост
+	ID          int       `json:"id"`
+	Kind        string    `json:"kind"`
+	AuthorID    int       `json:"author_id"`
+	Author      string    `json:"author"` // имя автора по AuthorID
+	Content     string    `json:"content"`
+	LikeIDs     []int     `json:"-"`                     // ID пользователей, лайкнувших пост
+	Likes       []string  `json:"likes"`                 // их имена, в порядке LikeIDs
+	ReplyToID   int       `json:"reply_to_id,omitempty"` // ID родительского поста (0 - не ответ)
+	ReplyCount  int       `json:"reply_count"`           // Количество прямых ответов
+	OriginalID  int       `json:"original_id,omitempty"` // ID оригинала для репоста и цитаты
+	RepostCount int       `json:"repost_count"`
+	QuoteCount  int       `json:"quote_count"`
+	Hashtags    []string  `json:"hashtags,omitempty"` // Хэштеги в нижнем регистре, без '#'
+	MentionIDs  []int     `json:"-"`                  // ID упомянутых существующих пользователей
+	Mentions    []string  `json:"mentions,omitempty"` // их имена, в порядке MentionIDs
+	Attachments []Media   `json:"attachments,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PostView - пост для ленты: репост и цитата несут встроенный оригинал
+type PostView struct {
+	*Post
+	Original *Post `json:"original,omitempty"`
+}
+
+// SearchHit - найденный пост и его релевантность
+type SearchHit struct {
+	Post  *Post   `json:"post"`
+	Score float64 `json:"score"`
+}
+
+// SearchResult - страница результатов поиска
+type SearchResult struct {
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+	Hits   []SearchHit `json:"hits"`
+}
+
+// TrendingTag - популярный хэштег
+type TrendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+// TrendingPost - популярный пост
+type TrendingPost struct {
+	Post  *Post   `json:"post"`
+	Score float64 `json:"score"`
+}
+
+// Trending - популярные хэштеги и посты
+type Trending struct {
+	Hashtags []TrendingTag  `json:"hashtags"`
+	Posts    []TrendingPost `json:"posts"`
+}
+
+// PostRevision - версия содержимого поста (запись истории правок)
+type PostRevision struct {
+	PostID    int       `json:"post_id"`
+	Version   int       `json:"version"` // 1 - исходный текст, далее по одной на каждую правку
+	Content   string    `json:"content"`
+	EditorID  int       `json:"editor_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,14 @@
+package models
+
+// PostLiked - данные события post.liked потока /stream
+type PostLiked struct {
+	PostID   int    `json:"post_id"`
+	Username string `json:"username"` // кто поставил лайк
+	Likes    int    `json:"likes"`    // лайков у поста после этого
+}
+
+// PostDeleted - данные события post.deleted потока /stream
+type PostDeleted struct {
+	PostID int    `json:"post_id"`
+	Author string `json:"author"`
+}
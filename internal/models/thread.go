@@ -0,0 +1,18 @@
+package models
+
+// ThreadNode - пост и ответы на него в дереве обсуждения
+type ThreadNode struct {
+	Post    *Post         `json:"post"`
+	Replies []*ThreadNode `json:"replies"`
+	// MoreReplies - сколько прямых ответов не вошло в выдачу (лимит или глубина)
+	MoreReplies int `json:"more_replies,omitempty"`
+}
+
+// Thread - обсуждение целиком, начиная с корневого поста
+type Thread struct {
+	Root    *ThreadNode `json:"root"`
+	FocusID int         `json:"focus_id"` // пост, для которого запрошено обсуждение
+	Depth   int         `json:"depth"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+}
@@ -1,7 +1,95 @@
 package models
 
+import "time"
+
 // User представляет пользователя системы
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
+	// CanonicalName - ключ уникальности имени (без учета регистра и похожих букв)
+	CanonicalName string `json:"-"`
+
+	// Профиль: пустое поле не заполнено
+	DisplayName string  `json:"display_name,omitempty"`
+	Bio         string  `json:"bio,omitempty"`
+	Location    string  `json:"location,omitempty"`
+	Website     string  `json:"website,omitempty"`
+	Avatar      *Avatar `json:"avatar,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Avatar - загруженный аватар. Изображения лежат в хранилище блобов:
+// квадратные копии каждого размера из Sizes.
+type Avatar struct {
+	// Version меняется при каждой загрузке и входит в ключи блобов и ETag
+	Version     int64     `json:"version"`
+	ContentType string    `json:"content_type"`
+	Sizes       []int     `json:"sizes"` // сторона квадрата в пикселях, по возрастанию
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserView - пользователь со счетчиками активности
+type UserView struct {
+	*User
+	PostCount     int `json:"post_count"`     // посты, цитаты и репосты пользователя
+	LikesReceived int `json:"likes_received"` // лайки его постам
+	LikesGiven    int `json:"likes_given"`    // его лайки чужим и своим постам
+}
+
+// UserList - страница пользователей в порядке регистрации
+type UserList struct {
+	Total  int     `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	Users  []*User `json:"users"`
+}
+
+// AnonymousAuthor - автор постов удаленного пользователя, выбравшего
+// обезличивание. Не может совпасть с именем пользователя: скобки в именах
+// недопустимы.
+const AnonymousAuthor = "[удален]"
+
+// Режимы удаления аккаунта: что происходит с постами пользователя
+const (
+	DeletePosts    = "delete"    // посты удаляются вместе с репостами
+	AnonymizePosts = "anonymize" // посты остаются без автора, репосты удаляются
+)
+
+// AuditEntry - запись журнала аудита. Не содержит персональных данных
+// удаленного пользователя: только ID и итоги операции.
+type AuditEntry struct {
+	ID              int       `json:"id"`
+	Action          string    `json:"action"` // user.deleted
+	UserID          int       `json:"user_id"`
+	Mode            string    `json:"mode"` // delete, anonymize
+	PostsDeleted    int       `json:"posts_deleted"`
+	PostsAnonymized int       `json:"posts_anonymized"`
+	LikesRemoved    int       `json:"likes_removed"`
+	MediaRemoved    int       `json:"media_removed"`
+	WebhooksRemoved int       `json:"webhooks_removed"`
+	TraceID         string    `json:"trace_id,omitempty"` // трассировка запроса на удаление
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Действия журнала аудита
+const AuditUserDeleted = "user.deleted"
+
+// AuditList - страница журнала аудита, новые записи первыми
+type AuditList struct {
+	Total   int           `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+	Entries []*AuditEntry `json:"entries"`
+}
+
+// UsernameAlias - прежнее имя пользователя. Запросы по нему перенаправляются
+// на текущее имя; другой пользователь не может занять его, пока не истек
+// карантин с момента ReleasedAt.
+type UsernameAlias struct {
+	Username      string    `json:"username"`
+	CanonicalName string    `json:"-"`
+	UserID        int       `json:"user_id"`
+	ReleasedAt    time.Time `json:"released_at"`
 }
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Webhook - подписка внешнего сервиса на события о постах
+type Webhook struct {
+	ID      int      `json:"id"`
+	OwnerID int      `json:"owner_id"`
+	Owner   string   `json:"owner"` // имя владельца по OwnerID, подставляется при чтении
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`          // типы событий: post.created, post.liked, post.deleted
+	UserIDs []int    `json:"-"`               // только посты этих авторов; пусто - любые
+	Users   []string `json:"users,omitempty"` // имена авторов из UserIDs
+	Tags    []string `json:"tags,omitempty"`  // только посты с этими хэштегами; пусто - любые
+	// Secret - ключ подписи HMAC; отдается клиенту только при создании
+	Secret   string `json:"secret,omitempty"`
+	Active   bool   `json:"active"`
+	Failures int    `json:"consecutive_failures"` // доставок подряд, не удавшихся после всех попыток
+	// DisabledReason - почему вебхук отключен автоматически
+	DisabledReason string    `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery - событие для доставки одному вебхуку (элемент очереди)
+type WebhookDelivery struct {
+	ID        int
+	WebhookID int
+	Event     string
+	Body      []byte // готовое тело запроса
+	// Attempt - сколько попыток уже сделано; повтор ставится в очередь
+	// заново с увеличенным счетчиком
+	Attempt int
+	// TraceContext - контекст трассировки запроса, породившего событие
+	TraceContext map[string]string
+}
+
+// WebhookAttempt - запись журнала доставок: одна попытка отправки
+type WebhookAttempt struct {
+	DeliveryID int           `json:"delivery_id"`
+	WebhookID  int           `json:"webhook_id"`
+	Event      string        `json:"event"`
+	Attempt    int           `json:"attempt"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Success    bool          `json:"success"`
+	Duration   time.Duration `json:"duration_ns"`
+	At         time.Time     `json:"at"`
+}
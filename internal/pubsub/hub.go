@@ -0,0 +1,221 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Типы событий потока
+const (
+	EventPostCreated = "post.created"
+	EventPostLiked   = "post.liked"
+	EventPostDeleted = "post.deleted"
+)
+
+// Префиксы тем события: подписчик может получать только события по
+// интересующим его пользователям, хэштегам и постам
+const (
+	topicUser = "user:"
+	topicTag  = "tag:"
+	topicPost = "post:"
+)
+
+// UserTopic - тема событий о постах пользователя
+func UserTopic(username string) string { return topicUser + username }
+
+// TagTopic - тема событий о постах с хэштегом
+func TagTopic(tag string) string { return topicTag + tag }
+
+// PostTopic - тема событий о посте, ответах на него и его репостах
+func PostTopic(id int) string { return topicPost + strconv.Itoa(id) }
+
+var (
+	// ErrSlowConsumer - подписчик отключен: его буфер переполнился
+	ErrSlowConsumer = errors.New("подписчик не успевает читать события")
+	// ErrClosed - хаб остановлен
+	ErrClosed = errors.New("поток событий закрыт")
+)
+
+// Event - событие потока. ID растут монотонно с запуска процесса,
+// по ним клиент продолжает чтение после переподключения.
+type Event struct {
+	ID   uint64          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+	Time time.Time       `json:"time"`
+	// Topics - темы события для фильтрации подписчиками, наружу не отдаются
+	Topics []string `json:"-"`
+}
+
+// Stats - снимок состояния хаба
+type Stats struct {
+	Subscribers  int    `json:"subscribers"`  // подключенных подписчиков
+	Published    uint64 `json:"published"`    // событий с момента запуска
+	Replay       int    `json:"replay"`       // событий в буфере повтора
+	Disconnected uint64 `json:"disconnected"` // отключено медленных подписчиков
+}
+
+// Hub - внутрипроцессная шина событий. Каждый подписчик получает события
+// через собственный буфер; подписчик, переполнивший буфер, отключается,
+// чтобы не тормозить публикацию. Последние события хранятся в буфере
+// повтора для продолжения чтения после переподключения.
+type Hub struct {
+	mu           sync.Mutex
+	bufferSize   int
+	replayCap    int
+	replay       []Event // последние события по возрастанию ID
+	lastID       uint64
+	subs         map[*Subscription]struct{}
+	closed       bool
+	disconnected uint64
+}
+
+// NewHub создает хаб с буфером bufferSize на подписчика и буфером
+// повтора из replaySize последних событий (0 - без повтора)
+func NewHub(bufferSize, replaySize int) *Hub {
+	return &Hub{
+		bufferSize: max(bufferSize, 1),
+		replayCap:  max(replaySize, 0),
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish рассылает событие типа typ с темами topics всем подписчикам.
+// Данные сериализуются сразу, поэтому последующие изменения data на событие
+// не влияют.
+func (h *Hub) Publish(typ string, data any, topics ...string) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("событие %s: %w", typ, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return Event{}, ErrClosed
+	}
+	h.lastID++
+	event := Event{ID: h.lastID, Type: typ, Data: raw, Time: time.Now().UTC(), Topics: topics}
+	if h.replayCap > 0 {
+		if len(h.replay) >= h.replayCap {
+			h.replay = h.replay[1:]
+		}
+		h.replay = append(h.replay, event)
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.events <- event:
+		default:
+			h.drop(sub, ErrSlowConsumer)
+			h.disconnected++
+		}
+	}
+	return event, nil
+}
+
+// Subscribe подключает подписчика. Если lastEventID больше 0, в Replay
+// попадают события после него, еще хранящиеся в буфере повтора; Gap
+// сообщает, что часть событий уже вытеснена и клиенту нужно перечитать
+// данные целиком. События из Replay в канал Events не дублируются.
+func (h *Hub) Subscribe(lastEventID uint64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+	sub := &Subscription{hub: h, events: make(chan Event, h.bufferSize)}
+	if lastEventID > 0 {
+		sub.Replay, sub.Gap = h.since(lastEventID)
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// since возвращает события буфера повтора после lastEventID и признак
+// пропуска (вызывается под мьютексом)
+func (h *Hub) since(lastEventID uint64) ([]Event, bool) {
+	if lastEventID >= h.lastID {
+		// ID больше последнего выданного - из предыдущего запуска процесса
+		return nil, lastEventID > h.lastID
+	}
+	if len(h.replay) == 0 {
+		return nil, true
+	}
+	first := h.replay[0].ID
+	if first > lastEventID+1 {
+		return append([]Event(nil), h.replay...), true
+	}
+	return append([]Event(nil), h.replay[lastEventID+1-first:]...), false
+}
+
+// drop отключает подписчика с причиной err (вызывается под мьютексом)
+func (h *Hub) drop(sub *Subscription, err error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.events)
+}
+
+// Stats возвращает текущее состояние хаба
+func (h *Hub) Stats() Stats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return Stats{
+		Subscribers:  len(h.subs),
+		Published:    h.lastID,
+		Replay:       len(h.replay),
+		Disconnected: h.disconnected,
+	}
+}
+
+// Close отключает всех подписчиков; дальнейшие Publish и Subscribe
+// завершаются ошибкой ErrClosed
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub, ErrClosed)
+	}
+}
+
+// Subscription - подписка на события хаба
+type Subscription struct {
+	hub    *Hub
+	events chan Event
+	err    error // причина отключения хабом, под hub.mu
+
+	Replay []Event // пропущенные события после Last-Event-ID
+	Gap    bool    // часть пропущенных событий уже недоступна
+}
+
+// Events возвращает канал событий. Канал закрывается при отключении
+// подписчика; причину сообщает Err.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err возвращает причину отключения хабом или nil, если подписка активна
+// или закрыта самим подписчиком
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Close отменяет подписку
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s, nil)
+}
@@ -0,0 +1,85 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestReplay проверяет продолжение по Last-Event-ID и признак пропуска
+func TestReplay(t *testing.T) {
+	h := NewHub(10, 3)
+	for i := 1; i <= 5; i++ {
+		if _, err := h.Publish(EventPostCreated, map[string]int{"id": i}); err != nil {
+			t.Fatalf("Ошибка публикации: %v", err)
+		}
+	}
+
+	cases := []struct {
+		last    uint64
+		replay  []uint64
+		gap     bool
+		comment string
+	}{
+		{0, nil, false, "новый подписчик"},
+		{3, []uint64{4, 5}, false, "события еще в буфере"},
+		{5, nil, false, "пропусков нет"},
+		{1, []uint64{3, 4, 5}, true, "событие 2 вытеснено"},
+		{9, nil, true, "ID из прошлого запуска"},
+	}
+	for _, c := range cases {
+		sub, err := h.Subscribe(c.last)
+		if err != nil {
+			t.Fatalf("%s: ошибка подписки: %v", c.comment, err)
+		}
+		var got []uint64
+		for _, e := range sub.Replay {
+			got = append(got, e.ID)
+		}
+		if len(got) != len(c.replay) || sub.Gap != c.gap {
+			t.Errorf("%s: ожидали %v (пропуск %v), получили %v (%v)", c.comment, c.replay, c.gap, got, sub.Gap)
+		}
+		for i := range got {
+			if got[i] != c.replay[i] {
+				t.Errorf("%s: ожидали %v, получили %v", c.comment, c.replay, got)
+				break
+			}
+		}
+		sub.Close()
+	}
+}
+
+// TestSlowConsumer проверяет, что переполнивший буфер подписчик отключается,
+// а остальные продолжают получать события
+func TestSlowConsumer(t *testing.T) {
+	h := NewHub(2, 0)
+	slow, _ := h.Subscribe(0)
+	fast, _ := h.Subscribe(0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := h.Publish(EventPostLiked, i); err != nil {
+			t.Fatalf("Ошибка публикации: %v", err)
+		}
+		<-fast.Events()
+	}
+
+	n := 0
+	for range slow.Events() {
+		n++
+	}
+	if n != 2 || !errors.Is(slow.Err(), ErrSlowConsumer) {
+		t.Errorf("Ожидали 2 события и ErrSlowConsumer, получили %d и %v", n, slow.Err())
+	}
+	if st := h.Stats(); st.Subscribers != 1 || st.Disconnected != 1 || st.Published != 3 {
+		t.Errorf("Неверная статистика: %+v", st)
+	}
+
+	// Закрытие хаба отключает оставшихся подписчиков
+	h.Close()
+	if _, ok := <-fast.Events(); ok || !errors.Is(fast.Err(), ErrClosed) {
+		t.Errorf("Подписка должна закрыться с ErrClosed, получили %v", fast.Err())
+	}
+	if _, err := h.Publish(EventPostDeleted, 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Ожидали ErrClosed после Close, получили %v", err)
+	}
+	fast.Close() // повторное закрытие безопасно
+}
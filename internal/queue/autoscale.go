@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+)
+
+// autoscaleMetrics публикуется в /debug/vars (сервер pprof)
+var autoscaleMetrics = expvar.NewMap("like_queue_autoscaler")
+
+// AutoscaleConfig - параметры автомасштабирования воркеров LikeQueue
+type AutoscaleConfig struct {
+	Enabled    bool
+	MinWorkers int
+	MaxWorkers int
+	Interval   time.Duration // период опроса очереди
+	// Заполнение буфера (0..1), выше которого пул растет, и ниже которого сжимается.
+	// Разрыв между порогами и есть гистерезис: в промежутке пул не меняется.
+	ScaleUpUtilization   float64
+	ScaleDownUtilization float64
+	// LatencyTarget - при среднем времени обработки выше цели пул растет (0 - не учитывать)
+	LatencyTarget time.Duration
+	// Сколько опросов подряд условие должно выполняться, прежде чем менять пул
+	UpAfter   int
+	DownAfter int
+	// Cooldown - минимальная пауза между двумя изменениями пула
+	Cooldown time.Duration
+}
+
+// Validate проверяет согласованность параметров
+func (c AutoscaleConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch {
+	case c.MinWorkers < 1:
+		return fmt.Errorf("min_workers должно быть не меньше 1, получено %d", c.MinWorkers)
+	case c.MaxWorkers < c.MinWorkers:
+		return fmt.Errorf("max_workers (%d) меньше min_workers (%d)", c.MaxWorkers, c.MinWorkers)
+	case c.Interval <= 0:
+		return errors.New("interval должен быть положительным")
+	case c.ScaleUpUtilization <= 0 || c.ScaleUpUtilization > 1:
+		return fmt.Errorf("scale_up_utilization должно быть в (0, 1], получено %g", c.ScaleUpUtilization)
+	case c.ScaleDownUtilization < 0 || c.ScaleDownUtilization >= c.ScaleUpUtilization:
+		return fmt.Errorf("scale_down_utilization должно быть в [0, scale_up_utilization), получено %g", c.ScaleDownUtilization)
+	case c.UpAfter < 1 || c.DownAfter < 1:
+		return errors.New("up_after и down_after должны быть не меньше 1")
+	case c.LatencyTarget < 0 || c.Cooldown < 0:
+		return errors.New("latency_target и cooldown не могут быть отрицательными")
+	}
+	return nil
+}
+
+// Autoscaler следит за глубиной очереди и временем обработки
+// и меняет количество воркеров в пределах [MinWorkers, MaxWorkers].
+// В выключенном состоянии только публикует метрики очереди.
+type Autoscaler struct {
+	queue  *LikeQueue
+	logger *logger.Logger
+
+	mu         sync.Mutex
+	cfg        AutoscaleConfig
+	upStreak   int
+	downStreak int
+	lastChange time.Time
+}
+
+// NewAutoscaler создает автомасштабирование для очереди; без логгера
+// сообщения о смене пула отбрасываются
+func NewAutoscaler(q *LikeQueue, cfg AutoscaleConfig, log *logger.Logger) (*Autoscaler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if log == nil {
+		log = logger.Discard()
+	}
+	return &Autoscaler{queue: q, logger: log, cfg: cfg}, nil
+}
+
+// SetConfig меняет параметры на лету (например, по SIGHUP)
+func (a *Autoscaler) SetConfig(cfg AutoscaleConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cfg = cfg
+	a.upStreak, a.downStreak = 0, 0
+	return nil
+}
+
+// Run опрашивает очередь до отмены контекста
+func (a *Autoscaler) Run(ctx context.Context) {
+	a.mu.Lock()
+	interval := a.cfg.Interval
+	a.mu.Unlock()
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.Tick(now)
+
+			// Интервал мог измениться после SetConfig
+			a.mu.Lock()
+			if a.cfg.Interval > 0 && a.cfg.Interval != interval {
+				interval = a.cfg.Interval
+				ticker.Reset(interval)
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// Tick выполняет один цикл: снимает метрики, принимает решение и применяет его
+func (a *Autoscaler) Tick(now time.Time) {
+	st := a.queue.Stats()
+
+	publishStats(st)
+
+	a.mu.Lock()
+	if !a.cfg.Enabled {
+		a.mu.Unlock()
+		return
+	}
+	target, reason := a.decide(st, now)
+	a.mu.Unlock()
+
+	if target == st.Workers {
+		return
+	}
+
+	if err := a.queue.Resize(target); err != nil {
+		a.logger.Error(fmt.Sprintf("Автомасштабирование: не удалось изменить пул %d -> %d: %v", st.Workers, target, err))
+		return
+	}
+
+	a.mu.Lock()
+	a.lastChange = now
+	a.mu.Unlock()
+
+	direction := "scale_up"
+	if target < st.Workers {
+		direction = "scale_down"
+	}
+	autoscaleMetrics.Add(direction+"_total", 1)
+	autoscaleMetrics.Set("last_decision", stringVar(fmt.Sprintf("%s %d -> %d (%s)", direction, st.Workers, target, reason)))
+	a.logger.Info(fmt.Sprintf("Автомасштабирование очереди лайков: %d -> %d воркеров (%s)", st.Workers, target, reason))
+}
+
+// decide возвращает желаемое количество воркеров и причину (вызывается под мьютексом)
+func (a *Autoscaler) decide(st Stats, now time.Time) (int, string) {
+	cfg := a.cfg
+	current := st.Workers
+
+	// Границы могли измениться: сначала возвращаем пул в допустимый диапазон
+	if current < cfg.MinWorkers {
+		return cfg.MinWorkers, "ниже min_workers"
+	}
+	if current > cfg.MaxWorkers {
+		return cfg.MaxWorkers, "выше max_workers"
+	}
+
+	utilization := 0.0
+	if st.Capacity > 0 {
+		utilization = float64(st.Pending) / float64(st.Capacity)
+	}
+	slow := cfg.LatencyTarget > 0 && st.AvgLatency > cfg.LatencyTarget
+
+	switch {
+	case utilization >= cfg.ScaleUpUtilization || (slow && st.Pending > 0):
+		a.upStreak++
+		a.downStreak = 0
+	case utilization <= cfg.ScaleDownUtilization && (!slow || st.Pending == 0):
+		// Среднее время обработки без новых событий не обновляется,
+		// поэтому на пустой очереди оно не мешает сжатию
+		a.downStreak++
+		a.upStreak = 0
+	default:
+		a.upStreak, a.downStreak = 0, 0
+		return current, ""
+	}
+
+	if !a.lastChange.IsZero() && now.Sub(a.lastChange) < cfg.Cooldown {
+		return current, ""
+	}
+
+	if a.upStreak >= cfg.UpAfter && current < cfg.MaxWorkers {
+		a.upStreak = 0
+		// Растем быстро (удвоение), сжимаемся по одному, чтобы не раскачивать пул
+		target := min(current*2, cfg.MaxWorkers)
+		return target, fmt.Sprintf("заполнение %.0f%%, обработка %s", utilization*100, st.AvgLatency)
+	}
+	if a.downStreak >= cfg.DownAfter && current > cfg.MinWorkers {
+		a.downStreak = 0
+		return current - 1, fmt.Sprintf("заполнение %.0f%%", utilization*100)
+	}
+	return current, ""
+}
+
+// publishStats обновляет метрики состояния очереди
+func publishStats(st Stats) {
+	autoscaleMetrics.Set("workers", intVar(int64(st.Workers)))
+	autoscaleMetrics.Set("running", intVar(int64(st.Running)))
+	autoscaleMetrics.Set("pending", intVar(int64(st.Pending)))
+	autoscaleMetrics.Set("capacity", intVar(int64(st.Capacity)))
+	autoscaleMetrics.Set("avg_latency_us", intVar(st.AvgLatency.Microseconds()))
+}
+
+func intVar(v int64) *expvar.Int {
+	i := new(expvar.Int)
+	i.Set(v)
+	return i
+}
+
+func stringVar(v string) *expvar.String {
+	s := new(expvar.String)
+	s.Set(v)
+	return s
+}
@@ -3,46 +3,187 @@ package queue
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Cere6rum/MicroBlog2/internal/models"
 )
 
+// saturationThreshold - доля заполнения буфера, при которой очередь считается перегруженной
+const saturationThreshold = 0.9
+
+// Queue - очередь событий с пулом воркеров для асинхронной обработки
+type Queue[T any] struct {
+	name        string // название для сообщений об ошибках, в родительном падеже
+	queue       chan T
+	mu          sync.Mutex
+	workers     int             // желаемое количество воркеров
+	stops       []chan struct{} // сигнал остановки для каждого запущенного воркера
+	nextID      int
+	processFunc func(T) error
+	wg          sync.WaitGroup
+	done        chan struct{}
+	// sendMu: отправки держат его на чтение, Stop закрывает канал под
+	// записью, поэтому отправка в закрытый канал невозможна
+	sendMu     sync.RWMutex
+	delayed    map[*time.Timer]struct{} // отложенные события, ждущие срока
+	running    atomic.Int32             // количество работающих воркеров
+	processed  atomic.Uint64
+	avgLatency atomic.Int64 // скользящее среднее времени обработки, нс
+
+	// Очередь недоставленных событий (DLQ): события, обработка которых
+	// завершилась ошибкой, хранятся до ручного повтора через ReplayDeadLetters.
+	// При переполнении вытесняются самые старые.
+	deadMu      sync.Mutex
+	dead        []T
+	deadCap     int
+	deadDropped atomic.Uint64
+}
+
+// latencyWeight - вес нового замера в скользящем среднем времени обработки
+const latencyWeight = 0.2
+
+// Stats - снимок состояния очереди
+type Stats struct {
+	Pending     int           `json:"pending"`        // событий в буфере
+	Capacity    int           `json:"capacity"`       // размер буфера
+	Workers     int           `json:"workers"`        // настроенное количество воркеров
+	Running     int           `json:"running"`        // фактически работающих воркеров
+	Processed   uint64        `json:"processed"`      // обработано событий с момента запуска
+	AvgLatency  time.Duration `json:"avg_latency_ns"` // скользящее среднее времени обработки
+	Delayed     int           `json:"delayed"`        // отложенных событий, ждущих срока
+	DeadLetters int           `json:"dead_letters"`   // событий в очереди недоставленных
+	DeadDropped uint64        `json:"dead_dropped"`   // вытеснено из очереди недоставленных
+}
+
 // LikeQueue - очередь для асинхронной обработки лайков
-type LikeQueue struct {
-	queue   chan models.LikeEvent
-	workers int
-	wg      sync.WaitGroup
-	done    chan struct{}
+type LikeQueue = Queue[models.LikeEvent]
+
+// IndexQueue - очередь обновлений поискового индекса
+type IndexQueue = Queue[models.IndexEvent]
+
+// NotificationQueue - очередь создания уведомлений
+type NotificationQueue = Queue[models.NotificationEvent]
+
+// WebhookQueue - очередь доставки исходящих вебхуков
+type WebhookQueue = Queue[models.WebhookDelivery]
+
+// New создает очередь; name - что обрабатывает очередь, в родительном падеже ("лайков")
+func New[T any](name string, bufferSize, workers int) *Queue[T] {
+	return &Queue[T]{
+		name:    name,
+		queue:   make(chan T, bufferSize),
+		workers: workers,
+		delayed: make(map[*time.Timer]struct{}),
+		done:    make(chan struct{}),
+	}
 }
 
 // NewLikeQueue создает новую очередь лайков
 func NewLikeQueue(bufferSize, workers int) *LikeQueue {
-	return &LikeQueue{
-		queue:   make(chan models.LikeEvent, bufferSize),
-		workers: workers,
-		done:    make(chan struct{}),
+	return New[models.LikeEvent]("лайков", bufferSize, workers)
+}
+
+// NewIndexQueue создает очередь индексации постов для поиска
+func NewIndexQueue(bufferSize, workers int) *IndexQueue {
+	return New[models.IndexEvent]("индексации", bufferSize, workers)
+}
+
+// NewNotificationQueue создает очередь создания уведомлений
+func NewNotificationQueue(bufferSize, workers int) *NotificationQueue {
+	return New[models.NotificationEvent]("уведомлений", bufferSize, workers)
+}
+
+// NewWebhookQueue создает очередь доставки вебхуков
+func NewWebhookQueue(bufferSize, workers int) *WebhookQueue {
+	return New[models.WebhookDelivery]("вебхуков", bufferSize, workers)
+}
+
+// SetDeadLetterCapacity включает очередь недоставленных событий размером n (0 - выключить)
+func (lq *Queue[T]) SetDeadLetterCapacity(n int) {
+	lq.deadMu.Lock()
+	defer lq.deadMu.Unlock()
+
+	lq.deadCap = max(n, 0)
+	if over := len(lq.dead) - lq.deadCap; over > 0 {
+		lq.dead = lq.dead[over:]
+		lq.deadDropped.Add(uint64(over))
 	}
 }
 
 // Start запускает обработчики (воркеры) очереди
-func (lq *LikeQueue) Start(processFunc func(models.LikeEvent) error) {
-	for i := 0; i < lq.workers; i++ {
-		lq.wg.Add(1)
-		go lq.worker(i, processFunc)
+func (lq *Queue[T]) Start(processFunc func(T) error) {
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+
+	lq.processFunc = processFunc
+	for len(lq.stops) < lq.workers {
+		lq.spawn()
+	}
+}
+
+// Resize меняет количество воркеров на лету.
+// Лишние воркеры дорабатывают текущее событие и завершаются, необработанные
+// события остаются в буфере и достаются оставшимся воркерам.
+func (lq *Queue[T]) Resize(workers int) error {
+	if workers <= 0 {
+		return fmt.Errorf("количество воркеров должно быть больше 0, получено %d", workers)
+	}
+
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+
+	select {
+	case <-lq.done:
+		return fmt.Errorf("очередь %s остановлена", lq.name)
+	default:
+	}
+
+	lq.workers = workers
+	if lq.processFunc == nil {
+		// Очередь еще не запущена: новое значение применится в Start
+		return nil
+	}
+	for len(lq.stops) < workers {
+		lq.spawn()
+	}
+	for len(lq.stops) > workers {
+		last := len(lq.stops) - 1
+		close(lq.stops[last])
+		lq.stops = lq.stops[:last]
 	}
+	return nil
 }
 
-// worker - горутина-обработчик событий лайков
-func (lq *LikeQueue) worker(id int, processFunc func(models.LikeEvent) error) {
+// spawn запускает одного воркера (вызывается под мьютексом)
+func (lq *Queue[T]) spawn() {
+	stop := make(chan struct{})
+	lq.stops = append(lq.stops, stop)
+	lq.wg.Add(1)
+	lq.running.Add(1)
+	go lq.worker(lq.nextID, stop, lq.processFunc)
+	lq.nextID++
+}
+
+// worker - горутина-обработчик событий очереди
+func (lq *Queue[T]) worker(id int, stop <-chan struct{}, processFunc func(T) error) {
 	defer lq.wg.Done()
+	defer lq.running.Add(-1)
 
 	for {
 		select {
 		case event := <-lq.queue:
-			// Обрабатываем событие лайка
+			// Обрабатываем событие
+			started := time.Now()
 			if err := processFunc(event); err != nil {
-				fmt.Printf("Worker %d: ошибка обработки лайка: %v\n", id, err)
+				fmt.Printf("Worker %d: ошибка обработки события очереди %s: %v\n", id, lq.name, err)
+				lq.deadLetter(event)
 			}
+			lq.observe(time.Since(started))
+
+		case <-stop:
+			// Воркер больше не нужен после уменьшения пула
+			return
 
 		case <-lq.done:
 			// Завершаем работу воркера
@@ -51,14 +192,200 @@ func (lq *LikeQueue) worker(id int, processFunc func(models.LikeEvent) error) {
 	}
 }
 
-// Enqueue добавляет событие лайка в очередь
-func (lq *LikeQueue) Enqueue(event models.LikeEvent) {
-	lq.queue <- event
+// observe учитывает время обработки события в скользящем среднем
+func (lq *Queue[T]) observe(d time.Duration) {
+	lq.processed.Add(1)
+	for {
+		old := lq.avgLatency.Load()
+		next := int64(d)
+		if old != 0 {
+			next = int64(float64(old)*(1-latencyWeight) + float64(d)*latencyWeight)
+		}
+		if lq.avgLatency.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// deadLetter сохраняет событие, обработка которого завершилась ошибкой
+func (lq *Queue[T]) deadLetter(event T) {
+	lq.deadMu.Lock()
+	defer lq.deadMu.Unlock()
+
+	if lq.deadCap == 0 {
+		return
+	}
+	if len(lq.dead) >= lq.deadCap {
+		lq.dead = lq.dead[1:]
+		lq.deadDropped.Add(1)
+	}
+	lq.dead = append(lq.dead, event)
+}
+
+// DeadLetters возвращает копию очереди недоставленных событий
+func (lq *Queue[T]) DeadLetters() []T {
+	lq.deadMu.Lock()
+	defer lq.deadMu.Unlock()
+	return append([]T(nil), lq.dead...)
+}
+
+// ReplayDeadLetters возвращает недоставленные события в основную очередь и
+// сообщает, сколько вернул. Повтор не блокируется: события, которым не хватило
+// места в буфере, остаются в DLQ до следующего повтора. События, которые снова
+// завершатся ошибкой, тоже вернутся в DLQ.
+func (lq *Queue[T]) ReplayDeadLetters() (int, error) {
+	// Stop закрывает канал только после done под тем же мьютексом,
+	// поэтому, пока он удерживается, отправка в канал безопасна
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+	select {
+	case <-lq.done:
+		return 0, fmt.Errorf("очередь %s остановлена", lq.name)
+	default:
+	}
+
+	lq.deadMu.Lock()
+	events := lq.dead
+	lq.dead = nil
+	lq.deadMu.Unlock()
+
+	replayed := 0
+	for _, event := range events {
+		if !lq.TryEnqueue(event) {
+			break
+		}
+		replayed++
+	}
+	if rest := events[replayed:]; len(rest) > 0 {
+		lq.deadMu.Lock()
+		// Пока шел повтор, в DLQ могли попасть новые события: старые идут первыми
+		lq.dead = append(rest, lq.dead...)
+		if over := len(lq.dead) - lq.deadCap; over > 0 {
+			lq.dead = lq.dead[over:]
+			lq.deadDropped.Add(uint64(over))
+		}
+		lq.deadMu.Unlock()
+	}
+	return replayed, nil
+}
+
+// Enqueue добавляет событие в очередь, ожидая места в буфере. Возвращает
+// false, если очередь остановлена (в том числе пока Enqueue ждал места).
+func (lq *Queue[T]) Enqueue(event T) bool {
+	lq.sendMu.RLock()
+	defer lq.sendMu.RUnlock()
+	select {
+	case <-lq.done:
+		return false
+	default:
+	}
+	select {
+	case lq.queue <- event:
+		return true
+	case <-lq.done:
+		return false
+	}
+}
+
+// TryEnqueue добавляет событие, если в буфере есть место, и не блокируется.
+// Возвращает false, если очередь переполнена или остановлена.
+func (lq *Queue[T]) TryEnqueue(event T) bool {
+	lq.sendMu.RLock()
+	defer lq.sendMu.RUnlock()
+	select {
+	case <-lq.done:
+		return false
+	default:
+	}
+	select {
+	case lq.queue <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// EnqueueAfter ставит событие в очередь через delay, не занимая воркер на
+// время ожидания. Если к сроку буфер переполнен, событие уходит в DLQ.
+// Возвращает false, если очередь уже остановлена; отложенные события,
+// не дождавшиеся срока до Stop, отбрасываются.
+func (lq *Queue[T]) EnqueueAfter(event T, delay time.Duration) bool {
+	lq.mu.Lock()
+	defer lq.mu.Unlock()
+	select {
+	case <-lq.done:
+		return false
+	default:
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		lq.mu.Lock()
+		defer lq.mu.Unlock()
+		delete(lq.delayed, timer)
+		select {
+		case <-lq.done:
+			return
+		default:
+		}
+		if !lq.TryEnqueue(event) {
+			fmt.Printf("Очередь %s переполнена, отложенное событие перемещено в DLQ\n", lq.name)
+			lq.deadLetter(event)
+		}
+	})
+	lq.delayed[timer] = struct{}{}
+	return true
+}
+
+// Stats возвращает текущее состояние очереди
+func (lq *Queue[T]) Stats() Stats {
+	lq.mu.Lock()
+	workers := lq.workers
+	delayed := len(lq.delayed)
+	lq.mu.Unlock()
+	lq.deadMu.Lock()
+	dead := len(lq.dead)
+	lq.deadMu.Unlock()
+
+	return Stats{
+		Pending:     len(lq.queue),
+		Capacity:    cap(lq.queue),
+		Workers:     workers,
+		Running:     int(lq.running.Load()),
+		Processed:   lq.processed.Load(),
+		AvgLatency:  time.Duration(lq.avgLatency.Load()),
+		Delayed:     delayed,
+		DeadLetters: dead,
+		DeadDropped: lq.deadDropped.Load(),
+	}
+}
+
+// Healthy проверяет, что воркеры запущены и буфер не переполнен
+func (lq *Queue[T]) Healthy() error {
+	st := lq.Stats()
+	if st.Running == 0 {
+		return fmt.Errorf("воркеры очереди %s не запущены", lq.name)
+	}
+	if st.Capacity > 0 && float64(st.Pending) >= float64(st.Capacity)*saturationThreshold {
+		return fmt.Errorf("очередь %s перегружена: %d из %d", lq.name, st.Pending, st.Capacity)
+	}
+	return nil
 }
 
 // Stop останавливает обработку очереди
-func (lq *LikeQueue) Stop() {
+func (lq *Queue[T]) Stop() {
+	lq.mu.Lock()
 	close(lq.done)
+	lq.stops = nil
+	for timer := range lq.delayed {
+		timer.Stop()
+	}
+	clear(lq.delayed)
+	lq.mu.Unlock()
+
 	lq.wg.Wait()
+	// Ждущие места Enqueue уже вышли по done, новые отправки его увидят
+	lq.sendMu.Lock()
 	close(lq.queue)
+	lq.sendMu.Unlock()
 }
@@ -0,0 +1,290 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// TestResizeKeepsEvents проверяет, что изменение пула воркеров не теряет события
+func TestResizeKeepsEvents(t *testing.T) {
+	const total = 200
+
+	lq := NewLikeQueue(total, 2)
+	var processed atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(total)
+	lq.Start(func(models.LikeEvent) error {
+		time.Sleep(time.Millisecond)
+		processed.Add(1)
+		wg.Done()
+		return nil
+	})
+
+	for i := 0; i < total; i++ {
+		lq.Enqueue(models.LikeEvent{PostID: i, Username: "user"})
+		switch i {
+		case 50:
+			if err := lq.Resize(6); err != nil {
+				t.Fatalf("Ошибка увеличения пула: %v", err)
+			}
+		case 120:
+			if err := lq.Resize(1); err != nil {
+				t.Fatalf("Ошибка уменьшения пула: %v", err)
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Обработано %d из %d событий", processed.Load(), total)
+	}
+
+	// Лишние воркеры завершаются асинхронно
+	deadline := time.Now().Add(time.Second)
+	for lq.Stats().Running != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := lq.Stats(); st.Workers != 1 || st.Running != 1 {
+		t.Errorf("Ожидали 1 воркер, получили %+v", st)
+	}
+
+	lq.Stop()
+	if err := lq.Resize(2); err == nil {
+		t.Error("Ожидали ошибку при изменении пула остановленной очереди")
+	}
+}
+
+// TestAutoscalerDecide проверяет пороги, гистерезис и паузу между изменениями пула
+func TestAutoscalerDecide(t *testing.T) {
+	a, err := NewAutoscaler(NewLikeQueue(100, 2), AutoscaleConfig{
+		Enabled:              true,
+		MinWorkers:           1,
+		MaxWorkers:           8,
+		Interval:             time.Second,
+		ScaleUpUtilization:   0.5,
+		ScaleDownUtilization: 0.1,
+		UpAfter:              2,
+		DownAfter:            3,
+		Cooldown:             10 * time.Second,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Ошибка создания автомасштабирования: %v", err)
+	}
+
+	now := time.Now()
+	busy := Stats{Pending: 60, Capacity: 100, Workers: 2}
+	if got, _ := a.decide(busy, now); got != 2 {
+		t.Errorf("Первый опрос выше порога не должен менять пул, получили %d", got)
+	}
+	if got, _ := a.decide(busy, now); got != 4 {
+		t.Errorf("Второй опрос подряд должен удвоить пул, получили %d", got)
+	}
+	a.lastChange = now
+
+	// Заполнение между порогами сбрасывает накопленную серию
+	idle := Stats{Pending: 0, Capacity: 100, Workers: 4}
+	middle := Stats{Pending: 30, Capacity: 100, Workers: 4}
+	later := now.Add(time.Minute)
+	a.decide(idle, later)
+	a.decide(idle, later)
+	a.decide(middle, later)
+	if got, _ := a.decide(idle, later); got != 4 {
+		t.Errorf("После сброса серии пул не должен сжиматься, получили %d", got)
+	}
+	a.decide(idle, later)
+	if got, _ := a.decide(idle, later); got != 3 {
+		t.Errorf("Ожидали сжатие до 3 воркеров, получили %d", got)
+	}
+
+	// Во время паузы после изменения пул не трогаем
+	a.lastChange = later
+	a.decide(busy, later.Add(time.Second))
+	if got, _ := a.decide(Stats{Pending: 60, Capacity: 100, Workers: 3}, later.Add(time.Second)); got != 3 {
+		t.Errorf("Во время паузы пул не должен меняться, получили %d", got)
+	}
+}
+
+// TestAutoscalerTickWithoutLogger проверяет, что изменение пула
+// без переданного логгера не приводит к панике
+func TestAutoscalerTickWithoutLogger(t *testing.T) {
+	lq := NewLikeQueue(100, 3)
+	lq.Start(func(e models.LikeEvent) error { return nil })
+	defer lq.Stop()
+
+	a, err := NewAutoscaler(lq, AutoscaleConfig{
+		Enabled:              true,
+		MinWorkers:           1,
+		MaxWorkers:           8,
+		Interval:             time.Second,
+		ScaleUpUtilization:   0.5,
+		ScaleDownUtilization: 0.1,
+		UpAfter:              1,
+		DownAfter:            1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Ошибка создания автомасштабирования: %v", err)
+	}
+
+	a.Tick(time.Now())
+	if st := lq.Stats(); st.Workers != 2 {
+		t.Errorf("Ожидали сжатие до 2 воркеров, получили %+v", st)
+	}
+}
+
+// TestDeadLetters проверяет сохранение событий с ошибкой и их повтор
+func TestDeadLetters(t *testing.T) {
+	lq := NewLikeQueue(10, 1)
+	lq.SetDeadLetterCapacity(2)
+	defer lq.Stop()
+
+	var fail atomic.Bool
+	fail.Store(true)
+	processed := make(chan int, 10)
+	lq.Start(func(e models.LikeEvent) error {
+		if fail.Load() {
+			processed <- -1
+			return errors.New("временная ошибка")
+		}
+		processed <- e.PostID
+		return nil
+	})
+
+	// Тест 1: при переполнении DLQ вытесняются самые старые события
+	for i := 1; i <= 3; i++ {
+		lq.Enqueue(models.LikeEvent{PostID: i})
+		<-processed
+	}
+	deadline := time.Now().Add(time.Second)
+	for lq.Stats().DeadLetters != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	st := lq.Stats()
+	if st.DeadLetters != 2 || st.DeadDropped != 1 {
+		t.Fatalf("Ожидали 2 события в DLQ и 1 вытесненное, получили %+v", st)
+	}
+	if dead := lq.DeadLetters(); dead[0].PostID != 2 || dead[1].PostID != 3 {
+		t.Errorf("Ожидали в DLQ посты 2 и 3, получили %+v", dead)
+	}
+
+	// Тест 2: повтор возвращает события в очередь
+	fail.Store(false)
+	n, err := lq.ReplayDeadLetters()
+	if err != nil || n != 2 {
+		t.Fatalf("Ожидали повтор 2 событий, получили %d (%v)", n, err)
+	}
+	got := map[int]bool{<-processed: true, <-processed: true}
+	if !got[2] || !got[3] {
+		t.Errorf("Повторно обработаны не те события: %v", got)
+	}
+	if st := lq.Stats(); st.DeadLetters != 0 {
+		t.Errorf("DLQ должна опустеть, осталось %d", st.DeadLetters)
+	}
+}
+
+// TestReplayDeadLettersFullBuffer проверяет, что повтор не блокируется на
+// заполненном буфере, а после остановки очереди возвращает ошибку
+func TestReplayDeadLettersFullBuffer(t *testing.T) {
+	lq := NewLikeQueue(2, 1) // воркеры не запущены: буфер не разбирается
+	lq.SetDeadLetterCapacity(10)
+	for i := 1; i <= 3; i++ {
+		lq.deadLetter(models.LikeEvent{PostID: i})
+	}
+	lq.Enqueue(models.LikeEvent{PostID: 100})
+
+	// Тест 1: в буфер помещается одно событие, остальные остаются в DLQ по порядку
+	n, err := lq.ReplayDeadLetters()
+	if err != nil || n != 1 {
+		t.Fatalf("Ожидали повтор 1 события, получили %d (%v)", n, err)
+	}
+	if dead := lq.DeadLetters(); len(dead) != 2 || dead[0].PostID != 2 || dead[1].PostID != 3 {
+		t.Errorf("Ожидали в DLQ посты 2 и 3, получили %+v", dead)
+	}
+
+	// Тест 2: остановленная очередь отказывает без паники
+	lq.Stop()
+	if _, err := lq.ReplayDeadLetters(); err == nil {
+		t.Error("Ожидали ошибку для остановленной очереди")
+	}
+}
+
+// TestEnqueueAfter проверяет отложенную постановку в очередь, уход в DLQ при
+// заполненном буфере и отмену отложенных событий при остановке
+func TestEnqueueAfter(t *testing.T) {
+	lq := NewLikeQueue(1, 1) // воркеры не запущены: буфер не разбирается
+	lq.SetDeadLetterCapacity(10)
+
+	// Тест 1: событие попадает в буфер только по истечении срока
+	if !lq.EnqueueAfter(models.LikeEvent{PostID: 1}, 20*time.Millisecond) {
+		t.Fatal("Ожидали постановку отложенного события")
+	}
+	if st := lq.Stats(); st.Delayed != 1 || st.Pending != 0 {
+		t.Errorf("Ожидали 1 отложенное событие и пустой буфер, получили %+v", st)
+	}
+	deadline := time.Now().Add(time.Second)
+	for lq.Stats().Pending != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if st := lq.Stats(); st.Delayed != 0 || st.Pending != 1 {
+		t.Fatalf("Ожидали событие в буфере, получили %+v", st)
+	}
+
+	// Тест 2: к сроку буфер заполнен - событие уходит в DLQ
+	lq.EnqueueAfter(models.LikeEvent{PostID: 2}, time.Millisecond)
+	for lq.Stats().DeadLetters != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if dead := lq.DeadLetters(); len(dead) != 1 || dead[0].PostID != 2 {
+		t.Errorf("Ожидали в DLQ пост 2, получили %+v", dead)
+	}
+
+	// Тест 3: остановка отменяет отложенные события, новые не принимаются
+	lq.EnqueueAfter(models.LikeEvent{PostID: 3}, time.Hour)
+	lq.Stop()
+	if st := lq.Stats(); st.Delayed != 0 {
+		t.Errorf("Ожидали отмену отложенных событий, осталось %d", st.Delayed)
+	}
+	if lq.EnqueueAfter(models.LikeEvent{PostID: 4}, time.Millisecond) {
+		t.Error("Остановленная очередь не должна принимать события")
+	}
+}
+
+// TestEnqueueAfterStop проверяет, что отправка в остановленную очередь
+// отказывает без паники, в том числе если Enqueue ждал места в буфере
+func TestEnqueueAfterStop(t *testing.T) {
+	lq := NewLikeQueue(1, 1) // воркеры не запущены: буфер не разбирается
+	if !lq.Enqueue(models.LikeEvent{PostID: 1}) {
+		t.Fatal("Ожидали постановку события")
+	}
+
+	// Тест 1: Enqueue, ждущий места, завершается при остановке
+	blocked := make(chan bool)
+	go func() {
+		blocked <- lq.Enqueue(models.LikeEvent{PostID: 2})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	lq.Stop()
+	select {
+	case ok := <-blocked:
+		if ok {
+			t.Error("Ожидали отказ Enqueue после остановки")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue не завершился после остановки очереди")
+	}
+
+	// Тест 2: новые отправки отказывают
+	if lq.Enqueue(models.LikeEvent{PostID: 3}) || lq.TryEnqueue(models.LikeEvent{PostID: 4}) {
+		t.Error("Ожидали отказ отправки в остановленную очередь")
+	}
+}
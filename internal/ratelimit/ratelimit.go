@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// idleTTL - через сколько неактивный клиент удаляется из таблицы
+const idleTTL = 10 * time.Minute
+
+// bucket - токен-бакет одного клиента
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// Limiter ограничивает частоту запросов по ключу (IP клиента).
+// Лимиты можно менять на лету через SetLimits.
+type Limiter struct {
+	mu        sync.Mutex
+	enabled   bool
+	rate      float64 // токенов в секунду
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time // источник времени (подменяется в тестах)
+}
+
+// NewLimiter создает ограничитель с rps запросов в секунду и всплеском burst
+func NewLimiter(enabled bool, rps float64, burst int) *Limiter {
+	return &Limiter{
+		enabled: enabled,
+		rate:    rps,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimits атомарно меняет параметры ограничения.
+// Накопленные токены клиентов обрезаются по новому burst.
+func (l *Limiter) SetLimits(enabled bool, rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enabled = enabled
+	l.rate = rps
+	l.burst = float64(burst)
+	for _, b := range l.buckets {
+		b.tokens = math.Min(b.tokens, l.burst)
+	}
+}
+
+// Allow списывает токен для ключа. Если токенов нет, возвращает false
+// и время, через которое появится следующий токен.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabled {
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	// Пополняем бакет пропорционально прошедшему времени
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if l.rate <= 0 {
+		return false, time.Second
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep удаляет давно неактивных клиентов (вызывается под мьютексом)
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware отвечает 429 Too Many Requests, если клиент превысил лимит
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.Allow(clientKey(r))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Слишком много запросов", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey возвращает IP клиента без порта
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
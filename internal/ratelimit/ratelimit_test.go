@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBucket проверяет всплеск, пополнение токенов и изоляцию клиентов
+func TestBucket(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(true, 2, 3)
+	l.now = func() time.Time { return now }
+
+	// Тест 1: всплеск burst запросов проходит сразу, следующий - нет
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("Запрос %d из всплеска должен пройти", i+1)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("Ожидали отказ с ожиданием 500 мс, получили %v %v", ok, wait)
+	}
+
+	// Тест 2: другой клиент не затронут
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("Лимит одного клиента не должен влиять на другого")
+	}
+
+	// Тест 3: токены пополняются со скоростью rate, но не выше burst
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("Через 500 мс должен появиться один токен")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("Второго токена еще нет")
+	}
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("После простоя запрос %d должен пройти", i+1)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("Накопление не должно превышать burst")
+	}
+}
+
+// TestSetLimits проверяет смену лимитов на лету
+func TestSetLimits(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(true, 1, 10)
+	l.now = func() time.Time { return now }
+	l.Allow("a")
+
+	// Новый burst обрезает накопленные токены
+	l.SetLimits(true, 1, 2)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("Запрос %d должен пройти", i+1)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("Токены должны обрезаться по новому burst")
+	}
+
+	l.SetLimits(false, 1, 2)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("Выключенный ограничитель должен пропускать все запросы")
+	}
+}
+
+// TestMiddleware проверяет ответ 429 с Retry-After
+func TestMiddleware(t *testing.T) {
+	l := NewLimiter(true, 0.5, 1)
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "10.0.0.1:5555"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	if w := do(); w.Code != http.StatusOK {
+		t.Fatalf("Первый запрос должен пройти, получили %d", w.Code)
+	}
+	w := do()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("Ожидали 429 с Retry-After 2, получили %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
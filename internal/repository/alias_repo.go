@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// AliasRepository defines abstraction for storage of former usernames.
+// Aliases are keyed by canonical name, so one alias also covers names
+// differing only in case or lookalike letters.
+type AliasRepository interface {
+	// Put stores the alias, replacing one with the same canonical name.
+	Put(ctx context.Context, alias *models.UsernameAlias) error
+	Get(ctx context.Context, canonical string) (*models.UsernameAlias, error)
+	Delete(ctx context.Context, canonical string) error
+	// DeleteByUser removes all aliases of the user and returns how many.
+	DeleteByUser(ctx context.Context, userID int) int
+}
+
+// InMemoryAliasRepo keeps aliases in a map by canonical name.
+type InMemoryAliasRepo struct {
+	mu      sync.RWMutex
+	aliases map[string]*models.UsernameAlias
+}
+
+func NewInMemoryAliasRepo() *InMemoryAliasRepo {
+	return &InMemoryAliasRepo{aliases: make(map[string]*models.UsernameAlias)}
+}
+
+func (r *InMemoryAliasRepo) Put(ctx context.Context, alias *models.UsernameAlias) error {
+	if alias.CanonicalName == "" {
+		return errors.New("alias canonical name is empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[alias.CanonicalName] = alias
+	return nil
+}
+
+func (r *InMemoryAliasRepo) Get(ctx context.Context, canonical string) (*models.UsernameAlias, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	alias, ok := r.aliases[canonical]
+	if !ok {
+		return nil, errors.New("alias not found")
+	}
+	return alias, nil
+}
+
+func (r *InMemoryAliasRepo) Delete(ctx context.Context, canonical string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.aliases, canonical)
+	return nil
+}
+
+func (r *InMemoryAliasRepo) DeleteByUser(ctx context.Context, userID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before := len(r.aliases)
+	maps.DeleteFunc(r.aliases, func(_ string, a *models.UsernameAlias) bool { return a.UserID == userID })
+	return before - len(r.aliases)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// AuditRepository defines abstraction for the append-only audit log.
+type AuditRepository interface {
+	Add(ctx context.Context, entry *models.AuditEntry) error
+	// List returns all entries, newest first.
+	List(ctx context.Context) []*models.AuditEntry
+}
+
+// InMemoryAuditRepo keeps audit entries in insertion order. Entries are
+// never changed or removed.
+type InMemoryAuditRepo struct {
+	mu      sync.RWMutex
+	entries []*models.AuditEntry
+}
+
+func NewInMemoryAuditRepo() *InMemoryAuditRepo {
+	return &InMemoryAuditRepo{}
+}
+
+func (r *InMemoryAuditRepo) Add(ctx context.Context, entry *models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n := len(r.entries); n > 0 && r.entries[n-1].ID >= entry.ID {
+		return fmt.Errorf("audit entry %d is not newer than %d", entry.ID, r.entries[n-1].ID)
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *InMemoryAuditRepo) List(ctx context.Context) []*models.AuditEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.AuditEntry, len(r.entries))
+	for i, e := range r.entries {
+		out[len(out)-1-i] = e
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// EntityRepository defines abstraction for hashtag and mention indexes.
+type EntityRepository interface {
+	// Index replaces the post's hashtags and mentioned user IDs with the given ones.
+	Index(ctx context.Context, postID int, hashtags []string, mentions []int) error
+	// ListByTag returns IDs of posts with the hashtag, oldest first.
+	ListByTag(ctx context.Context, tag string) ([]int, error)
+	// ListByMention returns IDs of posts mentioning the user, oldest first.
+	ListByMention(ctx context.Context, userID int) ([]int, error)
+}
+
+// InMemoryEntityRepo keeps inverted indexes tag -> post IDs and user ID -> post IDs.
+type InMemoryEntityRepo struct {
+	mu       sync.RWMutex
+	tags     map[string]map[int]struct{}
+	mentions map[int]map[int]struct{}
+	byPost   map[int]indexedEntities
+}
+
+// indexedEntities - what is indexed for one post
+type indexedEntities struct {
+	hashtags []string
+	mentions []int
+}
+
+func NewInMemoryEntityRepo() *InMemoryEntityRepo {
+	return &InMemoryEntityRepo{
+		tags:     make(map[string]map[int]struct{}),
+		mentions: make(map[int]map[int]struct{}),
+		byPost:   make(map[int]indexedEntities),
+	}
+}
+
+func (r *InMemoryEntityRepo) Index(ctx context.Context, postID int, hashtags []string, mentions []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.byPost[postID]
+	unindex(r.tags, old.hashtags, postID)
+	unindex(r.mentions, old.mentions, postID)
+
+	index(r.tags, hashtags, postID)
+	index(r.mentions, mentions, postID)
+	if len(hashtags) == 0 && len(mentions) == 0 {
+		delete(r.byPost, postID)
+	} else {
+		r.byPost[postID] = indexedEntities{hashtags: slices.Clone(hashtags), mentions: slices.Clone(mentions)}
+	}
+	return nil
+}
+
+func (r *InMemoryEntityRepo) ListByTag(ctx context.Context, tag string) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedIDs(r.tags[tag]), nil
+}
+
+func (r *InMemoryEntityRepo) ListByMention(ctx context.Context, userID int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedIDs(r.mentions[userID]), nil
+}
+
+func index[K comparable](idx map[K]map[int]struct{}, keys []K, postID int) {
+	for _, k := range keys {
+		if idx[k] == nil {
+			idx[k] = make(map[int]struct{})
+		}
+		idx[k][postID] = struct{}{}
+	}
+}
+
+func unindex[K comparable](idx map[K]map[int]struct{}, keys []K, postID int) {
+	for _, k := range keys {
+		delete(idx[k], postID)
+		if len(idx[k]) == 0 {
+			delete(idx, k)
+		}
+	}
+}
+
+// sortedIDs returns set members in ascending order; post IDs grow with creation time.
+func sortedIDs(set map[int]struct{}) []int {
+	out := make([]int, 0, len(set))
+	for id := range set {
+		out = append(out, id)
+	}
+	slices.Sort(out)
+	return out
+}
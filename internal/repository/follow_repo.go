@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// FollowRepository defines abstraction for storage of follow relationships
+// between users. Users are referenced by ID, so renames do not affect it.
+type FollowRepository interface {
+	// Follow stores the relationship and reports whether it is new.
+	Follow(ctx context.Context, followerID, followeeID int) bool
+	// Unfollow removes the relationship and reports whether it existed.
+	Unfollow(ctx context.Context, followerID, followeeID int) bool
+	// Followers returns IDs of users following userID, oldest first.
+	Followers(ctx context.Context, userID int) []int
+	// Following returns IDs of users followed by userID, oldest first.
+	Following(ctx context.Context, userID int) []int
+	// DeleteByUser removes relationships of the user in both directions
+	// and returns how many.
+	DeleteByUser(ctx context.Context, userID int) int
+}
+
+// InMemoryFollowRepo keeps both directions of every relationship in maps
+// by user ID.
+type InMemoryFollowRepo struct {
+	mu        sync.RWMutex
+	followers map[int][]int
+	following map[int][]int
+}
+
+func NewInMemoryFollowRepo() *InMemoryFollowRepo {
+	return &InMemoryFollowRepo{
+		followers: make(map[int][]int),
+		following: make(map[int][]int),
+	}
+}
+
+func (r *InMemoryFollowRepo) Follow(ctx context.Context, followerID, followeeID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Contains(r.following[followerID], followeeID) {
+		return false
+	}
+	r.following[followerID] = append(r.following[followerID], followeeID)
+	r.followers[followeeID] = append(r.followers[followeeID], followerID)
+	return true
+}
+
+func (r *InMemoryFollowRepo) Unfollow(ctx context.Context, followerID, followeeID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.following[followerID], followeeID) {
+		return false
+	}
+	r.following[followerID] = without(r.following[followerID], followeeID)
+	r.followers[followeeID] = without(r.followers[followeeID], followerID)
+	return true
+}
+
+func (r *InMemoryFollowRepo) Followers(ctx context.Context, userID int) []int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.followers[userID])
+}
+
+func (r *InMemoryFollowRepo) Following(ctx context.Context, userID int) []int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.following[userID])
+}
+
+func (r *InMemoryFollowRepo) DeleteByUser(ctx context.Context, userID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := len(r.followers[userID]) + len(r.following[userID])
+	for _, id := range r.followers[userID] {
+		r.following[id] = without(r.following[id], userID)
+	}
+	for _, id := range r.following[userID] {
+		r.followers[id] = without(r.followers[id], userID)
+	}
+	delete(r.followers, userID)
+	delete(r.following, userID)
+	return removed
+}
+
+// without returns ids without id; the slice is copied, so callers holding
+// the previous one are not affected
+func without(ids []int, id int) []int {
+	return slices.DeleteFunc(slices.Clone(ids), func(v int) bool { return v == id })
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// MediaRepository defines abstraction for uploaded media metadata.
+type MediaRepository interface {
+	Create(ctx context.Context, media *models.Media) error
+	GetByID(ctx context.Context, id int) (*models.Media, error)
+	Update(ctx context.Context, media *models.Media) error
+	Delete(ctx context.Context, id int) error
+	// ListPending returns the owner's media not attached to any post, oldest first.
+	ListPending(ctx context.Context, ownerID int) []*models.Media
+	// ListPendingBefore returns media of all owners not attached to any post
+	// and uploaded before t, oldest first.
+	ListPendingBefore(ctx context.Context, t time.Time) []*models.Media
+}
+
+// InMemoryMediaRepo keeps media metadata by ID.
+type InMemoryMediaRepo struct {
+	mu    sync.RWMutex
+	media map[int]*models.Media
+}
+
+func NewInMemoryMediaRepo() *InMemoryMediaRepo {
+	return &InMemoryMediaRepo{media: make(map[int]*models.Media)}
+}
+
+func (r *InMemoryMediaRepo) Create(ctx context.Context, media *models.Media) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.media[media.ID]; ok {
+		return fmt.Errorf("media %d already exists", media.ID)
+	}
+	r.media[media.ID] = media
+	return nil
+}
+
+func (r *InMemoryMediaRepo) GetByID(ctx context.Context, id int) (*models.Media, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	media, ok := r.media[id]
+	if !ok {
+		return nil, errors.New("media not found")
+	}
+	return media, nil
+}
+
+func (r *InMemoryMediaRepo) Update(ctx context.Context, media *models.Media) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.media[media.ID]; !ok {
+		return errors.New("media not found")
+	}
+	r.media[media.ID] = media
+	return nil
+}
+
+func (r *InMemoryMediaRepo) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.media[id]; !ok {
+		return errors.New("media not found")
+	}
+	delete(r.media, id)
+	return nil
+}
+
+func (r *InMemoryMediaRepo) ListPending(ctx context.Context, ownerID int) []*models.Media {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*models.Media
+	for _, m := range r.media {
+		if m.OwnerID == ownerID && m.PostID == 0 {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (r *InMemoryMediaRepo) ListPendingBefore(ctx context.Context, t time.Time) []*models.Media {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*models.Media
+	for _, m := range r.media {
+		if m.PostID == 0 && m.CreatedAt.Before(t) {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
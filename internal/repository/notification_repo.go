@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// MaxNotificationsPerUser - сколько последних уведомлений хранится на пользователя
+const MaxNotificationsPerUser = 1000
+
+// NotificationRepository defines abstraction for user notifications and
+// per-type notification preferences.
+type NotificationRepository interface {
+	Create(ctx context.Context, n *models.Notification) error
+	Update(ctx context.Context, n *models.Notification) error
+	// FindUnread returns the unread notification of type typ about postID.
+	FindUnread(ctx context.Context, userID int, typ string, postID int) (*models.Notification, error)
+	// ListByUser returns notifications, most recently updated first.
+	ListByUser(ctx context.Context, userID int) []*models.Notification
+	// MarkRead marks the given notifications (all if ids is empty) as read
+	// and returns how many changed.
+	MarkRead(ctx context.Context, userID int, ids []int) int
+	DeleteByPost(ctx context.Context, postID int) error
+	// DeleteByUser removes the user's notifications and preferences.
+	DeleteByUser(ctx context.Context, userID int) error
+	// RemoveActor removes the user from the actors of all notifications and
+	// decrements their counts. Notifications left without actors are deleted.
+	// Returns how many notifications changed or were deleted.
+	RemoveActor(ctx context.Context, actorID int) int
+	// Preferences returns explicitly set preferences; missing types are enabled.
+	Preferences(ctx context.Context, userID int) map[string]bool
+	SetPreferences(ctx context.Context, userID int, prefs map[string]bool) error
+}
+
+// InMemoryNotificationRepo keeps the latest MaxNotificationsPerUser
+// notifications per user.
+type InMemoryNotificationRepo struct {
+	mu     sync.RWMutex
+	byUser map[int][]*models.Notification
+	prefs  map[int]map[string]bool
+}
+
+func NewInMemoryNotificationRepo() *InMemoryNotificationRepo {
+	return &InMemoryNotificationRepo{
+		byUser: make(map[int][]*models.Notification),
+		prefs:  make(map[int]map[string]bool),
+	}
+}
+
+func (r *InMemoryNotificationRepo) Create(ctx context.Context, n *models.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := r.byUser[n.UserID]
+	for _, existing := range list {
+		if existing.ID == n.ID {
+			return fmt.Errorf("notification %d already exists", n.ID)
+		}
+	}
+	if len(list) >= MaxNotificationsPerUser {
+		// Вытесняем уведомление с самым старым последним событием
+		oldest := 0
+		for i, existing := range list {
+			if existing.UpdatedAt.Before(list[oldest].UpdatedAt) {
+				oldest = i
+			}
+		}
+		list = slices.Delete(list, oldest, oldest+1)
+	}
+	r.byUser[n.UserID] = append(list, n)
+	return nil
+}
+
+func (r *InMemoryNotificationRepo) Update(ctx context.Context, n *models.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.byUser[n.UserID] {
+		if existing.ID == n.ID {
+			r.byUser[n.UserID][i] = n
+			return nil
+		}
+	}
+	return errors.New("notification not found")
+}
+
+func (r *InMemoryNotificationRepo) FindUnread(ctx context.Context, userID int, typ string, postID int) (*models.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, n := range r.byUser[userID] {
+		if !n.Read && n.Type == typ && n.PostID == postID {
+			return n, nil
+		}
+	}
+	return nil, errors.New("notification not found")
+}
+
+func (r *InMemoryNotificationRepo) ListByUser(ctx context.Context, userID int) []*models.Notification {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.Notification, len(r.byUser[userID]))
+	copy(out, r.byUser[userID])
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].UpdatedAt.Equal(out[j].UpdatedAt) {
+			return out[i].UpdatedAt.After(out[j].UpdatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+func (r *InMemoryNotificationRepo) MarkRead(ctx context.Context, userID int, ids []int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	marked := 0
+	for _, n := range r.byUser[userID] {
+		if !n.Read && (len(ids) == 0 || slices.Contains(ids, n.ID)) {
+			n.Read = true
+			marked++
+		}
+	}
+	return marked
+}
+
+// DeleteByPost removes notifications about a deleted post.
+func (r *InMemoryNotificationRepo) DeleteByPost(ctx context.Context, postID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for userID, list := range r.byUser {
+		r.byUser[userID] = slices.DeleteFunc(list, func(n *models.Notification) bool {
+			return n.PostID == postID
+		})
+	}
+	return nil
+}
+
+func (r *InMemoryNotificationRepo) DeleteByUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byUser, userID)
+	delete(r.prefs, userID)
+	return nil
+}
+
+func (r *InMemoryNotificationRepo) RemoveActor(ctx context.Context, actorID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := 0
+	for userID, list := range r.byUser {
+		r.byUser[userID] = slices.DeleteFunc(list, func(n *models.Notification) bool {
+			i := slices.Index(n.ActorIDs, actorID)
+			if i < 0 {
+				return false
+			}
+			changed++
+			n.ActorIDs = slices.Delete(slices.Clone(n.ActorIDs), i, i+1)
+			n.Count--
+			return len(n.ActorIDs) == 0 || n.Count <= 0
+		})
+	}
+	return changed
+}
+
+func (r *InMemoryNotificationRepo) Preferences(ctx context.Context, userID int) map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.prefs[userID])
+}
+
+func (r *InMemoryNotificationRepo) SetPreferences(ctx context.Context, userID int, prefs map[string]bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefs[userID] = maps.Clone(prefs)
+	return nil
+}
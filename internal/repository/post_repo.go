@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"slices"
+	"sync"
 
 	"github.com/Cere6rum/MicroBlog2/internal/models"
 	"github.com/Cere6rum/MicroBlog2/internal/syncutils"
@@ -9,31 +12,62 @@ import (
 
 // PostRepository defines abstraction for post storage.
 type PostRepository interface {
-	Create(post *models.Post) error
-	GetByID(id int) (*models.Post, error)
-	List() []*models.Post
-	Update(post *models.Post) error
+	Create(ctx context.Context, post *models.Post) error
+	GetByID(ctx context.Context, id int) (*models.Post, error)
+	List(ctx context.Context) []*models.Post
+	Update(ctx context.Context, post *models.Post) error
+	// Delete removes the post; its ID is never reused.
+	Delete(ctx context.Context, id int) error
+	// ListReplies returns direct replies to the post, oldest first.
+	ListReplies(ctx context.Context, parentID int) []*models.Post
+	// GetRepost returns the user's plain repost of the original post, if any.
+	GetRepost(ctx context.Context, authorID, originalID int) (*models.Post, error)
+	// Ping проверяет доступность хранилища
+	Ping(ctx context.Context) error
 }
 
 // InMemoryPostRepo is an adapter over syncutils.SafePostStorage.
 type InMemoryPostRepo struct {
 	storage *syncutils.SafePostStorage
+
+	mu      sync.RWMutex
+	replies map[int][]int  // parent ID -> reply IDs in creation order
+	reposts map[[2]int]int // (author ID, original ID) -> repost ID
 }
 
 func NewInMemoryPostRepo() *InMemoryPostRepo {
-	return &InMemoryPostRepo{storage: syncutils.NewSafePostStorage()}
+	return &InMemoryPostRepo{
+		storage: syncutils.NewSafePostStorage(),
+		replies: make(map[int][]int),
+		reposts: make(map[[2]int]int),
+	}
 }
 
-func (r *InMemoryPostRepo) Create(post *models.Post) error {
-	r.storage.Add(post)
+func (r *InMemoryPostRepo) Create(ctx context.Context, post *models.Post) error {
+	// Posts are stored by ID, so concurrent creates and imports with gaps keep IDs aligned
+	if err := r.storage.Insert(post.ID-1, post); err != nil {
+		return errors.New("post already exists")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if post.ReplyToID > 0 {
+		r.replies[post.ReplyToID] = append(r.replies[post.ReplyToID], post.ID)
+	}
+	if post.Kind == models.PostKindRepost {
+		r.reposts[[2]int{post.AuthorID, post.OriginalID}] = post.ID
+	}
 	return nil
 }
 
-func (r *InMemoryPostRepo) GetByID(id int) (*models.Post, error) {
+func (r *InMemoryPostRepo) GetByID(ctx context.Context, id int) (*models.Post, error) {
 	v, ok := r.storage.GetByIndex(id - 1)
 	if !ok {
 		return nil, errors.New("post not found")
 	}
+	if v == nil {
+		return nil, errors.New("post not found") // deleted
+	}
 	p, ok := v.(*models.Post)
 	if !ok {
 		return nil, errors.New("stored value has unexpected type")
@@ -41,7 +75,7 @@ func (r *InMemoryPostRepo) GetByID(id int) (*models.Post, error) {
 	return p, nil
 }
 
-func (r *InMemoryPostRepo) List() []*models.Post {
+func (r *InMemoryPostRepo) List(ctx context.Context) []*models.Post {
 	raw := r.storage.GetAll()
 	out := make([]*models.Post, 0, len(raw))
 	for _, v := range raw {
@@ -52,11 +86,61 @@ func (r *InMemoryPostRepo) List() []*models.Post {
 	return out
 }
 
-func (r *InMemoryPostRepo) Update(post *models.Post) error {
+func (r *InMemoryPostRepo) Update(ctx context.Context, post *models.Post) error {
 	// Naive implementation: replace by index if exists
-	if post.ID <= 0 || post.ID > r.storage.Len() {
-		return errors.New("post not found")
+	if _, err := r.GetByID(ctx, post.ID); err != nil {
+		return err
 	}
 	r.storage.SetByIndex(post.ID-1, post)
 	return nil
 }
+
+func (r *InMemoryPostRepo) Delete(ctx context.Context, id int) error {
+	post, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// The slot stays as a tombstone so that IDs keep matching indexes
+	if err := r.storage.SetByIndex(id-1, nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if post.ReplyToID > 0 {
+		r.replies[post.ReplyToID] = slices.DeleteFunc(r.replies[post.ReplyToID], func(v int) bool { return v == id })
+	}
+	if post.Kind == models.PostKindRepost {
+		delete(r.reposts, [2]int{post.AuthorID, post.OriginalID})
+	}
+	return nil
+}
+
+func (r *InMemoryPostRepo) ListReplies(ctx context.Context, parentID int) []*models.Post {
+	r.mu.RLock()
+	ids := make([]int, len(r.replies[parentID]))
+	copy(ids, r.replies[parentID])
+	r.mu.RUnlock()
+
+	out := make([]*models.Post, 0, len(ids))
+	for _, id := range ids {
+		if p, err := r.GetByID(ctx, id); err == nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (r *InMemoryPostRepo) GetRepost(ctx context.Context, authorID, originalID int) (*models.Post, error) {
+	r.mu.RLock()
+	id, ok := r.reposts[[2]int{authorID, originalID}]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errors.New("repost not found")
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *InMemoryPostRepo) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
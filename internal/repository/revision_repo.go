@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// RevisionRepository defines abstraction for post edit history (post_revisions).
+type RevisionRepository interface {
+	Add(ctx context.Context, rev *models.PostRevision) error
+	ListByPost(ctx context.Context, postID int) ([]*models.PostRevision, error)
+	// DeleteByPost removes the history of a deleted post.
+	DeleteByPost(ctx context.Context, postID int) error
+}
+
+// InMemoryRevisionRepo keeps revisions grouped by post ID, oldest first.
+type InMemoryRevisionRepo struct {
+	mu        sync.RWMutex
+	revisions map[int][]*models.PostRevision
+}
+
+func NewInMemoryRevisionRepo() *InMemoryRevisionRepo {
+	return &InMemoryRevisionRepo{revisions: make(map[int][]*models.PostRevision)}
+}
+
+func (r *InMemoryRevisionRepo) Add(ctx context.Context, rev *models.PostRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revisions[rev.PostID] = append(r.revisions[rev.PostID], rev)
+	return nil
+}
+
+func (r *InMemoryRevisionRepo) ListByPost(ctx context.Context, postID int) ([]*models.PostRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.PostRevision, len(r.revisions[postID]))
+	copy(out, r.revisions[postID])
+	return out, nil
+}
+
+func (r *InMemoryRevisionRepo) DeleteByPost(ctx context.Context, postID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.revisions, postID)
+	return nil
+}
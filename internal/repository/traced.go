@@ -0,0 +1,554 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+var tracer = otel.Tracer("github.com/Cere6rum/MicroBlog2/internal/repository")
+
+// startSpan открывает клиентский спан вокруг обращения к хранилищу
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan фиксирует ошибку (если есть) и закрывает спан
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TracedUserRepo оборачивает UserRepository и пишет спан на каждый вызов.
+type TracedUserRepo struct {
+	next UserRepository
+}
+
+func NewTracedUserRepo(next UserRepository) *TracedUserRepo {
+	return &TracedUserRepo{next: next}
+}
+
+func (r *TracedUserRepo) Create(ctx context.Context, user *models.User) error {
+	ctx, span := startSpan(ctx, "UserRepository.Create", attribute.String("user.name", user.Username))
+	err := r.next.Create(ctx, user)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedUserRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UserRepository.GetByUsername", attribute.String("user.name", username))
+	u, err := r.next.GetByUsername(ctx, username)
+	endSpan(span, err)
+	return u, err
+}
+
+func (r *TracedUserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UserRepository.GetByID", attribute.Int("user.id", id))
+	u, err := r.next.GetByID(ctx, id)
+	endSpan(span, err)
+	return u, err
+}
+
+func (r *TracedUserRepo) Update(ctx context.Context, user *models.User) error {
+	ctx, span := startSpan(ctx, "UserRepository.Update", attribute.String("user.name", user.Username))
+	err := r.next.Update(ctx, user)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedUserRepo) Exists(ctx context.Context, username string) bool {
+	ctx, span := startSpan(ctx, "UserRepository.Exists", attribute.String("user.name", username))
+	ok := r.next.Exists(ctx, username)
+	span.SetAttributes(attribute.Bool("user.exists", ok))
+	endSpan(span, nil)
+	return ok
+}
+
+func (r *TracedUserRepo) Rename(ctx context.Context, oldUsername string, user *models.User) error {
+	ctx, span := startSpan(ctx, "UserRepository.Rename",
+		attribute.Int("user.id", user.ID), attribute.String("user.name", user.Username))
+	err := r.next.Rename(ctx, oldUsername, user)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedUserRepo) Delete(ctx context.Context, username string) error {
+	ctx, span := startSpan(ctx, "UserRepository.Delete", attribute.String("user.name", username))
+	err := r.next.Delete(ctx, username)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedUserRepo) List(ctx context.Context) []*models.User {
+	ctx, span := startSpan(ctx, "UserRepository.List")
+	users := r.next.List(ctx)
+	span.SetAttributes(attribute.Int("user.count", len(users)))
+	endSpan(span, nil)
+	return users
+}
+
+func (r *TracedUserRepo) ExistsCanonical(ctx context.Context, canonical string) bool {
+	ctx, span := startSpan(ctx, "UserRepository.ExistsCanonical")
+	ok := r.next.ExistsCanonical(ctx, canonical)
+	span.SetAttributes(attribute.Bool("user.exists", ok))
+	endSpan(span, nil)
+	return ok
+}
+
+func (r *TracedUserRepo) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "UserRepository.Ping")
+	err := r.next.Ping(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// TracedPostRepo оборачивает PostRepository и пишет спан на каждый вызов.
+type TracedPostRepo struct {
+	next PostRepository
+}
+
+func NewTracedPostRepo(next PostRepository) *TracedPostRepo {
+	return &TracedPostRepo{next: next}
+}
+
+func (r *TracedPostRepo) Create(ctx context.Context, post *models.Post) error {
+	ctx, span := startSpan(ctx, "PostRepository.Create", attribute.Int("post.id", post.ID))
+	err := r.next.Create(ctx, post)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedPostRepo) GetByID(ctx context.Context, id int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetByID", attribute.Int("post.id", id))
+	p, err := r.next.GetByID(ctx, id)
+	endSpan(span, err)
+	return p, err
+}
+
+func (r *TracedPostRepo) List(ctx context.Context) []*models.Post {
+	ctx, span := startSpan(ctx, "PostRepository.List")
+	posts := r.next.List(ctx)
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	endSpan(span, nil)
+	return posts
+}
+
+func (r *TracedPostRepo) Update(ctx context.Context, post *models.Post) error {
+	ctx, span := startSpan(ctx, "PostRepository.Update", attribute.Int("post.id", post.ID))
+	err := r.next.Update(ctx, post)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedPostRepo) Delete(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "PostRepository.Delete", attribute.Int("post.id", id))
+	err := r.next.Delete(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedPostRepo) ListReplies(ctx context.Context, parentID int) []*models.Post {
+	ctx, span := startSpan(ctx, "PostRepository.ListReplies", attribute.Int("post.id", parentID))
+	posts := r.next.ListReplies(ctx, parentID)
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	endSpan(span, nil)
+	return posts
+}
+
+func (r *TracedPostRepo) GetRepost(ctx context.Context, authorID, originalID int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetRepost",
+		attribute.Int("user.id", authorID), attribute.Int("post.original_id", originalID))
+	p, err := r.next.GetRepost(ctx, authorID, originalID)
+	endSpan(span, nil) // отсутствие репоста - штатный результат, а не ошибка
+	return p, err
+}
+
+func (r *TracedPostRepo) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "PostRepository.Ping")
+	err := r.next.Ping(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// TracedRevisionRepo оборачивает RevisionRepository и пишет спан на каждый вызов.
+type TracedRevisionRepo struct {
+	next RevisionRepository
+}
+
+func NewTracedRevisionRepo(next RevisionRepository) *TracedRevisionRepo {
+	return &TracedRevisionRepo{next: next}
+}
+
+func (r *TracedRevisionRepo) Add(ctx context.Context, rev *models.PostRevision) error {
+	ctx, span := startSpan(ctx, "RevisionRepository.Add",
+		attribute.Int("post.id", rev.PostID), attribute.Int("revision.version", rev.Version))
+	err := r.next.Add(ctx, rev)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedRevisionRepo) ListByPost(ctx context.Context, postID int) ([]*models.PostRevision, error) {
+	ctx, span := startSpan(ctx, "RevisionRepository.ListByPost", attribute.Int("post.id", postID))
+	revs, err := r.next.ListByPost(ctx, postID)
+	endSpan(span, err)
+	return revs, err
+}
+
+func (r *TracedRevisionRepo) DeleteByPost(ctx context.Context, postID int) error {
+	ctx, span := startSpan(ctx, "RevisionRepository.DeleteByPost", attribute.Int("post.id", postID))
+	err := r.next.DeleteByPost(ctx, postID)
+	endSpan(span, err)
+	return err
+}
+
+// TracedEntityRepo оборачивает EntityRepository и пишет спан на каждый вызов.
+type TracedEntityRepo struct {
+	next EntityRepository
+}
+
+func NewTracedEntityRepo(next EntityRepository) *TracedEntityRepo {
+	return &TracedEntityRepo{next: next}
+}
+
+func (r *TracedEntityRepo) Index(ctx context.Context, postID int, hashtags []string, mentions []int) error {
+	ctx, span := startSpan(ctx, "EntityRepository.Index", attribute.Int("post.id", postID),
+		attribute.Int("post.hashtag_count", len(hashtags)), attribute.Int("post.mention_count", len(mentions)))
+	err := r.next.Index(ctx, postID, hashtags, mentions)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedEntityRepo) ListByTag(ctx context.Context, tag string) ([]int, error) {
+	ctx, span := startSpan(ctx, "EntityRepository.ListByTag", attribute.String("post.hashtag", tag))
+	ids, err := r.next.ListByTag(ctx, tag)
+	endSpan(span, err)
+	return ids, err
+}
+
+func (r *TracedEntityRepo) ListByMention(ctx context.Context, userID int) ([]int, error) {
+	ctx, span := startSpan(ctx, "EntityRepository.ListByMention", attribute.Int("user.id", userID))
+	ids, err := r.next.ListByMention(ctx, userID)
+	endSpan(span, err)
+	return ids, err
+}
+
+// TracedWebhookRepo оборачивает WebhookRepository и пишет спан на каждый вызов.
+type TracedWebhookRepo struct {
+	next WebhookRepository
+}
+
+func NewTracedWebhookRepo(next WebhookRepository) *TracedWebhookRepo {
+	return &TracedWebhookRepo{next: next}
+}
+
+func (r *TracedWebhookRepo) Create(ctx context.Context, hook *models.Webhook) error {
+	ctx, span := startSpan(ctx, "WebhookRepository.Create", attribute.Int("webhook.id", hook.ID))
+	err := r.next.Create(ctx, hook)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedWebhookRepo) GetByID(ctx context.Context, id int) (*models.Webhook, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.GetByID", attribute.Int("webhook.id", id))
+	hook, err := r.next.GetByID(ctx, id)
+	endSpan(span, err)
+	return hook, err
+}
+
+func (r *TracedWebhookRepo) List(ctx context.Context) []*models.Webhook {
+	ctx, span := startSpan(ctx, "WebhookRepository.List")
+	hooks := r.next.List(ctx)
+	span.SetAttributes(attribute.Int("webhook.count", len(hooks)))
+	endSpan(span, nil)
+	return hooks
+}
+
+func (r *TracedWebhookRepo) Update(ctx context.Context, hook *models.Webhook) error {
+	ctx, span := startSpan(ctx, "WebhookRepository.Update", attribute.Int("webhook.id", hook.ID))
+	err := r.next.Update(ctx, hook)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedWebhookRepo) Delete(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "WebhookRepository.Delete", attribute.Int("webhook.id", id))
+	err := r.next.Delete(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedWebhookRepo) AddAttempt(ctx context.Context, attempt *models.WebhookAttempt) error {
+	ctx, span := startSpan(ctx, "WebhookRepository.AddAttempt",
+		attribute.Int("webhook.id", attempt.WebhookID), attribute.Int("webhook.delivery_id", attempt.DeliveryID))
+	err := r.next.AddAttempt(ctx, attempt)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedWebhookRepo) ListAttempts(ctx context.Context, webhookID int) ([]*models.WebhookAttempt, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.ListAttempts", attribute.Int("webhook.id", webhookID))
+	attempts, err := r.next.ListAttempts(ctx, webhookID)
+	endSpan(span, err)
+	return attempts, err
+}
+
+// TracedNotificationRepo оборачивает NotificationRepository и пишет спан на каждый вызов.
+type TracedNotificationRepo struct {
+	next NotificationRepository
+}
+
+func NewTracedNotificationRepo(next NotificationRepository) *TracedNotificationRepo {
+	return &TracedNotificationRepo{next: next}
+}
+
+func (r *TracedNotificationRepo) Create(ctx context.Context, n *models.Notification) error {
+	ctx, span := startSpan(ctx, "NotificationRepository.Create",
+		attribute.Int("notification.id", n.ID), attribute.Int("user.id", n.UserID))
+	err := r.next.Create(ctx, n)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedNotificationRepo) Update(ctx context.Context, n *models.Notification) error {
+	ctx, span := startSpan(ctx, "NotificationRepository.Update",
+		attribute.Int("notification.id", n.ID), attribute.Int("user.id", n.UserID))
+	err := r.next.Update(ctx, n)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedNotificationRepo) FindUnread(ctx context.Context, userID int, typ string, postID int) (*models.Notification, error) {
+	ctx, span := startSpan(ctx, "NotificationRepository.FindUnread", attribute.Int("user.id", userID),
+		attribute.String("notification.type", typ), attribute.Int("post.id", postID))
+	n, err := r.next.FindUnread(ctx, userID, typ, postID)
+	// Отсутствие непрочитанного уведомления - обычный случай, а не ошибка
+	span.SetAttributes(attribute.Bool("notification.found", err == nil))
+	endSpan(span, nil)
+	return n, err
+}
+
+func (r *TracedNotificationRepo) ListByUser(ctx context.Context, userID int) []*models.Notification {
+	ctx, span := startSpan(ctx, "NotificationRepository.ListByUser", attribute.Int("user.id", userID))
+	list := r.next.ListByUser(ctx, userID)
+	span.SetAttributes(attribute.Int("notification.count", len(list)))
+	endSpan(span, nil)
+	return list
+}
+
+func (r *TracedNotificationRepo) MarkRead(ctx context.Context, userID int, ids []int) int {
+	ctx, span := startSpan(ctx, "NotificationRepository.MarkRead", attribute.Int("user.id", userID))
+	marked := r.next.MarkRead(ctx, userID, ids)
+	span.SetAttributes(attribute.Int("notification.marked", marked))
+	endSpan(span, nil)
+	return marked
+}
+
+func (r *TracedNotificationRepo) DeleteByPost(ctx context.Context, postID int) error {
+	ctx, span := startSpan(ctx, "NotificationRepository.DeleteByPost", attribute.Int("post.id", postID))
+	err := r.next.DeleteByPost(ctx, postID)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedNotificationRepo) DeleteByUser(ctx context.Context, userID int) error {
+	ctx, span := startSpan(ctx, "NotificationRepository.DeleteByUser", attribute.Int("user.id", userID))
+	err := r.next.DeleteByUser(ctx, userID)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedNotificationRepo) RemoveActor(ctx context.Context, userID int) int {
+	ctx, span := startSpan(ctx, "NotificationRepository.RemoveActor", attribute.Int("user.id", userID))
+	changed := r.next.RemoveActor(ctx, userID)
+	span.SetAttributes(attribute.Int("notification.count", changed))
+	endSpan(span, nil)
+	return changed
+}
+
+func (r *TracedNotificationRepo) Preferences(ctx context.Context, userID int) map[string]bool {
+	ctx, span := startSpan(ctx, "NotificationRepository.Preferences", attribute.Int("user.id", userID))
+	prefs := r.next.Preferences(ctx, userID)
+	endSpan(span, nil)
+	return prefs
+}
+
+func (r *TracedNotificationRepo) SetPreferences(ctx context.Context, userID int, prefs map[string]bool) error {
+	ctx, span := startSpan(ctx, "NotificationRepository.SetPreferences", attribute.Int("user.id", userID))
+	err := r.next.SetPreferences(ctx, userID, prefs)
+	endSpan(span, err)
+	return err
+}
+
+// TracedMediaRepo оборачивает MediaRepository и пишет спан на каждый вызов.
+type TracedMediaRepo struct {
+	next MediaRepository
+}
+
+func NewTracedMediaRepo(next MediaRepository) *TracedMediaRepo {
+	return &TracedMediaRepo{next: next}
+}
+
+func (r *TracedMediaRepo) Create(ctx context.Context, media *models.Media) error {
+	ctx, span := startSpan(ctx, "MediaRepository.Create", attribute.Int("media.id", media.ID))
+	err := r.next.Create(ctx, media)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedMediaRepo) GetByID(ctx context.Context, id int) (*models.Media, error) {
+	ctx, span := startSpan(ctx, "MediaRepository.GetByID", attribute.Int("media.id", id))
+	media, err := r.next.GetByID(ctx, id)
+	endSpan(span, err)
+	return media, err
+}
+
+func (r *TracedMediaRepo) Update(ctx context.Context, media *models.Media) error {
+	ctx, span := startSpan(ctx, "MediaRepository.Update", attribute.Int("media.id", media.ID))
+	err := r.next.Update(ctx, media)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedMediaRepo) Delete(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "MediaRepository.Delete", attribute.Int("media.id", id))
+	err := r.next.Delete(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedMediaRepo) ListPending(ctx context.Context, ownerID int) []*models.Media {
+	ctx, span := startSpan(ctx, "MediaRepository.ListPending", attribute.Int("user.id", ownerID))
+	media := r.next.ListPending(ctx, ownerID)
+	span.SetAttributes(attribute.Int("media.count", len(media)))
+	endSpan(span, nil)
+	return media
+}
+
+func (r *TracedMediaRepo) ListPendingBefore(ctx context.Context, t time.Time) []*models.Media {
+	ctx, span := startSpan(ctx, "MediaRepository.ListPendingBefore")
+	media := r.next.ListPendingBefore(ctx, t)
+	span.SetAttributes(attribute.Int("media.count", len(media)))
+	endSpan(span, nil)
+	return media
+}
+
+// TracedAuditRepo оборачивает AuditRepository и пишет спан на каждый вызов.
+type TracedAuditRepo struct {
+	next AuditRepository
+}
+
+func NewTracedAuditRepo(next AuditRepository) *TracedAuditRepo {
+	return &TracedAuditRepo{next: next}
+}
+
+func (r *TracedAuditRepo) Add(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, span := startSpan(ctx, "AuditRepository.Add",
+		attribute.Int("audit.id", entry.ID), attribute.String("audit.action", entry.Action))
+	err := r.next.Add(ctx, entry)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedAuditRepo) List(ctx context.Context) []*models.AuditEntry {
+	ctx, span := startSpan(ctx, "AuditRepository.List")
+	entries := r.next.List(ctx)
+	span.SetAttributes(attribute.Int("audit.count", len(entries)))
+	endSpan(span, nil)
+	return entries
+}
+
+// TracedAliasRepo оборачивает AliasRepository и пишет спан на каждый вызов.
+type TracedAliasRepo struct {
+	next AliasRepository
+}
+
+func NewTracedAliasRepo(next AliasRepository) *TracedAliasRepo {
+	return &TracedAliasRepo{next: next}
+}
+
+func (r *TracedAliasRepo) Put(ctx context.Context, alias *models.UsernameAlias) error {
+	ctx, span := startSpan(ctx, "AliasRepository.Put", attribute.Int("user.id", alias.UserID))
+	err := r.next.Put(ctx, alias)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedAliasRepo) Get(ctx context.Context, canonical string) (*models.UsernameAlias, error) {
+	ctx, span := startSpan(ctx, "AliasRepository.Get", attribute.String("user.canonical_name", canonical))
+	alias, err := r.next.Get(ctx, canonical)
+	endSpan(span, err)
+	return alias, err
+}
+
+func (r *TracedAliasRepo) Delete(ctx context.Context, canonical string) error {
+	ctx, span := startSpan(ctx, "AliasRepository.Delete", attribute.String("user.canonical_name", canonical))
+	err := r.next.Delete(ctx, canonical)
+	endSpan(span, err)
+	return err
+}
+
+func (r *TracedAliasRepo) DeleteByUser(ctx context.Context, userID int) int {
+	ctx, span := startSpan(ctx, "AliasRepository.DeleteByUser", attribute.Int("user.id", userID))
+	n := r.next.DeleteByUser(ctx, userID)
+	span.SetAttributes(attribute.Int("alias.count", n))
+	endSpan(span, nil)
+	return n
+}
+
+// TracedFollowRepo оборачивает FollowRepository и пишет спан на каждый вызов.
+type TracedFollowRepo struct {
+	next FollowRepository
+}
+
+func NewTracedFollowRepo(next FollowRepository) *TracedFollowRepo {
+	return &TracedFollowRepo{next: next}
+}
+
+func (r *TracedFollowRepo) Follow(ctx context.Context, followerID, followeeID int) bool {
+	ctx, span := startSpan(ctx, "FollowRepository.Follow",
+		attribute.Int("user.id", followerID), attribute.Int("follow.followee_id", followeeID))
+	created := r.next.Follow(ctx, followerID, followeeID)
+	endSpan(span, nil)
+	return created
+}
+
+func (r *TracedFollowRepo) Unfollow(ctx context.Context, followerID, followeeID int) bool {
+	ctx, span := startSpan(ctx, "FollowRepository.Unfollow",
+		attribute.Int("user.id", followerID), attribute.Int("follow.followee_id", followeeID))
+	removed := r.next.Unfollow(ctx, followerID, followeeID)
+	endSpan(span, nil)
+	return removed
+}
+
+func (r *TracedFollowRepo) Followers(ctx context.Context, userID int) []int {
+	ctx, span := startSpan(ctx, "FollowRepository.Followers", attribute.Int("user.id", userID))
+	ids := r.next.Followers(ctx, userID)
+	span.SetAttributes(attribute.Int("follow.count", len(ids)))
+	endSpan(span, nil)
+	return ids
+}
+
+func (r *TracedFollowRepo) Following(ctx context.Context, userID int) []int {
+	ctx, span := startSpan(ctx, "FollowRepository.Following", attribute.Int("user.id", userID))
+	ids := r.next.Following(ctx, userID)
+	span.SetAttributes(attribute.Int("follow.count", len(ids)))
+	endSpan(span, nil)
+	return ids
+}
+
+func (r *TracedFollowRepo) DeleteByUser(ctx context.Context, userID int) int {
+	ctx, span := startSpan(ctx, "FollowRepository.DeleteByUser", attribute.Int("user.id", userID))
+	n := r.next.DeleteByUser(ctx, userID)
+	span.SetAttributes(attribute.Int("follow.count", n))
+	endSpan(span, nil)
+	return n
+}
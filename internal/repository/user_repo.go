@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"slices"
+	"sync"
 
 	"github.com/Cere6rum/MicroBlog2/internal/models"
 	"github.com/Cere6rum/MicroBlog2/internal/syncutils"
@@ -9,29 +12,60 @@ import (
 
 // UserRepository defines abstraction for user storage.
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByUsername(username string) (*models.User, error)
-	Exists(username string) bool
+	Create(ctx context.Context, user *models.User) error
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	// Update replaces the stored user with the same username.
+	Update(ctx context.Context, user *models.User) error
+	Exists(ctx context.Context, username string) bool
+	// Rename stores user under its new Username and CanonicalName and frees
+	// oldUsername. The user ID must not change.
+	Rename(ctx context.Context, oldUsername string, user *models.User) error
+	// Delete removes the user and frees the username.
+	Delete(ctx context.Context, username string) error
+	// List returns all users ordered by ID.
+	List(ctx context.Context) []*models.User
+	// ExistsCanonical reports whether a user with the canonical name key exists.
+	ExistsCanonical(ctx context.Context, canonical string) bool
+	// Ping проверяет доступность хранилища
+	Ping(ctx context.Context) error
 }
 
 // InMemoryUserRepo is an adapter over syncutils.SafeUserStorage.
 type InMemoryUserRepo struct {
 	storage *syncutils.SafeUserStorage
+
+	mu        sync.Mutex
+	canonical map[string]string // canonical name -> username
+	byID      map[int]string    // ID -> username
 }
 
 func NewInMemoryUserRepo() *InMemoryUserRepo {
-	return &InMemoryUserRepo{storage: syncutils.NewSafeUserStorage()}
+	return &InMemoryUserRepo{
+		storage:   syncutils.NewSafeUserStorage(),
+		canonical: make(map[string]string),
+		byID:      make(map[int]string),
+	}
 }
 
-func (r *InMemoryUserRepo) Create(user *models.User) error {
-	if r.Exists(user.Username) {
+func (r *InMemoryUserRepo) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Exists(ctx, user.Username) {
 		return errors.New("user already exists")
 	}
+	if user.CanonicalName != "" {
+		if _, taken := r.canonical[user.CanonicalName]; taken {
+			return errors.New("user with the same canonical name already exists")
+		}
+		r.canonical[user.CanonicalName] = user.Username
+	}
+	r.byID[user.ID] = user.Username
 	r.storage.Set(user.Username, user)
 	return nil
 }
 
-func (r *InMemoryUserRepo) GetByUsername(username string) (*models.User, error) {
+func (r *InMemoryUserRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	v, ok := r.storage.Get(username)
 	if !ok {
 		return nil, errors.New("user not found")
@@ -43,6 +77,101 @@ func (r *InMemoryUserRepo) GetByUsername(username string) (*models.User, error)
 	return u, nil
 }
 
-func (r *InMemoryUserRepo) Exists(username string) bool {
+func (r *InMemoryUserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.mu.Lock()
+	username, ok := r.byID[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return r.GetByUsername(ctx, username)
+}
+
+func (r *InMemoryUserRepo) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, err := r.GetByUsername(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+	if user.CanonicalName != old.CanonicalName {
+		if owner, taken := r.canonical[user.CanonicalName]; taken && owner != user.Username {
+			return errors.New("user with the same canonical name already exists")
+		}
+		delete(r.canonical, old.CanonicalName)
+		if user.CanonicalName != "" {
+			r.canonical[user.CanonicalName] = user.Username
+		}
+	}
+	r.storage.Set(user.Username, user)
+	return nil
+}
+
+func (r *InMemoryUserRepo) Exists(ctx context.Context, username string) bool {
 	return r.storage.Exists(username)
 }
+
+func (r *InMemoryUserRepo) Rename(ctx context.Context, oldUsername string, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, err := r.GetByUsername(ctx, oldUsername)
+	if err != nil {
+		return err
+	}
+	if old.ID != user.ID {
+		return errors.New("user ID cannot change on rename")
+	}
+	if user.Username != oldUsername && r.Exists(ctx, user.Username) {
+		return errors.New("user already exists")
+	}
+	if owner, taken := r.canonical[user.CanonicalName]; taken && owner != oldUsername {
+		return errors.New("user with the same canonical name already exists")
+	}
+	if r.canonical[old.CanonicalName] == oldUsername {
+		delete(r.canonical, old.CanonicalName)
+	}
+	if user.CanonicalName != "" {
+		r.canonical[user.CanonicalName] = user.Username
+	}
+	r.byID[user.ID] = user.Username
+	r.storage.Rename(oldUsername, user.Username, user)
+	return nil
+}
+
+func (r *InMemoryUserRepo) Delete(ctx context.Context, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, err := r.GetByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if r.canonical[user.CanonicalName] == username {
+		delete(r.canonical, user.CanonicalName)
+	}
+	delete(r.byID, user.ID)
+	r.storage.Delete(username)
+	return nil
+}
+
+func (r *InMemoryUserRepo) List(ctx context.Context) []*models.User {
+	raw := r.storage.GetAll()
+	out := make([]*models.User, 0, len(raw))
+	for _, v := range raw {
+		if u, ok := v.(*models.User); ok {
+			out = append(out, u)
+		}
+	}
+	slices.SortFunc(out, func(a, b *models.User) int { return a.ID - b.ID })
+	return out
+}
+
+func (r *InMemoryUserRepo) ExistsCanonical(ctx context.Context, canonical string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.canonical[canonical]
+	return ok
+}
+
+func (r *InMemoryUserRepo) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
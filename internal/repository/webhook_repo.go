@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// MaxWebhookAttempts - сколько последних попыток доставки хранится на вебхук
+const MaxWebhookAttempts = 100
+
+// WebhookRepository defines abstraction for webhook subscriptions and delivery log.
+type WebhookRepository interface {
+	Create(ctx context.Context, hook *models.Webhook) error
+	GetByID(ctx context.Context, id int) (*models.Webhook, error)
+	List(ctx context.Context) []*models.Webhook
+	Update(ctx context.Context, hook *models.Webhook) error
+	Delete(ctx context.Context, id int) error
+	AddAttempt(ctx context.Context, attempt *models.WebhookAttempt) error
+	ListAttempts(ctx context.Context, webhookID int) ([]*models.WebhookAttempt, error)
+}
+
+// InMemoryWebhookRepo keeps webhooks by ID and the latest MaxWebhookAttempts
+// delivery attempts per webhook, oldest first.
+type InMemoryWebhookRepo struct {
+	mu       sync.RWMutex
+	hooks    map[int]*models.Webhook
+	attempts map[int][]*models.WebhookAttempt
+}
+
+func NewInMemoryWebhookRepo() *InMemoryWebhookRepo {
+	return &InMemoryWebhookRepo{
+		hooks:    make(map[int]*models.Webhook),
+		attempts: make(map[int][]*models.WebhookAttempt),
+	}
+}
+
+func (r *InMemoryWebhookRepo) Create(ctx context.Context, hook *models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hooks[hook.ID]; ok {
+		return fmt.Errorf("webhook %d already exists", hook.ID)
+	}
+	r.hooks[hook.ID] = hook
+	return nil
+}
+
+func (r *InMemoryWebhookRepo) GetByID(ctx context.Context, id int) (*models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hook, ok := r.hooks[id]
+	if !ok {
+		return nil, errors.New("webhook not found")
+	}
+	return hook, nil
+}
+
+// List returns all webhooks ordered by ID.
+func (r *InMemoryWebhookRepo) List(ctx context.Context) []*models.Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.Webhook, 0, len(r.hooks))
+	for _, hook := range r.hooks {
+		out = append(out, hook)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (r *InMemoryWebhookRepo) Update(ctx context.Context, hook *models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hooks[hook.ID]; !ok {
+		return errors.New("webhook not found")
+	}
+	r.hooks[hook.ID] = hook
+	return nil
+}
+
+// Delete removes the webhook together with its delivery log.
+func (r *InMemoryWebhookRepo) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hooks[id]; !ok {
+		return errors.New("webhook not found")
+	}
+	delete(r.hooks, id)
+	delete(r.attempts, id)
+	return nil
+}
+
+func (r *InMemoryWebhookRepo) AddAttempt(ctx context.Context, attempt *models.WebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hooks[attempt.WebhookID]; !ok {
+		return errors.New("webhook not found")
+	}
+	log := r.attempts[attempt.WebhookID]
+	if len(log) >= MaxWebhookAttempts {
+		log = log[1:]
+	}
+	r.attempts[attempt.WebhookID] = append(log, attempt)
+	return nil
+}
+
+func (r *InMemoryWebhookRepo) ListAttempts(ctx context.Context, webhookID int) ([]*models.WebhookAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.WebhookAttempt, len(r.attempts[webhookID]))
+	copy(out, r.attempts[webhookID])
+	return out, nil
+}
@@ -0,0 +1,210 @@
+// Package search - встроенный полнотекстовый индекс постов со стеммингом
+// для русского и английского, фразовыми запросами и ранжированием BM25.
+package search
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// Параметры ранжирования BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Hit - найденный пост и его релевантность
+type Hit struct {
+	PostID int     `json:"post_id"`
+	Score  float64 `json:"score"`
+}
+
+// Result - страница результатов поиска
+type Result struct {
+	Total int   `json:"total"` // всего найдено постов
+	Hits  []Hit `json:"hits"`
+}
+
+// document - проиндексированный пост
+type document struct {
+	authorID int
+	length   int              // количество слов без стоп-слов
+	terms    map[string][]int // основа -> позиции в тексте
+}
+
+// Index - инвертированный индекс: основа слова -> посты, в которых она встречается.
+// Безопасен для конкурентного использования.
+type Index struct {
+	mu        sync.RWMutex
+	docs      map[int]*document
+	postings  map[string]map[int]struct{}
+	revisions map[int]int // последняя примененная версия поста (включая удаление)
+	totalLen  int
+}
+
+// NewIndex создает пустой индекс
+func NewIndex() *Index {
+	return &Index{
+		docs:      make(map[int]*document),
+		postings:  make(map[string]map[int]struct{}),
+		revisions: make(map[int]int),
+	}
+}
+
+// Upsert индексирует текст поста. Автор хранится по ID, поэтому смена имени
+// не требует переиндексации. События могут приходить из очереди не по
+// порядку, поэтому версия, не превышающая уже примененную, игнорируется.
+// Возвращает false, если событие устарело.
+func (ix *Index) Upsert(postID, authorID int, content string, revision int) bool {
+	doc := &document{authorID: authorID, terms: make(map[string][]int)}
+	for _, t := range tokenize(content) {
+		doc.terms[t.term] = append(doc.terms[t.term], t.pos)
+		doc.length++
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if revision <= ix.revisions[postID] {
+		return false
+	}
+	ix.revisions[postID] = revision
+	ix.remove(postID)
+	ix.docs[postID] = doc
+	ix.totalLen += doc.length
+	for term := range doc.terms {
+		if ix.postings[term] == nil {
+			ix.postings[term] = make(map[int]struct{})
+		}
+		ix.postings[term][postID] = struct{}{}
+	}
+	return true
+}
+
+// Delete удаляет пост из индекса; revision должна быть больше последней версии поста
+func (ix *Index) Delete(postID, revision int) bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if revision <= ix.revisions[postID] {
+		return false
+	}
+	ix.revisions[postID] = revision
+	ix.remove(postID)
+	return true
+}
+
+// Len возвращает количество проиндексированных постов
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// remove убирает документ из словаря (вызывается под мьютексом)
+func (ix *Index) remove(postID int) {
+	doc, ok := ix.docs[postID]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(ix.postings[term], postID)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.totalLen -= doc.length
+	delete(ix.docs, postID)
+}
+
+// Search находит посты, содержащие все слова и фразы запроса, и возвращает
+// страницу [offset, offset+limit) по убыванию релевантности (при равенстве -
+// сначала новые). limit <= 0 - без ограничения.
+func (ix *Index) Search(q Query, limit, offset int) Result {
+	terms := q.allTerms()
+	if len(terms) == 0 {
+		return Result{Hits: []Hit{}}
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// Кандидаты - пересечение списков постов, начиная с самого короткого
+	slices.SortFunc(terms, func(a, b string) int { return len(ix.postings[a]) - len(ix.postings[b]) })
+	var hits []Hit
+	avgLen := float64(ix.totalLen) / float64(max(len(ix.docs), 1))
+	for id := range ix.postings[terms[0]] {
+		doc := ix.docs[id]
+		if q.AuthorID != 0 && doc.authorID != q.AuthorID {
+			continue
+		}
+		if !ix.matches(doc, terms, q.phrases) {
+			continue
+		}
+		hits = append(hits, Hit{PostID: id, Score: ix.score(doc, terms, avgLen)})
+	}
+
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return b.PostID - a.PostID
+	})
+
+	res := Result{Total: len(hits), Hits: []Hit{}}
+	if offset < len(hits) {
+		hits = hits[offset:]
+		if limit > 0 && limit < len(hits) {
+			hits = hits[:limit]
+		}
+		res.Hits = hits
+	}
+	return res
+}
+
+// matches проверяет наличие всех основ и всех фраз в документе
+func (ix *Index) matches(doc *document, terms []string, phrases [][]token) bool {
+	for _, t := range terms {
+		if _, ok := doc.terms[t]; !ok {
+			return false
+		}
+	}
+	for _, p := range phrases {
+		if !hasPhrase(doc, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPhrase ищет позицию, от которой все слова фразы стоят на своих относительных местах
+func hasPhrase(doc *document, phrase []token) bool {
+	for _, start := range doc.terms[phrase[0].term] {
+		found := true
+		for _, t := range phrase[1:] {
+			if !slices.Contains(doc.terms[t.term], start+t.pos) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// score - BM25 документа по основам запроса (вызывается под мьютексом)
+func (ix *Index) score(doc *document, terms []string, avgLen float64) float64 {
+	n := float64(len(ix.docs))
+	var score float64
+	for _, t := range terms {
+		df := float64(len(ix.postings[t]))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		tf := float64(len(doc.terms[t]))
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.length)/avgLen))
+	}
+	return score
+}
@@ -0,0 +1,60 @@
+package search
+
+import "strings"
+
+// Query - разобранный поисковый запрос
+type Query struct {
+	terms    []string  // отдельные слова (основы)
+	phrases  [][]token // фразы в кавычках: основы с относительными позициями
+	AuthorID int       // фильтр по ID автора (0 - любой)
+}
+
+// ParseQuery разбирает текст запроса: слова ищутся независимо, текст в
+// двойных кавычках - как фраза (слова подряд в том же порядке).
+// Все слова и фразы должны присутствовать в посте.
+func ParseQuery(text string) Query {
+	var q Query
+	parts := strings.Split(text, `"`)
+	for i, part := range parts {
+		tokens := tokenize(part)
+		// Нечетные части лежат внутри кавычек; незакрытая кавычка считается закрытой в конце
+		if i%2 == 1 && len(tokens) > 1 {
+			base := tokens[0].pos
+			for j := range tokens {
+				tokens[j].pos -= base
+			}
+			q.phrases = append(q.phrases, tokens)
+			continue
+		}
+		for _, t := range tokens {
+			q.terms = append(q.terms, t.term)
+		}
+	}
+	return q
+}
+
+// Empty сообщает, что в запросе нет ни одного значимого слова
+func (q Query) Empty() bool {
+	return len(q.terms) == 0 && len(q.phrases) == 0
+}
+
+// allTerms возвращает все основы запроса без повторов
+func (q Query) allTerms() []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	for _, t := range q.terms {
+		add(t)
+	}
+	for _, p := range q.phrases {
+		for _, t := range p {
+			add(t.term)
+		}
+	}
+	return out
+}
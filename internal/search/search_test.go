@@ -0,0 +1,82 @@
+package search
+
+import "testing"
+
+// TestStem проверяет, что формы одного слова сводятся к одной основе
+func TestStem(t *testing.T) {
+	groups := [][]string{
+		{"кошка", "кошки", "кошками", "кошке"},
+		{"новости", "новость", "новостями"},
+		{"программирование", "программированием"},
+		{"run", "runs", "running"},
+		{"connect", "connected", "connection", "connections"},
+	}
+	for _, g := range groups {
+		want := Stem(g[0])
+		for _, w := range g[1:] {
+			if got := Stem(w); got != want {
+				t.Errorf("Stem(%q) = %q, ожидали %q (как у %q)", w, got, want, g[0])
+			}
+		}
+	}
+}
+
+// TestIndexSearch проверяет поиск по словам, фразам, автору и постраничную выдачу
+func TestIndexSearch(t *testing.T) {
+	ix := NewIndex()
+	ix.Upsert(1, 1, "Кошки и собаки живут дружно", 1)
+	ix.Upsert(2, 2, "Собаки не дружат с кошками", 1)
+	ix.Upsert(3, 1, "Running with dogs and cats", 1)
+	ix.Upsert(4, 2, "Кошка, кошка, кошка!", 1)
+
+	ids := func(r Result) []int {
+		out := make([]int, 0, len(r.Hits))
+		for _, h := range r.Hits {
+			out = append(out, h.PostID)
+		}
+		return out
+	}
+
+	// Слова в разных формах
+	if r := ix.Search(ParseQuery("кошка собака"), 0, 0); r.Total != 2 {
+		t.Errorf("Ожидали 2 поста с кошками и собаками, получили %v", ids(r))
+	}
+	// Релевантность: пост с тремя упоминаниями выше
+	if r := ix.Search(ParseQuery("кошки"), 0, 0); len(r.Hits) != 3 || r.Hits[0].PostID != 4 {
+		t.Errorf("Ожидали пост 4 первым среди 3, получили %v", ids(r))
+	}
+	// Фраза учитывает порядок и стоп-слова
+	if r := ix.Search(ParseQuery(`"кошки и собаки"`), 0, 0); r.Total != 1 || r.Hits[0].PostID != 1 {
+		t.Errorf("Ожидали только пост 1 по фразе, получили %v", ids(r))
+	}
+	if r := ix.Search(ParseQuery(`"собаки кошки"`), 0, 0); r.Total != 0 {
+		t.Errorf("Фраза в обратном порядке не должна находиться, получили %v", ids(r))
+	}
+	// Английский стемминг и фильтр по автору
+	q := ParseQuery("run cat")
+	q.AuthorID = 2
+	if r := ix.Search(q, 0, 0); r.Total != 0 {
+		t.Errorf("Фильтр по автору должен отсечь пост 3, получили %v", ids(r))
+	}
+	q.AuthorID = 1
+	if r := ix.Search(q, 0, 0); r.Total != 1 || r.Hits[0].PostID != 3 {
+		t.Errorf("Ожидали пост 3, получили %v", ids(r))
+	}
+	// Постраничная выдача
+	if r := ix.Search(ParseQuery("кошка"), 1, 1); r.Total != 3 || len(r.Hits) != 1 {
+		t.Errorf("Ожидали 1 пост из 3 на второй странице, получили %d из %d", len(r.Hits), r.Total)
+	}
+
+	// Устаревшая версия не перезаписывает новую, удаление убирает пост
+	ix.Upsert(1, 1, "Только птицы", 3)
+	if ix.Upsert(1, 1, "Кошки снова", 2) {
+		t.Error("Версия 2 после версии 3 должна игнорироваться")
+	}
+	if r := ix.Search(ParseQuery("птицы"), 0, 0); r.Total != 1 {
+		t.Errorf("Ожидали пост с птицами, получили %v", ids(r))
+	}
+	ix.Delete(1, 4)
+	if r := ix.Search(ParseQuery("птицы"), 0, 0); r.Total != 0 || ix.Len() != 3 {
+		t.Errorf("Пост 1 должен быть удален из индекса, найдено %v, всего %d", ids(r), ix.Len())
+	}
+}
@@ -0,0 +1,263 @@
+package search
+
+import "strings"
+
+// Stem возвращает основу слова: для кириллицы - по алгоритму Портера для
+// русского языка, для латиницы - по упрощенному алгоритму Портера для английского.
+// Слово должно быть в нижнем регистре.
+func Stem(word string) string {
+	for _, r := range word {
+		switch {
+		case r >= 'а' && r <= 'я' || r == 'ё':
+			return stemRussian(word)
+		case r >= 'a' && r <= 'z':
+			return stemEnglish(word)
+		}
+	}
+	return word
+}
+
+// --- Русский (Snowball Russian) ---
+
+const ruVowels = "аеиоуыэюя"
+
+var (
+	ruPerfectiveGerund1 = []string{"вшись", "вши", "в"} // после а/я
+	ruPerfectiveGerund2 = []string{"ившись", "ывшись", "ивши", "ывши", "ив", "ыв"}
+	ruReflexive         = []string{"ся", "сь"}
+	ruAdjective         = []string{
+		"ими", "ыми", "его", "ого", "ему", "ому",
+		"ее", "ие", "ые", "ое", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}
+	ruParticiple1 = []string{"ем", "нн", "вш", "ющ", "щ"} // после а/я
+	ruParticiple2 = []string{"ивш", "ывш", "ующ"}
+	ruVerb1       = []string{ // после а/я
+		"ете", "йте", "ешь", "нно", "ла", "на", "ли", "ем", "ло", "но", "ет", "ют", "ны", "ть", "й", "л", "н",
+	}
+	ruVerb2 = []string{
+		"ейте", "уйте", "ила", "ыла", "ена", "ите", "или", "ыли", "ило", "ыло", "ено", "ует", "уют",
+		"ены", "ить", "ыть", "ишь", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ят", "ит", "ыт", "ую", "ю",
+	}
+	ruNoun = []string{
+		"иями", "ями", "ами", "ией", "иям", "ием", "иях",
+		"ев", "ов", "ие", "ье", "еи", "ии", "ей", "ой", "ий", "ям", "ем", "ам", "ом", "ах", "ях", "ию", "ью", "ия", "ья",
+		"а", "е", "и", "й", "о", "у", "ы", "ь", "ю", "я",
+	}
+	ruSuperlative   = []string{"ейше", "ейш"}
+	ruDerivational  = []string{"ость", "ост"}
+	ruPrecededByAYa = "ая"
+)
+
+func stemRussian(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ё", "е"))
+	rv := ruRV(w)
+	if rv >= len(w) {
+		return string(w)
+	}
+	r2 := ruR2(w)
+
+	// Шаг 1
+	if n := ruSuffix(w, rv, ruPerfectiveGerund1, true); n > 0 {
+		w = w[:len(w)-n]
+	} else if n := ruSuffix(w, rv, ruPerfectiveGerund2, false); n > 0 {
+		w = w[:len(w)-n]
+	} else {
+		if n := ruSuffix(w, rv, ruReflexive, false); n > 0 {
+			w = w[:len(w)-n]
+		}
+		if n := ruSuffix(w, rv, ruAdjective, false); n > 0 {
+			w = w[:len(w)-n]
+			if n := ruSuffix(w, rv, ruParticiple1, true); n > 0 {
+				w = w[:len(w)-n]
+			} else if n := ruSuffix(w, rv, ruParticiple2, false); n > 0 {
+				w = w[:len(w)-n]
+			}
+		} else if n := ruSuffix(w, rv, ruVerb1, true); n > 0 {
+			w = w[:len(w)-n]
+		} else if n := ruSuffix(w, rv, ruVerb2, false); n > 0 {
+			w = w[:len(w)-n]
+		} else if n := ruSuffix(w, rv, ruNoun, false); n > 0 {
+			w = w[:len(w)-n]
+		}
+	}
+
+	// Шаг 2
+	if len(w) > rv && w[len(w)-1] == 'и' {
+		w = w[:len(w)-1]
+	}
+
+	// Шаг 3
+	if n := ruSuffix(w, r2, ruDerivational, false); n > 0 {
+		w = w[:len(w)-n]
+	}
+
+	// Шаг 4
+	if n := ruSuffix(w, rv, ruSuperlative, false); n > 0 {
+		w = w[:len(w)-n]
+	}
+	switch {
+	case hasRuneSuffix(w, rv, "нн"):
+		w = w[:len(w)-1]
+	case len(w) > rv && w[len(w)-1] == 'ь':
+		w = w[:len(w)-1]
+	}
+	return string(w)
+}
+
+// ruSuffix возвращает длину самого длинного окончания из list, лежащего в
+// области [region:]. Если afterAYa, окончанию должна предшествовать а или я
+// из той же области; сама эта буква не удаляется.
+func ruSuffix(w []rune, region int, list []string, afterAYa bool) int {
+	best := 0
+	for _, s := range list {
+		if !hasRuneSuffix(w, region, s) {
+			continue
+		}
+		n := len([]rune(s))
+		if afterAYa {
+			i := len(w) - n - 1
+			if i < region || !strings.ContainsRune(ruPrecededByAYa, w[i]) {
+				continue
+			}
+		}
+		best = max(best, n)
+	}
+	return best
+}
+
+func hasRuneSuffix(w []rune, region int, suffix string) bool {
+	s := []rune(suffix)
+	return len(w)-len(s) >= region && string(w[len(w)-len(s):]) == suffix
+}
+
+// ruRV - начало области после первой гласной
+func ruRV(w []rune) int {
+	for i, r := range w {
+		if strings.ContainsRune(ruVowels, r) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// ruR2 - начало области R2 (R1 внутри R1)
+func ruR2(w []rune) int {
+	r1 := regionAfterVC(w, 0, func(r rune) bool { return strings.ContainsRune(ruVowels, r) })
+	return regionAfterVC(w, r1, func(r rune) bool { return strings.ContainsRune(ruVowels, r) })
+}
+
+// regionAfterVC возвращает позицию после первой пары "гласная, согласная" начиная с from
+func regionAfterVC(w []rune, from int, vowel func(rune) bool) int {
+	for i := from + 1; i < len(w); i++ {
+		if !vowel(w[i]) && vowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// --- Английский (упрощенный Porter) ---
+
+// enSuffixes - замены окончаний (шаги 2-4 алгоритма Портера), применяются при m(основа) > 0
+var enSuffixes = []struct{ from, to string }{
+	{"ational", "ate"}, {"tional", "tion"}, {"ization", "ize"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"iveness", "ive"}, {"biliti", "ble"}, {"ation", "ate"},
+	{"alism", "al"}, {"aliti", "al"}, {"iviti", "ive"}, {"ement", ""}, {"ment", ""},
+	{"ness", ""}, {"able", ""}, {"ible", ""}, {"ator", "ate"}, {"tion", "t"}, {"sion", "s"}, {"ance", ""}, {"ence", ""},
+	{"izer", "ize"}, {"ful", ""}, {"ism", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""}, {"ent", ""},
+}
+
+func stemEnglish(w string) string {
+	if len(w) <= 2 {
+		return w
+	}
+
+	// Шаг 1a: множественное число
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+	case strings.HasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+
+	// Шаг 1b: -eed, -ed, -ing
+	switch {
+	case strings.HasSuffix(w, "eed"):
+		if enMeasure(w[:len(w)-3]) > 0 {
+			w = w[:len(w)-1]
+		}
+	case strings.HasSuffix(w, "ed") && enHasVowel(w[:len(w)-2]):
+		w = enFixup(w[:len(w)-2])
+	case strings.HasSuffix(w, "ing") && enHasVowel(w[:len(w)-3]):
+		w = enFixup(w[:len(w)-3])
+	}
+
+	// Шаг 1c: y -> i
+	if strings.HasSuffix(w, "y") && enHasVowel(w[:len(w)-1]) {
+		w = w[:len(w)-1] + "i"
+	}
+
+	for _, s := range enSuffixes {
+		if strings.HasSuffix(w, s.from) {
+			if stem := w[:len(w)-len(s.from)]; enMeasure(stem) > 0 {
+				w = stem + s.to
+			}
+			break
+		}
+	}
+
+	// Шаг 5: конечная e
+	if strings.HasSuffix(w, "e") && enMeasure(w[:len(w)-1]) > 1 {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// enFixup восстанавливает основу после удаления -ed/-ing
+func enFixup(w string) string {
+	switch {
+	case strings.HasSuffix(w, "at"), strings.HasSuffix(w, "bl"), strings.HasSuffix(w, "iz"):
+		return w + "e"
+	case len(w) >= 2 && w[len(w)-1] == w[len(w)-2] && !enIsVowel(w, len(w)-1) &&
+		!strings.ContainsRune("lsz", rune(w[len(w)-1])):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func enIsVowel(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return true
+	case 'y':
+		return i > 0 && !enIsVowel(w, i-1)
+	}
+	return false
+}
+
+func enHasVowel(w string) bool {
+	for i := range len(w) {
+		if enIsVowel(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// enMeasure - количество последовательностей "гласные, согласные" в основе
+func enMeasure(w string) int {
+	m := 0
+	prevVowel := false
+	for i := range len(w) {
+		v := enIsVowel(w, i)
+		if prevVowel && !v {
+			m++
+		}
+		prevVowel = v
+	}
+	return m
+}
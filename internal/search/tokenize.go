@@ -0,0 +1,42 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords не индексируются и не участвуют в запросах, но занимают позицию,
+// поэтому фраза "кошки и собаки" не совпадет с "кошки собаки"
+var stopWords = map[string]bool{
+	"и": true, "в": true, "во": true, "не": true, "что": true, "он": true, "на": true, "я": true,
+	"с": true, "со": true, "как": true, "а": true, "то": true, "все": true, "она": true, "так": true,
+	"его": true, "но": true, "да": true, "ты": true, "к": true, "у": true, "же": true, "вы": true,
+	"за": true, "бы": true, "по": true, "ее": true, "мне": true, "есть": true, "от": true, "о": true,
+	"из": true, "ли": true, "или": true, "это": true,
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "to": true, "was": true, "with": true,
+}
+
+// token - основа слова и его порядковый номер в тексте
+type token struct {
+	term string
+	pos  int
+}
+
+// tokenize разбивает текст на слова (буквы и цифры любых алфавитов),
+// приводит к нижнему регистру и основе, пропуская стоп-слова
+func tokenize(text string) []token {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]token, 0, len(words))
+	for pos, w := range words {
+		w = strings.ReplaceAll(w, "ё", "е")
+		if stopWords[w] {
+			continue
+		}
+		tokens = append(tokens, token{term: Stem(w), pos: pos})
+	}
+	return tokens
+}
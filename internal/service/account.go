@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// Размер страницы журнала аудита
+const (
+	DefaultAuditLimit = 50
+	MaxAuditLimit     = 500
+)
+
+// WithAuditRepo задает хранилище журнала аудита
+func WithAuditRepo(r repository.AuditRepository) Option {
+	return func(s *MicroBlogService) {
+		s.auditRepo = r
+	}
+}
+
+// DeleteUser удаляет аккаунт и персональные данные пользователя:
+// профиль и аватар, прежние имена, подписки в обе стороны, его лайки,
+// упоминания в чужих постах, загруженные изображения, вебхуки,
+// уведомления и упоминания его имени в чужих уведомлениях. Посты в режиме
+// models.DeletePosts удаляются, в режиме models.AnonymizePosts остаются
+// с автором models.AnonymousAuthor; репосты удаляются в обоих режимах.
+// Имя освобождается. Итог записывается в журнал аудита без имени
+// пользователя.
+func (s *MicroBlogService) DeleteUser(ctx context.Context, username, mode string) (*models.AuditEntry, error) {
+	ctx, span := startSpan(ctx, "DeleteUser", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.String("delete.mode", mode),
+	))
+	defer span.End()
+
+	if mode != models.DeletePosts && mode != models.AnonymizePosts {
+		return nil, fail(span, validation.Errors{validation.NewFieldError("posts", validation.CodeInvalidValue,
+			fmt.Sprintf("ожидается %s или %s", models.DeletePosts, models.AnonymizePosts))})
+	}
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	entry := &models.AuditEntry{Action: models.AuditUserDeleted, UserID: user.ID, Mode: mode}
+
+	if err := s.erasePosts(ctx, user, mode, entry); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления постов пользователя ID %d: %v", user.ID, err))
+		return nil, fail(span, err)
+	}
+
+	s.mediaMu.Lock()
+	for _, m := range s.mediaRepo.ListPending(ctx, user.ID) {
+		s.dropMedia(ctx, m)
+		entry.MediaRemoved++
+	}
+	s.mediaMu.Unlock()
+	// Прежние имена - тоже персональные данные; освобождаются сразу
+	s.aliasRepo.DeleteByUser(ctx, user.ID)
+	s.followRepo.DeleteByUser(ctx, user.ID)
+	if user.Avatar != nil && s.blobs != nil {
+		s.deleteBlobs(ctx, avatarKeys(user.ID, user.Avatar))
+	}
+
+	s.webhookMu.Lock()
+	for _, h := range s.webhookRepo.List(ctx) {
+		if h.OwnerID != user.ID {
+			continue
+		}
+		if err := s.webhookRepo.Delete(ctx, h.ID); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка удаления вебхука %d: %v", h.ID, err))
+			continue
+		}
+		entry.WebhooksRemoved++
+	}
+	s.webhookMu.Unlock()
+
+	s.notificationMu.Lock()
+	if err := s.notificationRepo.DeleteByUser(ctx, user.ID); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления уведомлений пользователя ID %d: %v", user.ID, err))
+	}
+	s.notificationRepo.RemoveActor(ctx, user.ID)
+	s.notificationMu.Unlock()
+
+	if err := s.userRepo.Delete(ctx, user.Username); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления пользователя ID %d: %v", user.ID, err))
+		return nil, fail(span, err)
+	}
+
+	entry.ID = int(s.auditIDCounter.Increment())
+	entry.CreatedAt = s.clock.Now()
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		entry.TraceID = sc.TraceID().String()
+	}
+	if err := s.auditRepo.Add(ctx, entry); err != nil {
+		// Аккаунт уже удален: отказ журнала не должен скрыть это от клиента
+		s.logger.Error(fmt.Sprintf("Ошибка записи в журнал аудита: %v", err))
+	}
+
+	span.SetAttributes(
+		attribute.Int("post.deleted_count", entry.PostsDeleted),
+		attribute.Int("post.anonymized_count", entry.PostsAnonymized),
+	)
+	s.logger.Info(fmt.Sprintf("Пользователь ID %d удален (%s): постов удалено %d, обезличено %d, лайков снято %d",
+		user.ID, mode, entry.PostsDeleted, entry.PostsAnonymized, entry.LikesRemoved))
+	return entry, nil
+}
+
+// erasePosts снимает лайки пользователя, убирает его имя из упоминаний
+// в постах и индексе и удаляет или обезличивает его посты
+func (s *MicroBlogService) erasePosts(ctx context.Context, user *models.User, mode string, entry *models.AuditEntry) error {
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	isUser := func(id int) bool { return id == user.ID }
+	for _, p := range s.postRepo.List(ctx) {
+		liked, mentioned := slices.Contains(p.LikeIDs, user.ID), slices.Contains(p.MentionIDs, user.ID)
+		if !liked && !mentioned {
+			continue
+		}
+		p = clonePost(p)
+		if liked {
+			p.LikeIDs = slices.DeleteFunc(p.LikeIDs, isUser)
+			entry.LikesRemoved++
+		}
+		if mentioned {
+			p.MentionIDs = slices.DeleteFunc(p.MentionIDs, isUser)
+			s.indexEntities(ctx, p)
+		}
+		if err := s.postRepo.Update(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range s.postRepo.List(ctx) {
+		if p.AuthorID != user.ID {
+			continue
+		}
+		if _, err := s.postRepo.GetByID(ctx, p.ID); err != nil {
+			continue // уже удален как репост другого поста пользователя
+		}
+		if mode == models.DeletePosts || p.Kind == models.PostKindRepost {
+			entry.MediaRemoved += len(p.Attachments)
+			n, err := s.removePost(ctx, p)
+			if err != nil {
+				return err
+			}
+			entry.PostsDeleted += n
+			continue
+		}
+		if err := s.anonymizePost(ctx, p); err != nil {
+			return err
+		}
+		entry.PostsAnonymized++
+	}
+	return nil
+}
+
+// anonymizePost убирает автора из поста и его вложений: при чтении они
+// показываются от имени models.AnonymousAuthor. Поисковый индекс не
+// трогаем: он хранит ID автора, а ID удаленного пользователя больше не
+// найти по имени. Вызывается под postMu.
+func (s *MicroBlogService) anonymizePost(ctx context.Context, post *models.Post) error {
+	post = clonePost(post)
+	post.AuthorID = 0
+	s.mediaMu.Lock()
+	for i := range post.Attachments {
+		post.Attachments[i].OwnerID = 0
+		m := post.Attachments[i]
+		if err := s.mediaRepo.Update(ctx, &m); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка обновления владельца изображения %d: %v", m.ID, err))
+		}
+	}
+	s.mediaMu.Unlock()
+	return s.postRepo.Update(ctx, post)
+}
+
+// ListAudit возвращает страницу журнала аудита, новые записи первыми
+func (s *MicroBlogService) ListAudit(ctx context.Context, limit, offset int) (*models.AuditList, error) {
+	ctx, span := startSpan(ctx, "ListAudit")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultAuditLimit
+	}
+	limit = min(limit, MaxAuditLimit)
+	offset = max(offset, 0)
+
+	entries := s.auditRepo.List(ctx)
+	list := &models.AuditList{Total: len(entries), Limit: limit, Offset: offset, Entries: []*models.AuditEntry{}}
+	if offset < len(entries) {
+		list.Entries = entries[offset:min(offset+limit, len(entries))]
+	}
+	return list, nil
+}
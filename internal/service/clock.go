@@ -0,0 +1,15 @@
+package service
+
+import "time"
+
+// Clock - источник текущего времени; в тестах подменяется фиксированными часами
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock возвращает реальное время в UTC
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
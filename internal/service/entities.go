@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/entities"
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// GetPostsByTag возвращает посты с хэштегом tag (с '#' или без, регистр не важен), старые первыми
+func (s *MicroBlogService) GetPostsByTag(ctx context.Context, tag string) ([]*models.Post, error) {
+	tag = entities.NormalizeTag(tag)
+	ctx, span := startSpan(ctx, "GetPostsByTag", trace.WithAttributes(attribute.String("post.hashtag", tag)))
+	defer span.End()
+
+	ids, err := s.entityRepo.ListByTag(ctx, tag)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка чтения индекса хэштега %s: %v", tag, err))
+		return nil, fail(span, err)
+	}
+	posts := s.postsByIDs(ctx, ids)
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	return posts, nil
+}
+
+// GetMentions возвращает посты, в которых упомянут пользователь username, старые первыми
+func (s *MicroBlogService) GetMentions(ctx context.Context, username string) ([]*models.Post, error) {
+	ctx, span := startSpan(ctx, "GetMentions", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+
+	ids, err := s.entityRepo.ListByMention(ctx, user.ID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка чтения индекса упоминаний %s: %v", username, err))
+		return nil, fail(span, err)
+	}
+	posts := s.postsByIDs(ctx, ids)
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	return posts, nil
+}
+
+// extractEntities разбирает текст поста и возвращает хэштеги и ID
+// упомянутых пользователей. Упоминания несуществующих пользователей не
+// индексируются и остаются просто текстом.
+func (s *MicroBlogService) extractEntities(ctx context.Context, content string) (hashtags []string, mentions []int) {
+	found := entities.Extract(content)
+	for _, name := range found.Mentions {
+		if user, err := s.userRepo.GetByUsername(ctx, name); err == nil {
+			if !slices.Contains(mentions, user.ID) {
+				mentions = append(mentions, user.ID)
+			}
+		} else {
+			s.logger.Debug(fmt.Sprintf("Упоминание несуществующего пользователя @%s пропущено", name))
+		}
+	}
+	return found.Hashtags, mentions
+}
+
+// indexEntities обновляет индексы хэштегов и упоминаний поста. Ошибка
+// индексации не отменяет сохранение поста и только попадает в лог.
+func (s *MicroBlogService) indexEntities(ctx context.Context, post *models.Post) {
+	if err := s.entityRepo.Index(ctx, post.ID, post.Hashtags, post.MentionIDs); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка индексации хэштегов и упоминаний поста %d: %v", post.ID, err))
+	}
+}
+
+// postsByIDs загружает посты для выдачи по списку ID, пропуская отсутствующие
+func (s *MicroBlogService) postsByIDs(ctx context.Context, ids []int) []*models.Post {
+	posts := make([]*models.Post, 0, len(ids))
+	for _, id := range ids {
+		if p, err := s.postRepo.GetByID(ctx, id); err == nil {
+			posts = append(posts, s.viewPost(ctx, p))
+		}
+	}
+	return posts
+}
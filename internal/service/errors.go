@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Cere6rum/MicroBlog2/internal/imaging"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// Ошибки сервиса. Обработчики HTTP сопоставляют их с кодами ответа через errors.Is.
+var (
+	ErrEmptyUsername = validation.ErrEmptyUsername
+	ErrUserExists    = errors.New("пользователь уже существует")
+	ErrUserNotFound  = errors.New("пользователь не найден")
+	// ErrUsernameReserved - имя недавно освобождено другим пользователем
+	ErrUsernameReserved = errors.New("имя недавно освобождено и пока недоступно")
+	ErrEmptyContent     = validation.ErrEmptyContent
+	ErrPostNotFound     = errors.New("пост не найден")
+	ErrForbidden        = errors.New("недостаточно прав для этого действия")
+	// ErrInvalidInput - ввод не прошел проверку; подробности по полям в validation.Errors
+	ErrInvalidInput = validation.ErrInvalid
+
+	ErrParentNotFound   = errors.New("пост, на который дается ответ, не найден")
+	ErrOriginalNotFound = errors.New("исходный пост для репоста или цитаты не найден")
+	ErrAlreadyReposted  = errors.New("пост уже репостнут этим пользователем")
+	ErrRepostOwnPost    = errors.New("нельзя репостнуть собственный пост")
+	ErrNotEditable      = errors.New("репост нельзя редактировать")
+	ErrFollowSelf       = errors.New("нельзя подписаться на самого себя")
+	// ErrShuttingDown - очередь уже остановлена, сервер завершает работу
+	ErrShuttingDown = errors.New("сервер завершает работу")
+
+	ErrEmptyQuery = errors.New("поисковый запрос не содержит значимых слов")
+
+	ErrWebhookNotFound  = errors.New("вебхук не найден")
+	ErrWebhooksDisabled = errors.New("вебхуки отключены")
+	ErrTooManyWebhooks  = errors.New("превышено число вебхуков пользователя")
+
+	ErrUploadsDisabled  = errors.New("загрузка файлов отключена")
+	ErrAvatarNotFound   = errors.New("аватар не загружен")
+	ErrUnsupportedImage = imaging.ErrUnsupported
+	ErrImageTooLarge    = imaging.ErrTooLarge
+	ErrCorruptImage     = imaging.ErrCorrupt
+
+	ErrMediaNotFound       = errors.New("изображение не найдено")
+	ErrMediaAttached       = errors.New("изображение уже прикреплено к посту")
+	ErrAttachmentNotFound  = errors.New("прикрепляемое изображение не найдено")
+	ErrNoMedia             = errors.New("не передано ни одного изображения")
+	ErrTooManyAttachments  = fmt.Errorf("к посту можно прикрепить не больше %d изображений", MaxAttachments)
+	ErrTooManyPendingMedia = fmt.Errorf("больше %d загруженных, но не прикрепленных изображений", MaxPendingMedia)
+)
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+)
+
+// WithFollowRepo задает хранилище подписок
+func WithFollowRepo(r repository.FollowRepository) Option {
+	return func(s *MicroBlogService) {
+		s.followRepo = r
+	}
+}
+
+// Follow подписывает follower на followee. Повторная подписка ничего не
+// меняет; о новой подписке followee получает уведомление.
+func (s *MicroBlogService) Follow(ctx context.Context, follower, followee string) error {
+	ctx, span := startSpan(ctx, "Follow", trace.WithAttributes(
+		attribute.String("user.name", follower),
+		attribute.String("follow.followee", followee),
+	))
+	defer span.End()
+
+	// Под блокировкой пользователей: удаление аккаунта не пропустит
+	// подписку, созданную одновременно с ним
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	from, to, err := s.followPair(ctx, follower, followee)
+	if err != nil {
+		return fail(span, err)
+	}
+	if !s.followRepo.Follow(ctx, from.ID, to.ID) {
+		return nil
+	}
+	s.logger.Info(fmt.Sprintf("Пользователь %s подписался на %s", from.Username, to.Username))
+	s.notify(ctx, models.NotificationEvent{
+		Type:        models.NotificationFollow,
+		RecipientID: to.ID,
+		ActorID:     from.ID,
+	})
+	return nil
+}
+
+// Unfollow отменяет подписку follower на followee; отсутствие подписки не ошибка
+func (s *MicroBlogService) Unfollow(ctx context.Context, follower, followee string) error {
+	ctx, span := startSpan(ctx, "Unfollow", trace.WithAttributes(
+		attribute.String("user.name", follower),
+		attribute.String("follow.followee", followee),
+	))
+	defer span.End()
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	from, to, err := s.followPair(ctx, follower, followee)
+	if err != nil {
+		return fail(span, err)
+	}
+	if s.followRepo.Unfollow(ctx, from.ID, to.ID) {
+		s.logger.Info(fmt.Sprintf("Пользователь %s отписался от %s", from.Username, to.Username))
+	}
+	return nil
+}
+
+// followPair находит обоих участников подписки
+func (s *MicroBlogService) followPair(ctx context.Context, follower, followee string) (*models.User, *models.User, error) {
+	from, err := s.userRepo.GetByUsername(ctx, follower)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	to, err := s.userRepo.GetByUsername(ctx, followee)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	if from.ID == to.ID {
+		return nil, nil, ErrFollowSelf
+	}
+	return from, to, nil
+}
+
+// ListFollowers возвращает страницу подписчиков пользователя, в порядке подписки
+func (s *MicroBlogService) ListFollowers(ctx context.Context, username string, limit, offset int) (*models.UserList, error) {
+	ctx, span := startSpan(ctx, "ListFollowers", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	return s.userPage(ctx, s.followRepo.Followers(ctx, user.ID), limit, offset), nil
+}
+
+// ListFollowing возвращает страницу пользователей, на которых подписан
+// пользователь, в порядке подписки
+func (s *MicroBlogService) ListFollowing(ctx context.Context, username string, limit, offset int) (*models.UserList, error) {
+	ctx, span := startSpan(ctx, "ListFollowing", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	return s.userPage(ctx, s.followRepo.Following(ctx, user.ID), limit, offset), nil
+}
+
+// userPage превращает страницу ID в страницу пользователей; limit и offset
+// ограничиваются так же, как в ListUsers
+func (s *MicroBlogService) userPage(ctx context.Context, ids []int, limit, offset int) *models.UserList {
+	if limit <= 0 {
+		limit = DefaultUserLimit
+	}
+	limit = min(limit, MaxUserLimit)
+	offset = max(offset, 0)
+
+	list := &models.UserList{Total: len(ids), Limit: limit, Offset: offset, Users: []*models.User{}}
+	if offset >= len(ids) {
+		return list
+	}
+	for _, id := range ids[offset:min(offset+limit, len(ids))] {
+		if user, err := s.userRepo.GetByID(ctx, id); err == nil {
+			list.Users = append(list.Users, user)
+		}
+	}
+	return list
+}
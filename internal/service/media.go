@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/imaging"
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+)
+
+// Ограничения вложений
+const (
+	MaxAttachments  = 4    // изображений в одном посте
+	MaxPendingMedia = 20   // загруженных, но не прикрепленных изображений на пользователя
+	MediaMaxSide    = 2048 // большая сторона сохраняемой копии в пикселях
+	ThumbnailSide   = 400  // большая сторона миниатюры в пикселях
+)
+
+// DefaultPendingMediaTTL - сколько хранится изображение, так и не
+// прикрепленное к посту
+const DefaultPendingMediaTTL = 24 * time.Hour
+
+// WithPendingMediaTTL задает срок хранения неприкрепленных изображений
+// (0 - хранить до удаления аккаунта)
+func WithPendingMediaTTL(d time.Duration) Option {
+	return func(s *MicroBlogService) {
+		s.pendingMediaTTL = d
+	}
+}
+
+// WithMediaRepo задает хранилище сведений о загруженных изображениях
+func WithMediaRepo(r repository.MediaRepository) Option {
+	return func(s *MicroBlogService) {
+		s.mediaRepo = r
+	}
+}
+
+// Attach прикрепляет к создаваемому посту загруженные автором изображения
+func Attach(mediaIDs ...int) PostOption {
+	return func(p *postParams) {
+		p.mediaIDs = append(p.mediaIDs, mediaIDs...)
+	}
+}
+
+// UploadMedia загружает изображения для будущего поста. Формат определяется
+// по содержимому; каждое изображение уменьшается до MediaMaxSide, получает
+// миниатюру и перекодируется, поэтому EXIF и другие метаданные (включая
+// геометку) не сохраняются. У GIF сохраняется только первый кадр.
+// Либо сохраняются все файлы, либо ни одного.
+func (s *MicroBlogService) UploadMedia(ctx context.Context, username string, files ...[]byte) ([]*models.Media, error) {
+	ctx, span := startSpan(ctx, "UploadMedia", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.Int("media.count", len(files)),
+	))
+	defer span.End()
+
+	if s.blobs == nil {
+		return nil, fail(span, ErrUploadsDisabled)
+	}
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	if len(files) == 0 {
+		return nil, fail(span, ErrNoMedia)
+	}
+	if len(files) > MaxAttachments {
+		return nil, fail(span, ErrTooManyAttachments)
+	}
+
+	// Сначала декодируем все файлы: ошибка в любом отклоняет загрузку целиком
+	type decoded struct {
+		img    image.Image
+		format string
+	}
+	images := make([]decoded, len(files))
+	for i, data := range files {
+		img, format, err := imaging.Decode(data)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Изображение %d от %s отклонено: %v", i+1, username, err))
+			return nil, fail(span, fmt.Errorf("файл %d: %w", i+1, err))
+		}
+		images[i] = decoded{img, format}
+	}
+
+	s.mediaMu.Lock()
+	defer s.mediaMu.Unlock()
+	if n := len(s.mediaRepo.ListPending(ctx, user.ID)); n+len(files) > MaxPendingMedia {
+		return nil, fail(span, ErrTooManyPendingMedia)
+	}
+
+	now := s.clock.Now()
+	out := make([]*models.Media, 0, len(files))
+	for _, d := range images {
+		media, err := s.storeMedia(ctx, user, d.img, d.format, now)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка сохранения изображения от %s: %v", username, err))
+			for _, m := range out {
+				s.dropMedia(ctx, m)
+			}
+			return nil, fail(span, err)
+		}
+		out = append(out, media)
+	}
+
+	s.logger.Info(fmt.Sprintf("Пользователь %s загрузил изображений: %d", username, len(out)))
+	views := make([]*models.Media, len(out))
+	for i, m := range out {
+		views[i] = s.viewMedia(ctx, m)
+		views[i].AccessKey = m.AccessKey // ключ получает только загрузивший
+	}
+	return views, nil
+}
+
+// GetMedia возвращает сведения об изображении и его файл: миниатюру или
+// сохраненную копию. Изображение, еще не прикрепленное к посту, отдается
+// только по ключу доступа из ответа на загрузку; без ключа его нет, как и
+// несуществующего.
+func (s *MicroBlogService) GetMedia(ctx context.Context, id int, accessKey string, thumbnail bool) ([]byte, *models.Media, error) {
+	ctx, span := startSpan(ctx, "GetMedia", trace.WithAttributes(
+		attribute.Int("media.id", id),
+		attribute.Bool("media.thumbnail", thumbnail),
+	))
+	defer span.End()
+
+	if s.blobs == nil {
+		return nil, nil, fail(span, ErrUploadsDisabled)
+	}
+	media, err := s.mediaRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, fail(span, ErrMediaNotFound)
+	}
+	if media.PostID == 0 && (accessKey == "" ||
+		subtle.ConstantTimeCompare([]byte(accessKey), []byte(media.AccessKey)) != 1) {
+		return nil, nil, fail(span, ErrMediaNotFound)
+	}
+	key := mediaKey(media, "original")
+	if thumbnail {
+		key = mediaKey(media, "thumbnail")
+	}
+	data, _, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Файл %s не прочитан: %v", key, err))
+		return nil, nil, fail(span, ErrMediaNotFound)
+	}
+	return data, s.viewMedia(ctx, media), nil
+}
+
+// viewMedia возвращает копию сведений об изображении с текущим именем
+// владельца и без ключа доступа
+func (s *MicroBlogService) viewMedia(ctx context.Context, m *models.Media) *models.Media {
+	out := *m
+	out.Owner = s.username(ctx, m.OwnerID)
+	out.AccessKey = ""
+	return &out
+}
+
+// ExpirePendingMedia удаляет изображения, не прикрепленные к посту дольше
+// срока хранения, и возвращает, сколько удалено
+func (s *MicroBlogService) ExpirePendingMedia(ctx context.Context) int {
+	ctx, span := startSpan(ctx, "ExpirePendingMedia")
+	defer span.End()
+
+	if s.pendingMediaTTL <= 0 {
+		return 0
+	}
+	// Под той же блокировкой, что и прикрепление: изображение не удалится
+	// между проверкой при создании поста и сохранением вложения
+	s.mediaMu.Lock()
+	defer s.mediaMu.Unlock()
+
+	expired := s.mediaRepo.ListPendingBefore(ctx, s.clock.Now().Add(-s.pendingMediaTTL))
+	for _, m := range expired {
+		s.dropMedia(ctx, m)
+	}
+	span.SetAttributes(attribute.Int("media.count", len(expired)))
+	if len(expired) > 0 {
+		s.logger.Info(fmt.Sprintf("Удалено неприкрепленных изображений старше %s: %d", s.pendingMediaTTL, len(expired)))
+	}
+	return len(expired)
+}
+
+// RunMediaExpiry периодически удаляет просроченные неприкрепленные
+// изображения до отмены контекста
+func (s *MicroBlogService) RunMediaExpiry(ctx context.Context) {
+	if s.pendingMediaTTL <= 0 || s.blobs == nil {
+		return
+	}
+	ticker := time.NewTicker(min(max(s.pendingMediaTTL/4, time.Minute), time.Hour))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ExpirePendingMedia(ctx)
+		}
+	}
+}
+
+// storeMedia сохраняет копию и миниатюру изображения и регистрирует его.
+// Вызывается под mediaMu.
+func (s *MicroBlogService) storeMedia(ctx context.Context, owner *models.User, img image.Image, format string, now time.Time) (*models.Media, error) {
+	full := imaging.Fit(img, MediaMaxSide)
+	thumb := imaging.Fit(img, ThumbnailSide)
+	fullData, contentType, err := imaging.Encode(full, format)
+	if err != nil {
+		return nil, err
+	}
+	thumbData, _, err := imaging.Encode(thumb, format)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("генерация ключа доступа: %w", err)
+	}
+
+	media := &models.Media{
+		ID:          int(s.mediaIDCounter.Increment()),
+		OwnerID:     owner.ID,
+		ContentType: contentType,
+		Width:       full.Bounds().Dx(),
+		Height:      full.Bounds().Dy(),
+		Size:        len(fullData),
+		ThumbWidth:  thumb.Bounds().Dx(),
+		ThumbHeight: thumb.Bounds().Dy(),
+		CreatedAt:   now,
+		AccessKey:   hex.EncodeToString(key),
+	}
+	if err := s.blobs.Put(ctx, mediaKey(media, "original"), contentType, fullData); err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Put(ctx, mediaKey(media, "thumbnail"), contentType, thumbData); err != nil {
+		s.deleteBlobs(ctx, []string{mediaKey(media, "original")})
+		return nil, err
+	}
+	if err := s.mediaRepo.Create(ctx, media); err != nil {
+		s.deleteBlobs(ctx, mediaKeys(media))
+		return nil, err
+	}
+	return media, nil
+}
+
+// pendingMedia проверяет, что изображения ids загружены автором и еще не
+// прикреплены. Вызывается под mediaMu.
+func (s *MicroBlogService) pendingMedia(ctx context.Context, author *models.User, ids []int) ([]*models.Media, error) {
+	if len(ids) > MaxAttachments {
+		return nil, ErrTooManyAttachments
+	}
+	out := make([]*models.Media, 0, len(ids))
+	for i, id := range ids {
+		if slices.Contains(ids[:i], id) {
+			return nil, fmt.Errorf("%w: изображение %d указано дважды", ErrMediaAttached, id)
+		}
+		media, err := s.mediaRepo.GetByID(ctx, id)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("%w: %d", ErrAttachmentNotFound, id)
+		case media.OwnerID != author.ID:
+			return nil, fmt.Errorf("%w: изображение %d загружено другим пользователем", ErrForbidden, id)
+		case media.PostID != 0:
+			return nil, fmt.Errorf("%w: изображение %d", ErrMediaAttached, id)
+		}
+		out = append(out, media)
+	}
+	return out, nil
+}
+
+// attachMedia сохраняет изображения, прикрепленные к созданному посту.
+// Вызывается под mediaMu.
+func (s *MicroBlogService) attachMedia(ctx context.Context, attachments []models.Media) {
+	for _, m := range attachments {
+		// Репозиторий отдает общий указатель: сохраняем отдельную копию
+		if err := s.mediaRepo.Update(ctx, &m); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка прикрепления изображения %d к посту %d: %v", m.ID, m.PostID, err))
+		}
+	}
+}
+
+// dropMedia удаляет изображение вместе с файлами
+func (s *MicroBlogService) dropMedia(ctx context.Context, media *models.Media) {
+	if err := s.mediaRepo.Delete(ctx, media.ID); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления изображения %d: %v", media.ID, err))
+	}
+	if s.blobs != nil {
+		s.deleteBlobs(ctx, mediaKeys(media))
+	}
+}
+
+// mediaKey - ключ блоба с вариантом изображения ("original" или "thumbnail")
+func mediaKey(m *models.Media, variant string) string {
+	return fmt.Sprintf("media/%d/%s%s", m.ID, variant, imaging.Extension(m.ContentType))
+}
+
+// mediaKeys - ключи всех файлов изображения
+func mediaKeys(m *models.Media) []string {
+	return []string{mediaKey(m, "original"), mediaKey(m, "thumbnail")}
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/tracing"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// Размер страницы уведомлений
+const (
+	DefaultNotificationLimit = 20
+	MaxNotificationLimit     = 100
+)
+
+// notificationActors - сколько последних участников хранится в уведомлении;
+// остальные учитываются только в счетчике
+const notificationActors = 3
+
+// WithNotificationQueue включает асинхронное создание уведомлений через очередь.
+// Воркеры очереди запускаются снаружи с обработчиком ProcessNotificationEvent.
+func WithNotificationQueue(q *queue.NotificationQueue) Option {
+	return func(s *MicroBlogService) {
+		s.notificationQueue = q
+	}
+}
+
+// WithNotificationRepo задает хранилище уведомлений и настроек
+func WithNotificationRepo(r repository.NotificationRepository) Option {
+	return func(s *MicroBlogService) {
+		s.notificationRepo = r
+	}
+}
+
+// ListNotifications возвращает страницу уведомлений пользователя, новые
+// первыми; unreadOnly оставляет только непрочитанные
+func (s *MicroBlogService) ListNotifications(ctx context.Context, username string, unreadOnly bool, limit, offset int) (*models.NotificationList, error) {
+	ctx, span := startSpan(ctx, "ListNotifications", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	if limit <= 0 {
+		limit = DefaultNotificationLimit
+	}
+	limit = min(limit, MaxNotificationLimit)
+	offset = max(offset, 0)
+
+	// Воркеры меняют уведомления на месте, поэтому наружу отдаются копии
+	s.notificationMu.Lock()
+	var list []*models.Notification
+	unread := 0
+	for _, n := range s.notificationRepo.ListByUser(ctx, user.ID) {
+		if !n.Read {
+			unread++
+		}
+		if unreadOnly && n.Read {
+			continue
+		}
+		c := *n
+		c.ActorIDs = slices.Clone(n.ActorIDs)
+		list = append(list, &c)
+	}
+	s.notificationMu.Unlock()
+
+	result := &models.NotificationList{
+		Unread:        unread,
+		Total:         len(list),
+		Limit:         limit,
+		Offset:        offset,
+		Notifications: make([]*models.Notification, 0),
+	}
+	if offset < len(list) {
+		result.Notifications = list[offset:min(offset+limit, len(list))]
+	}
+	// Имена участников подставляются только для выданной страницы
+	for _, n := range result.Notifications {
+		n.Actors = s.usernames(ctx, n.ActorIDs)
+		n.Text = notificationText(n)
+	}
+	span.SetAttributes(attribute.Int("notification.unread", unread))
+	return result, nil
+}
+
+// MarkNotificationsRead отмечает уведомления ids прочитанными (пустой
+// список - все) и возвращает, сколько непрочитанных осталось
+func (s *MicroBlogService) MarkNotificationsRead(ctx context.Context, username string, ids []int) (int, error) {
+	ctx, span := startSpan(ctx, "MarkNotificationsRead", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return 0, fail(span, ErrUserNotFound)
+	}
+
+	s.notificationMu.Lock()
+	defer s.notificationMu.Unlock()
+
+	marked := s.notificationRepo.MarkRead(ctx, user.ID, ids)
+	unread := 0
+	for _, n := range s.notificationRepo.ListByUser(ctx, user.ID) {
+		if !n.Read {
+			unread++
+		}
+	}
+	s.logger.Debug(fmt.Sprintf("Пользователь %s прочитал уведомлений: %d, осталось: %d", username, marked, unread))
+	return unread, nil
+}
+
+// NotificationPreferences возвращает настройки уведомлений по типам
+func (s *MicroBlogService) NotificationPreferences(ctx context.Context, username string) (map[string]bool, error) {
+	ctx, span := startSpan(ctx, "NotificationPreferences", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	return s.preferences(ctx, user.ID), nil
+}
+
+// SetNotificationPreferences включает или выключает уведомления указанных
+// типов; остальные типы не меняются. Возвращает итоговые настройки.
+func (s *MicroBlogService) SetNotificationPreferences(ctx context.Context, username string, changes map[string]bool) (map[string]bool, error) {
+	ctx, span := startSpan(ctx, "SetNotificationPreferences", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	var errs validation.Errors
+	for typ := range changes {
+		if !slices.Contains(models.NotificationTypes, typ) {
+			errs = append(errs, validation.NewFieldError("preferences."+typ, validation.CodeInvalidValue,
+				"неизвестный тип уведомлений"))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fail(span, errs)
+	}
+
+	s.notificationMu.Lock()
+	defer s.notificationMu.Unlock()
+
+	prefs := s.preferences(ctx, user.ID)
+	for typ, enabled := range changes {
+		prefs[typ] = enabled
+	}
+	if err := s.notificationRepo.SetPreferences(ctx, user.ID, prefs); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка сохранения настроек уведомлений %s: %v", username, err))
+		return nil, fail(span, err)
+	}
+	s.logger.Info(fmt.Sprintf("Пользователь %s изменил настройки уведомлений: %v", username, prefs))
+	return prefs, nil
+}
+
+// preferences дополняет сохраненные настройки типами по умолчанию (включены)
+func (s *MicroBlogService) preferences(ctx context.Context, userID int) map[string]bool {
+	prefs := s.notificationRepo.Preferences(ctx, userID)
+	if prefs == nil {
+		prefs = make(map[string]bool, len(models.NotificationTypes))
+	}
+	for _, typ := range models.NotificationTypes {
+		if _, ok := prefs[typ]; !ok {
+			prefs[typ] = true
+		}
+	}
+	return prefs
+}
+
+// notify отправляет повод для уведомления в очередь. Без очереди
+// (например, в тестах) уведомление создается сразу. Вызывается под
+// блокировками постов и пользователей, поэтому очередь не ждет: при
+// переполнении уведомление пропускается.
+func (s *MicroBlogService) notify(ctx context.Context, event models.NotificationEvent) {
+	if event.RecipientID == event.ActorID || event.RecipientID == 0 {
+		return // о собственных действиях и обезличенным постам не уведомляем
+	}
+	event.TraceContext = tracing.Inject(ctx)
+	if s.notificationQueue == nil {
+		if err := s.ProcessNotificationEvent(event); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка создания уведомления для пользователя ID %d: %v", event.RecipientID, err))
+		}
+		return
+	}
+	if !s.notificationQueue.TryEnqueue(event) {
+		s.logger.Error(fmt.Sprintf("Очередь уведомлений переполнена, уведомление %s для пользователя ID %d пропущено", event.Type, event.RecipientID))
+	}
+}
+
+// ProcessNotificationEvent создает уведомление (вызывается из очереди
+// уведомлений). Лайк поста, о котором уже есть непрочитанное уведомление,
+// добавляется в него.
+func (s *MicroBlogService) ProcessNotificationEvent(event models.NotificationEvent) error {
+	origin := trace.SpanContextFromContext(tracing.Extract(context.Background(), event.TraceContext))
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("notification.type", event.Type),
+			attribute.Int("user.id", event.RecipientID),
+			attribute.Int("post.id", event.PostID),
+		),
+	}
+	if origin.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: origin}))
+	}
+	ctx, span := startSpan(context.Background(), "ProcessNotificationEvent", opts...)
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, event.RecipientID)
+	if err != nil {
+		s.logger.Debug(fmt.Sprintf("Уведомление пропущено: получатель ID %d не найден", event.RecipientID))
+		return nil
+	}
+
+	// Пост проверяется под блокировкой: удаление поста убирает уведомления
+	// о нем под той же блокировкой, и запоздавшее событие их не вернет
+	s.notificationMu.Lock()
+	defer s.notificationMu.Unlock()
+
+	if event.Type != models.NotificationFollow {
+		if _, err := s.postRepo.GetByID(ctx, event.PostID); err != nil {
+			s.logger.Debug(fmt.Sprintf("Уведомление пропущено: пост %d удален", event.PostID))
+			return nil
+		}
+	}
+
+	if !s.preferences(ctx, user.ID)[event.Type] {
+		span.SetAttributes(attribute.Bool("notification.muted", true))
+		return nil
+	}
+
+	now := s.clock.Now()
+	if event.Type == models.NotificationLike {
+		if n, err := s.notificationRepo.FindUnread(ctx, user.ID, event.Type, event.PostID); err == nil {
+			if slices.Contains(n.ActorIDs, event.ActorID) {
+				return nil
+			}
+			n.ActorIDs = append([]int{event.ActorID}, n.ActorIDs...)[:min(len(n.ActorIDs)+1, notificationActors)]
+			n.Count++
+			n.UpdatedAt = now
+			if err := s.notificationRepo.Update(ctx, n); err != nil {
+				s.logger.Error(fmt.Sprintf("Ошибка обновления уведомления %d: %v", n.ID, err))
+				return fail(span, err)
+			}
+			span.SetAttributes(attribute.Bool("notification.aggregated", true))
+			return nil
+		}
+	}
+
+	n := &models.Notification{
+		ID:        int(s.notificationIDCounter.Increment()),
+		UserID:    user.ID,
+		Type:      event.Type,
+		PostID:    event.PostID,
+		ActorIDs:  []int{event.ActorID},
+		Count:     1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.notificationRepo.Create(ctx, n); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка создания уведомления для %s: %v", user.Username, err))
+		return fail(span, err)
+	}
+	s.logger.Debug(fmt.Sprintf("Уведомление %d (%s) для %s создано", n.ID, n.Type, user.Username))
+	return nil
+}
+
+// notificationText формирует текст уведомления по именам участников n.Actors
+func notificationText(n *models.Notification) string {
+	switch n.Type {
+	case models.NotificationMention:
+		return fmt.Sprintf("Пользователь %s упомянул вас в посте", n.Actors[0])
+	case models.NotificationFollow:
+		return fmt.Sprintf("Пользователь %s подписался на вас", n.Actors[0])
+	}
+	switch n.Count {
+	case 1:
+		return fmt.Sprintf("Пользователь %s оценил ваш пост", n.Actors[0])
+	case 2:
+		return fmt.Sprintf("%s и %s оценили ваш пост", n.Actors[0], n.Actors[1])
+	default:
+		others := n.Count - 1
+		return fmt.Sprintf("%s и еще %d %s оценили ваш пост", n.Actors[0], others, pluralUsers(others))
+	}
+}
+
+// pluralUsers согласует слово "пользователь" с числом n
+func pluralUsers(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "пользователь"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "пользователя"
+	default:
+		return "пользователей"
+	}
+}
@@ -1,74 +1,222 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"math"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+	"github.com/Cere6rum/MicroBlog2/internal/tracing"
+	"github.com/Cere6rum/MicroBlog2/internal/trending"
 )
 
+// postParams - необязательные параметры создаваемого поста
+type postParams struct {
+	replyToID int
+	quoteOfID int
+	mediaIDs  []int
+}
+
+// PostOption задает необязательный параметр CreatePost
+type PostOption func(*postParams)
+
+// ReplyTo делает создаваемый пост ответом на пост parentID
+func ReplyTo(parentID int) PostOption {
+	return func(p *postParams) {
+		p.replyToID = parentID
+	}
+}
+
+// Quote делает создаваемый пост цитатой поста originalID
+func Quote(originalID int) PostOption {
+	return func(p *postParams) {
+		p.quoteOfID = originalID
+	}
+}
+
 // CreatePost создает новый пост
-func (s *MicroBlogService) CreatePost(username, content string) (*models.Post, error) {
-	if content == "" {
-		s.logger.Error("Попытка создания поста с пустым содержимым")
-		return nil, errors.New("содержимое поста не может быть пустым")
+func (s *MicroBlogService) CreatePost(ctx context.Context, username, content string, opts ...PostOption) (*models.Post, error) {
+	var params postParams
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	ctx, span := startSpan(ctx, "CreatePost", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	content, err := s.validator.PostContent(content)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Недопустимое содержимое поста: %v", err))
+		return nil, fail(span, err)
 	}
 
 	// Проверяем существование пользователя
-	user, err := s.userRepo.GetByUsername(username)
+	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Пользователь %s не найден: %v", username, err))
-		return nil, errors.New("пользователь не найден")
+		return nil, fail(span, ErrUserNotFound)
+	}
+
+	// Ответ и цитата ссылаются на существующие посты. Блокировка держится до
+	// обновления их счетчиков, чтобы не потерять конкурентные изменения.
+	var parent, original *models.Post
+	if params.replyToID != 0 || params.quoteOfID != 0 {
+		s.postMu.Lock()
+		defer s.postMu.Unlock()
+	}
+	if params.replyToID != 0 {
+		span.SetAttributes(attribute.Int("post.reply_to_id", params.replyToID))
+		parent, err = s.postRepo.GetByID(ctx, params.replyToID)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Родительский пост %d для ответа не найден: %v", params.replyToID, err))
+			return nil, fail(span, ErrParentNotFound)
+		}
+	}
+	if params.quoteOfID != 0 {
+		span.SetAttributes(attribute.Int("post.original_id", params.quoteOfID))
+		original, err = s.resolveOriginal(ctx, params.quoteOfID)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Цитируемый пост %d не найден: %v", params.quoteOfID, err))
+			return nil, fail(span, ErrOriginalNotFound)
+		}
+	}
+	// Изображения проверяются и прикрепляются под одной блокировкой, чтобы
+	// одно изображение не попало в два поста
+	var media []*models.Media
+	if len(params.mediaIDs) > 0 {
+		span.SetAttributes(attribute.IntSlice("post.media_ids", params.mediaIDs))
+		s.mediaMu.Lock()
+		defer s.mediaMu.Unlock()
+		if media, err = s.pendingMedia(ctx, user, params.mediaIDs); err != nil {
+			s.logger.Error(fmt.Sprintf("Недопустимые вложения поста от %s: %v", username, err))
+			return nil, fail(span, err)
+		}
 	}
 
 	// Создаем новый пост
 	postID := int(s.postIDCounter.Increment())
+	now := s.clock.Now()
 	post := &models.Post{
-		ID:       postID,
-		AuthorID: user.ID,
-		Author:   user.Username,
-		Content:  content,
-		Likes:    make([]string, 0),
+		ID:        postID,
+		Kind:      models.PostKindPost,
+		AuthorID:  user.ID,
+		Content:   content,
+		ReplyToID: params.replyToID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if original != nil {
+		post.Kind = models.PostKindQuote
+		post.OriginalID = original.ID
+	}
+	post.Hashtags, post.MentionIDs = s.extractEntities(ctx, content)
+	for _, m := range media {
+		attached := *m
+		attached.PostID = postID
+		attached.AccessKey = "" // прикрепленное изображение доступно всем
+		post.Attachments = append(post.Attachments, attached)
 	}
 
 	// Добавляем в репозиторий
-	if err := s.postRepo.Create(post); err != nil {
+	if err := s.postRepo.Create(ctx, post); err != nil {
 		s.logger.Error(fmt.Sprintf("Ошибка при создании поста: %v", err))
-		return nil, err
+		return nil, fail(span, err)
 	}
+	s.attachMedia(ctx, post.Attachments)
+	s.indexEntities(ctx, post)
+
+	if parent != nil {
+		parent = clonePost(parent)
+		parent.ReplyCount++
+		if err := s.postRepo.Update(ctx, parent); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка при обновлении счетчика ответов поста %d: %v", parent.ID, err))
+		}
+		s.recordActivity(parent, trending.ReplyWeight)
+	}
+	s.trending.RecordTags(post.Hashtags, trending.TagWeight, now)
+	if original != nil {
+		original = clonePost(original)
+		original.QuoteCount++
+		if err := s.postRepo.Update(ctx, original); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка при обновлении счетчика цитат поста %d: %v", original.ID, err))
+		}
+	}
+
+	// Исходный текст - первая версия в истории правок
+	if err := s.revisionRepo.Add(ctx, &models.PostRevision{
+		PostID:    postID,
+		Version:   1,
+		Content:   content,
+		EditorID:  user.ID,
+		CreatedAt: now,
+	}); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при сохранении истории поста %d: %v", postID, err))
+	}
+	s.enqueueIndex(ctx, models.IndexEvent{
+		Op:       models.IndexOpUpsert,
+		PostID:   postID,
+		AuthorID: user.ID,
+		Content:  content,
+		Revision: 1,
+	})
+	view := s.viewPost(ctx, post)
+	s.publish(ctx, pubsub.EventPostCreated, view, &models.PostView{Post: view, Original: s.viewPost(ctx, original)})
+	s.notifyMentions(ctx, post, nil)
+	span.SetAttributes(attribute.Int("post.id", postID))
 	s.logger.Info(fmt.Sprintf("Создан новый пост ID: %d от пользователя: %s", postID, username))
 
-	return post, nil
+	return view, nil
 }
 
 // GetAllPosts возвращает все посты
-func (s *MicroBlogService) GetAllPosts() ([]*models.Post, error) {
-	posts := s.postRepo.List()
+func (s *MicroBlogService) GetAllPosts(ctx context.Context) ([]*models.Post, error) {
+	ctx, span := startSpan(ctx, "GetAllPosts")
+	defer span.End()
+
+	posts := s.viewPosts(ctx, s.postRepo.List(ctx))
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
 	s.logger.Debug(fmt.Sprintf("Запрошены все посты, количество: %d", len(posts)))
 	return posts, nil
 }
 
 // LikePost добавляет лайк к посту (асинхронно через очередь)
-func (s *MicroBlogService) LikePost(postID int, username string) error {
+func (s *MicroBlogService) LikePost(ctx context.Context, postID int, username string) error {
+	ctx, span := startSpan(ctx, "LikePost", trace.WithAttributes(
+		attribute.Int("post.id", postID),
+		attribute.String("user.name", username),
+	))
+	defer span.End()
+
 	// Проверяем существование пользователя
-	if !s.userRepo.Exists(username) {
+	if !s.userRepo.Exists(ctx, username) {
 		s.logger.Error(fmt.Sprintf("Пользователь %s не найден для лайка", username))
-		return errors.New("пользователь не найден")
+		return fail(span, ErrUserNotFound)
 	}
 
 	// Проверяем существование поста
-	_, err := s.postRepo.GetByID(postID)
+	_, err := s.postRepo.GetByID(ctx, postID)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Пост с ID %d не найден: %v", postID, err))
-		return errors.New("пост не найден")
+		return fail(span, ErrPostNotFound)
 	}
 
-	// Отправляем событие в очередь для асинхронной обработки
+	// Отправляем событие в очередь для асинхронной обработки.
+	// Контекст трассировки едет вместе с событием, чтобы спан воркера
+	// был связан с исходным запросом.
 	event := models.LikeEvent{
-		PostID:   postID,
-		Username: username,
+		PostID:       postID,
+		Username:     username,
+		TraceContext: tracing.Inject(ctx),
+	}
+	if !s.likeQueue.Enqueue(event) {
+		s.logger.Error(fmt.Sprintf("Очередь лайков остановлена, лайк от %s к посту %d не принят", username, postID))
+		return fail(span, ErrShuttingDown)
 	}
-	s.likeQueue.Enqueue(event)
 	s.logger.Info(fmt.Sprintf("Лайк от %s к посту %d добавлен в очередь", username, postID))
 
 	return nil
@@ -76,27 +224,338 @@ func (s *MicroBlogService) LikePost(postID int, username string) error {
 
 // ProcessLikeEvent обрабатывает событие лайка (вызывается из очереди)
 func (s *MicroBlogService) ProcessLikeEvent(event models.LikeEvent) error {
-	post, err := s.postRepo.GetByID(event.PostID)
+	// Воркер начинает новый трейс, а исходный запрос прикрепляется ссылкой:
+	// обработка может завершиться намного позже ответа клиенту.
+	origin := trace.SpanContextFromContext(tracing.Extract(context.Background(), event.TraceContext))
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int("post.id", event.PostID),
+			attribute.String("user.name", event.Username),
+		),
+	}
+	if origin.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: origin}))
+	}
+	ctx, span := startSpan(context.Background(), "ProcessLikeEvent", opts...)
+	defer span.End()
+
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	post, err := s.postRepo.GetByID(ctx, event.PostID)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("Пост с ID %d не найден при обработке лайка: %v", event.PostID, err))
-		return errors.New("пост не найден")
+		return fail(span, ErrPostNotFound)
+	}
+	// Аккаунт мог быть удален или переименован, пока лайк ждал в очереди
+	username, err := s.ResolveUsername(ctx, event.Username)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пользователь %s не найден при обработке лайка поста %d", event.Username, event.PostID))
+		return fail(span, ErrUserNotFound)
+	}
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return fail(span, ErrUserNotFound)
 	}
+	event.Username = user.Username
 
 	// Проверяем, не лайкал ли уже этот пользователь
-	for _, liker := range post.Likes {
-		if liker == event.Username {
-			s.logger.Debug(fmt.Sprintf("Пользователь %s уже лайкнул пост %d", event.Username, event.PostID))
-			return nil // Уже лайкнуто
-		}
+	if slices.Contains(post.LikeIDs, user.ID) {
+		s.logger.Debug(fmt.Sprintf("Пользователь %s уже лайкнул пост %d", event.Username, event.PostID))
+		span.SetAttributes(attribute.Bool("like.duplicate", true))
+		return nil // Уже лайкнуто
 	}
 
-	// Добавляем лайк и обновляем в репозитории
-	post.Likes = append(post.Likes, event.Username)
-	if err := s.postRepo.Update(post); err != nil {
+	// Добавляем лайк в копию и заменяем ею пост в репозитории
+	post = clonePost(post)
+	post.LikeIDs = append(post.LikeIDs, user.ID)
+	if err := s.postRepo.Update(ctx, post); err != nil {
 		s.logger.Error(fmt.Sprintf("Ошибка при обновлении поста после лайка: %v", err))
-		return err
+		return fail(span, err)
 	}
+	s.recordActivity(post, trending.LikeWeight)
+	s.publish(ctx, pubsub.EventPostLiked, s.viewPost(ctx, post), models.PostLiked{
+		PostID:   post.ID,
+		Username: event.Username,
+		Likes:    len(post.LikeIDs),
+	})
+	s.notify(ctx, models.NotificationEvent{
+		Type:        models.NotificationLike,
+		RecipientID: post.AuthorID,
+		ActorID:     user.ID,
+		PostID:      post.ID,
+	})
 
 	s.logger.Info(fmt.Sprintf("Лайк от %s к посту %d успешно обработан", event.Username, event.PostID))
 	return nil
 }
+
+// EditPost меняет текст поста. Править может только автор; каждая правка
+// сохраняется в истории как новая версия.
+func (s *MicroBlogService) EditPost(ctx context.Context, postID int, username, content string) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "EditPost", trace.WithAttributes(
+		attribute.Int("post.id", postID),
+		attribute.String("user.name", username),
+	))
+	defer span.End()
+
+	content, err := s.validator.PostContent(content)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Недопустимое содержимое поста %d: %v", postID, err))
+		return nil, fail(span, err)
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пользователь %s не найден: %v", username, err))
+		return nil, fail(span, ErrUserNotFound)
+	}
+
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пост с ID %d не найден: %v", postID, err))
+		return nil, fail(span, ErrPostNotFound)
+	}
+	if post.AuthorID != user.ID {
+		s.logger.Error(fmt.Sprintf("Пользователь %s пытается изменить чужой пост %d", username, postID))
+		return nil, fail(span, ErrForbidden)
+	}
+	if post.Kind == models.PostKindRepost {
+		return nil, fail(span, ErrNotEditable)
+	}
+	if post.Content == content {
+		return s.viewPost(ctx, post), nil // Текст не изменился - новая версия не нужна
+	}
+
+	history, err := s.revisionRepo.ListByPost(ctx, postID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка чтения истории поста %d: %v", postID, err))
+		return nil, fail(span, err)
+	}
+
+	now := s.clock.Now()
+	previousMentions := post.MentionIDs
+	post = clonePost(post)
+	post.Content = content
+	post.Hashtags, post.MentionIDs = s.extractEntities(ctx, content)
+	post.UpdatedAt = now
+	if err := s.postRepo.Update(ctx, post); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при обновлении поста %d: %v", postID, err))
+		return nil, fail(span, err)
+	}
+	s.indexEntities(ctx, post)
+
+	rev := &models.PostRevision{
+		PostID:    postID,
+		Version:   len(history) + 1,
+		Content:   content,
+		EditorID:  user.ID,
+		CreatedAt: now,
+	}
+	if err := s.revisionRepo.Add(ctx, rev); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при сохранении истории поста %d: %v", postID, err))
+		return nil, fail(span, err)
+	}
+	s.enqueueIndex(ctx, models.IndexEvent{
+		Op:       models.IndexOpUpsert,
+		PostID:   postID,
+		AuthorID: post.AuthorID,
+		Content:  content,
+		Revision: rev.Version,
+	})
+	s.notifyMentions(ctx, post, previousMentions)
+
+	span.SetAttributes(attribute.Int("revision.version", rev.Version))
+	s.logger.Info(fmt.Sprintf("Пост %d изменен пользователем %s (версия %d)", postID, username, rev.Version))
+	return s.viewPost(ctx, post), nil
+}
+
+// GetPostHistory возвращает все версии поста, начиная с исходной
+func (s *MicroBlogService) GetPostHistory(ctx context.Context, postID int) ([]*models.PostRevision, error) {
+	ctx, span := startSpan(ctx, "GetPostHistory", trace.WithAttributes(attribute.Int("post.id", postID)))
+	defer span.End()
+
+	if _, err := s.postRepo.GetByID(ctx, postID); err != nil {
+		return nil, fail(span, ErrPostNotFound)
+	}
+
+	history, err := s.revisionRepo.ListByPost(ctx, postID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка чтения истории поста %d: %v", postID, err))
+		return nil, fail(span, err)
+	}
+	return history, nil
+}
+
+// DeletePost удаляет пост. Удалить может только автор; вместе с постом
+// удаляются его репосты, а цитаты и ответы остаются без исходного поста.
+func (s *MicroBlogService) DeletePost(ctx context.Context, postID int, username string) error {
+	ctx, span := startSpan(ctx, "DeletePost", trace.WithAttributes(
+		attribute.Int("post.id", postID),
+		attribute.String("user.name", username),
+	))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пользователь %s не найден: %v", username, err))
+		return fail(span, ErrUserNotFound)
+	}
+
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пост с ID %d не найден: %v", postID, err))
+		return fail(span, ErrPostNotFound)
+	}
+	if post.AuthorID != user.ID {
+		s.logger.Error(fmt.Sprintf("Пользователь %s пытается удалить чужой пост %d", username, postID))
+		return fail(span, ErrForbidden)
+	}
+
+	deleted, err := s.removePost(ctx, post)
+	if err != nil {
+		return fail(span, err)
+	}
+
+	span.SetAttributes(attribute.Int("post.deleted_count", deleted))
+	s.logger.Info(fmt.Sprintf("Пост %d удален пользователем %s (вместе с репостами: %d)", postID, username, deleted-1))
+	return nil
+}
+
+// removePost удаляет пост вместе с его репостами и уменьшает счетчики
+// родителя и оригинала. Возвращает число удаленных постов. Вызывается под postMu.
+func (s *MicroBlogService) removePost(ctx context.Context, post *models.Post) (int, error) {
+	deleted := []*models.Post{post}
+	for _, p := range s.postRepo.List(ctx) {
+		if p.Kind == models.PostKindRepost && p.OriginalID == post.ID {
+			deleted = append(deleted, p)
+		}
+	}
+	for _, p := range deleted {
+		if err := s.postRepo.Delete(ctx, p.ID); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка при удалении поста %d: %v", p.ID, err))
+			return 0, err
+		}
+		s.forgetPost(ctx, p)
+		view := s.viewPost(ctx, p)
+		s.publish(ctx, pubsub.EventPostDeleted, view, models.PostDeleted{PostID: p.ID, Author: view.Author})
+	}
+
+	// Ответ уменьшает счетчик ответов родителя
+	if post.ReplyToID != 0 {
+		if parent, err := s.postRepo.GetByID(ctx, post.ReplyToID); err == nil && parent.ReplyCount > 0 {
+			parent = clonePost(parent)
+			parent.ReplyCount--
+			if err := s.postRepo.Update(ctx, parent); err != nil {
+				s.logger.Error(fmt.Sprintf("Ошибка при обновлении счетчика ответов поста %d: %v", parent.ID, err))
+			}
+		}
+	}
+
+	// Репост или цитата уменьшают счетчик оригинала
+	if post.OriginalID != 0 {
+		if original, err := s.postRepo.GetByID(ctx, post.OriginalID); err == nil {
+			original = clonePost(original)
+			switch post.Kind {
+			case models.PostKindRepost:
+				original.RepostCount--
+			case models.PostKindQuote:
+				original.QuoteCount--
+			}
+			if err := s.postRepo.Update(ctx, original); err != nil {
+				s.logger.Error(fmt.Sprintf("Ошибка при обновлении счетчиков поста %d: %v", original.ID, err))
+			}
+		}
+	}
+
+	return len(deleted), nil
+}
+
+// forgetPost убирает удаленный пост из индексов хэштегов, поиска и популярного
+func (s *MicroBlogService) forgetPost(ctx context.Context, post *models.Post) {
+	if err := s.entityRepo.Index(ctx, post.ID, nil, nil); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления поста %d из индекса хэштегов: %v", post.ID, err))
+	}
+	if post.Kind != models.PostKindRepost {
+		// Удаление окончательно: максимальная версия отбрасывает запоздавшие обновления
+		s.enqueueIndex(ctx, models.IndexEvent{Op: models.IndexOpDelete, PostID: post.ID, Revision: math.MaxInt})
+	}
+	s.trending.Forget(post.ID)
+	if err := s.revisionRepo.DeleteByPost(ctx, post.ID); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления истории поста %d: %v", post.ID, err))
+	}
+	s.notificationMu.Lock()
+	if err := s.notificationRepo.DeleteByPost(ctx, post.ID); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления уведомлений о посте %d: %v", post.ID, err))
+	}
+	s.notificationMu.Unlock()
+	if len(post.Attachments) > 0 {
+		s.mediaMu.Lock()
+		for i := range post.Attachments {
+			s.dropMedia(ctx, &post.Attachments[i])
+		}
+		s.mediaMu.Unlock()
+	}
+}
+
+// clonePost возвращает копию поста со своими срезами. Посты из репозитория
+// одновременно читают обработчики и подписчики, поэтому изменения вносятся
+// в копию, которая затем заменяет пост через Update.
+func clonePost(post *models.Post) *models.Post {
+	c := *post
+	c.LikeIDs = slices.Clone(post.LikeIDs)
+	c.Likes = slices.Clone(post.Likes)
+	c.Hashtags = slices.Clone(post.Hashtags)
+	c.MentionIDs = slices.Clone(post.MentionIDs)
+	c.Mentions = slices.Clone(post.Mentions)
+	c.Attachments = slices.Clone(post.Attachments)
+	return &c
+}
+
+// viewPost возвращает копию поста для выдачи с текущими именами автора,
+// лайкнувших, упомянутых и владельцев вложений. nil остается nil.
+func (s *MicroBlogService) viewPost(ctx context.Context, post *models.Post) *models.Post {
+	if post == nil {
+		return nil
+	}
+	v := clonePost(post)
+	v.Author = s.username(ctx, post.AuthorID)
+	v.Likes = s.usernames(ctx, post.LikeIDs)
+	v.Mentions = s.usernames(ctx, post.MentionIDs)
+	for i := range v.Attachments {
+		v.Attachments[i].Owner = s.username(ctx, v.Attachments[i].OwnerID)
+	}
+	return v
+}
+
+// viewPosts применяет viewPost к каждому посту
+func (s *MicroBlogService) viewPosts(ctx context.Context, posts []*models.Post) []*models.Post {
+	views := make([]*models.Post, len(posts))
+	for i, p := range posts {
+		views[i] = s.viewPost(ctx, p)
+	}
+	return views
+}
+
+// notifyMentions уведомляет упомянутых в посте пользователей, кроме
+// упомянутых в предыдущей версии текста (known)
+func (s *MicroBlogService) notifyMentions(ctx context.Context, post *models.Post, known []int) {
+	for _, id := range post.MentionIDs {
+		if slices.Contains(known, id) {
+			continue
+		}
+		s.notify(ctx, models.NotificationEvent{
+			Type:        models.NotificationMention,
+			RecipientID: id,
+			ActorID:     post.AuthorID,
+			PostID:      post.ID,
+		})
+	}
+}
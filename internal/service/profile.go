@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/blob"
+	"github.com/Cere6rum/MicroBlog2/internal/imaging"
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// AvatarSizes - стороны квадратных копий аватара в пикселях, по возрастанию
+var AvatarSizes = []int{48, 128, 400}
+
+// ProfileUpdate - изменение профиля; nil - поле не меняется, пустая
+// строка очищает поле
+type ProfileUpdate = validation.ProfileFields
+
+// WithBlobStore задает хранилище аватаров и вложений. Без него загрузка
+// файлов отключена.
+func WithBlobStore(store blob.Store) Option {
+	return func(s *MicroBlogService) {
+		s.blobs = store
+	}
+}
+
+// UpdateProfile меняет поля профиля пользователя username
+func (s *MicroBlogService) UpdateProfile(ctx context.Context, username string, update ProfileUpdate) (*models.User, error) {
+	ctx, span := startSpan(ctx, "UpdateProfile", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	fields, err := s.validator.Profile(update)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Недопустимые поля профиля %s: %v", username, err))
+		return nil, fail(span, err)
+	}
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	current, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	// Репозиторий отдает общий указатель: меняем копию, чтобы читатели
+	// не увидели профиль наполовину измененным
+	user := *current
+	for _, f := range []struct {
+		dst *string
+		src *string
+	}{
+		{&user.DisplayName, fields.DisplayName},
+		{&user.Bio, fields.Bio},
+		{&user.Location, fields.Location},
+		{&user.Website, fields.Website},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	user.UpdatedAt = s.clock.Now()
+	if err := s.userRepo.Update(ctx, &user); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при обновлении профиля %s: %v", username, err))
+		return nil, fail(span, err)
+	}
+
+	s.logger.Info(fmt.Sprintf("Пользователь %s обновил профиль", username))
+	return &user, nil
+}
+
+// SetAvatar загружает аватар пользователя username. Формат определяется по
+// содержимому; изображение обрезается до квадрата, уменьшается до каждого
+// размера из AvatarSizes и перекодируется. Прежний аватар удаляется.
+func (s *MicroBlogService) SetAvatar(ctx context.Context, username string, data []byte) (*models.User, error) {
+	ctx, span := startSpan(ctx, "SetAvatar", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.Int("avatar.bytes", len(data)),
+	))
+	defer span.End()
+
+	if s.blobs == nil {
+		return nil, fail(span, ErrUploadsDisabled)
+	}
+	if !s.userRepo.Exists(ctx, username) {
+		return nil, fail(span, ErrUserNotFound)
+	}
+
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Аватар %s отклонен: %v", username, err))
+		return nil, fail(span, err)
+	}
+	span.SetAttributes(attribute.String("avatar.format", format))
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	current, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	now := s.clock.Now()
+	avatar := &models.Avatar{Version: now.UnixNano(), Sizes: slices.Clone(AvatarSizes), UpdatedAt: now}
+	if current.Avatar != nil && avatar.Version <= current.Avatar.Version {
+		avatar.Version = current.Avatar.Version + 1
+	}
+
+	var stored []string
+	for _, size := range AvatarSizes {
+		encoded, contentType, err := imaging.Encode(imaging.Square(img, size), format)
+		if err == nil {
+			avatar.ContentType = contentType
+			key := avatarKey(current.ID, avatar, size)
+			if err = s.blobs.Put(ctx, key, contentType, encoded); err == nil {
+				stored = append(stored, key)
+				continue
+			}
+		}
+		s.logger.Error(fmt.Sprintf("Ошибка сохранения аватара %s (%d px): %v", username, size, err))
+		s.deleteBlobs(ctx, stored)
+		return nil, fail(span, err)
+	}
+
+	user := *current
+	user.Avatar = avatar
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, &user); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при обновлении пользователя %s: %v", username, err))
+		s.deleteBlobs(ctx, stored)
+		return nil, fail(span, err)
+	}
+	if current.Avatar != nil {
+		s.deleteBlobs(ctx, avatarKeys(current.ID, current.Avatar))
+	}
+
+	s.logger.Info(fmt.Sprintf("Пользователь %s загрузил аватар (%s, %d байт)", username, format, len(data)))
+	return &user, nil
+}
+
+// DeleteAvatar удаляет аватар пользователя username
+func (s *MicroBlogService) DeleteAvatar(ctx context.Context, username string) error {
+	ctx, span := startSpan(ctx, "DeleteAvatar", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	current, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return fail(span, ErrUserNotFound)
+	}
+	if current.Avatar == nil {
+		return fail(span, ErrAvatarNotFound)
+	}
+	user := *current
+	user.Avatar = nil
+	user.UpdatedAt = s.clock.Now()
+	if err := s.userRepo.Update(ctx, &user); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при обновлении пользователя %s: %v", username, err))
+		return fail(span, err)
+	}
+	if s.blobs != nil {
+		s.deleteBlobs(ctx, avatarKeys(current.ID, current.Avatar))
+	}
+
+	s.logger.Info(fmt.Sprintf("Пользователь %s удалил аватар", username))
+	return nil
+}
+
+// GetAvatar возвращает копию аватара размером не меньше size (наибольшую,
+// если такой нет; size 0 - наибольшую)
+func (s *MicroBlogService) GetAvatar(ctx context.Context, username string, size int) ([]byte, *models.Avatar, int, error) {
+	ctx, span := startSpan(ctx, "GetAvatar", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.Int("avatar.size", size),
+	))
+	defer span.End()
+
+	if s.blobs == nil {
+		return nil, nil, 0, fail(span, ErrUploadsDisabled)
+	}
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, nil, 0, fail(span, ErrUserNotFound)
+	}
+	avatar := user.Avatar
+	if avatar == nil || len(avatar.Sizes) == 0 {
+		return nil, nil, 0, fail(span, ErrAvatarNotFound)
+	}
+
+	chosen := avatar.Sizes[len(avatar.Sizes)-1]
+	if size > 0 {
+		if i := slices.IndexFunc(avatar.Sizes, func(v int) bool { return v >= size }); i >= 0 {
+			chosen = avatar.Sizes[i]
+		}
+	}
+	data, _, err := s.blobs.Get(ctx, avatarKey(user.ID, avatar, chosen))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Аватар %s (%d px) не прочитан: %v", username, chosen, err))
+		return nil, nil, 0, fail(span, ErrAvatarNotFound)
+	}
+	return data, avatar, chosen, nil
+}
+
+// avatarKey - ключ блоба с копией аватара размером size
+func avatarKey(userID int, a *models.Avatar, size int) string {
+	return fmt.Sprintf("avatars/%d/%d/%d%s", userID, a.Version, size, imaging.Extension(a.ContentType))
+}
+
+// avatarKeys - ключи всех копий аватара
+func avatarKeys(userID int, a *models.Avatar) []string {
+	keys := make([]string, 0, len(a.Sizes))
+	for _, size := range a.Sizes {
+		keys = append(keys, avatarKey(userID, a, size))
+	}
+	return keys
+}
+
+// deleteBlobs удаляет объекты, ошибки только пишутся в лог
+func (s *MicroBlogService) deleteBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка удаления %s из хранилища: %v", key, err))
+		}
+	}
+}
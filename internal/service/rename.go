@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+)
+
+// DefaultNameCooldown - сколько освобожденное при смене имя остается за
+// прежним владельцем: другие не могут его занять
+const DefaultNameCooldown = 30 * 24 * time.Hour
+
+// WithAliasRepo задает хранилище прежних имен пользователей
+func WithAliasRepo(r repository.AliasRepository) Option {
+	return func(s *MicroBlogService) {
+		s.aliasRepo = r
+	}
+}
+
+// WithNameCooldown задает карантин освобожденных имен; 0 - имя свободно сразу
+func WithNameCooldown(d time.Duration) Option {
+	return func(s *MicroBlogService) {
+		s.nameCooldown = d
+	}
+}
+
+// RenameUser меняет имя пользователя. Прежнее имя сохраняется как псевдоним:
+// запросы по нему перенаправляются на новое, а занять его другому
+// пользователю нельзя до конца карантина. Посты, лайки, вложения, вебхуки
+// и уведомления ссылаются на пользователя по ID и показывают новое имя
+// сразу. Тексты постов не меняются, но прежние упоминания засчитываются
+// новому имени.
+func (s *MicroBlogService) RenameUser(ctx context.Context, username, newName string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "RenameUser", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.String("user.new_name", newName),
+	))
+	defer span.End()
+
+	newName, err := s.validator.Username(newName)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Недопустимое новое имя для %s: %v", username, err))
+		return nil, fail(span, err)
+	}
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	current, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	if newName == current.Username {
+		return current, nil
+	}
+	// Смена регистра или похожих букв своего имени не конфликтует с самим собой
+	canonical := validation.CanonicalUsername(newName)
+	if s.userRepo.Exists(ctx, newName) || (canonical != current.CanonicalName && s.userRepo.ExistsCanonical(ctx, canonical)) {
+		s.logger.Error(fmt.Sprintf("Пользователь %s уже существует", newName))
+		return nil, fail(span, ErrUserExists)
+	}
+	if err := s.checkNameReserved(ctx, canonical, current.ID); err != nil {
+		return nil, fail(span, err)
+	}
+
+	now := s.clock.Now()
+	// Псевдоним записываем до переименования: лайк из очереди со старым
+	// именем либо застанет пользователя под ним, либо найдет псевдоним
+	alias := &models.UsernameAlias{
+		Username:      current.Username,
+		CanonicalName: current.CanonicalName,
+		UserID:        current.ID,
+		ReleasedAt:    now,
+	}
+	if err := s.aliasRepo.Put(ctx, alias); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка сохранения прежнего имени %s: %v", username, err))
+		return nil, fail(span, err)
+	}
+	user := *current
+	user.Username = newName
+	user.CanonicalName = canonical
+	user.UpdatedAt = now
+	if err := s.userRepo.Rename(ctx, current.Username, &user); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при переименовании %s: %v", username, err))
+		if err := s.aliasRepo.Delete(ctx, alias.CanonicalName); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка удаления прежнего имени %s: %v", username, err))
+		}
+		return nil, fail(span, err)
+	}
+	if canonical != current.CanonicalName {
+		// Имя снова занято: его псевдоним (свой прежний или истекший чужой) больше не нужен
+		if err := s.aliasRepo.Delete(ctx, canonical); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка удаления псевдонима %s: %v", newName, err))
+		}
+	}
+
+	span.SetAttributes(attribute.Int("user.id", user.ID))
+	s.logger.Info(fmt.Sprintf("Пользователь ID %d сменил имя %s на %s", user.ID, username, newName))
+	return &user, nil
+}
+
+// ResolveUsername возвращает текущее имя пользователя по имени или
+// прежнему имени. Псевдоним действует, пока имя не занял кто-то другой.
+func (s *MicroBlogService) ResolveUsername(ctx context.Context, name string) (string, error) {
+	ctx, span := startSpan(ctx, "ResolveUsername", trace.WithAttributes(attribute.String("user.name", name)))
+	defer span.End()
+
+	if s.userRepo.Exists(ctx, name) {
+		return name, nil
+	}
+	alias, err := s.aliasRepo.Get(ctx, validation.CanonicalUsername(name))
+	if err != nil {
+		return "", fail(span, ErrUserNotFound)
+	}
+	user, err := s.userRepo.GetByID(ctx, alias.UserID)
+	if err != nil {
+		return "", fail(span, ErrUserNotFound)
+	}
+	return user.Username, nil
+}
+
+// checkNameReserved отклоняет имя, освобожденное другим пользователем
+// меньше nameCooldown назад. userID 0 - регистрация нового пользователя.
+func (s *MicroBlogService) checkNameReserved(ctx context.Context, canonical string, userID int) error {
+	alias, err := s.aliasRepo.Get(ctx, canonical)
+	if err != nil || alias.UserID == userID {
+		return nil
+	}
+	if s.clock.Now().Before(alias.ReleasedAt.Add(s.nameCooldown)) {
+		s.logger.Error(fmt.Sprintf("Имя %s освобождено %s и еще на карантине", alias.Username, alias.ReleasedAt.Format(time.RFC3339)))
+		return ErrUsernameReserved
+	}
+	return nil
+}
+
+// username возвращает текущее имя пользователя id. Удаленный пользователь
+// (и ID 0 у обезличенных постов) показывается как models.AnonymousAuthor.
+func (s *MicroBlogService) username(ctx context.Context, id int) string {
+	if user, err := s.userRepo.GetByID(ctx, id); err == nil {
+		return user.Username
+	}
+	return models.AnonymousAuthor
+}
+
+// usernames возвращает текущие имена пользователей ids в том же порядке
+func (s *MicroBlogService) usernames(ctx context.Context, ids []int) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = s.username(ctx, id)
+	}
+	return names
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+)
+
+// Repost делает репост чужого поста. Репост репоста указывает на оригинал,
+// повторный репост того же поста запрещен.
+func (s *MicroBlogService) Repost(ctx context.Context, username string, postID int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "Repost", trace.WithAttributes(
+		attribute.Int("post.id", postID),
+		attribute.String("user.name", username),
+	))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пользователь %s не найден для репоста: %v", username, err))
+		return nil, fail(span, ErrUserNotFound)
+	}
+
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	original, err := s.resolveOriginal(ctx, postID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пост %d для репоста не найден: %v", postID, err))
+		return nil, fail(span, ErrOriginalNotFound)
+	}
+	if original.AuthorID == user.ID {
+		return nil, fail(span, ErrRepostOwnPost)
+	}
+	if _, err := s.postRepo.GetRepost(ctx, user.ID, original.ID); err == nil {
+		s.logger.Debug(fmt.Sprintf("Пользователь %s уже репостнул пост %d", username, original.ID))
+		return nil, fail(span, ErrAlreadyReposted)
+	}
+
+	now := s.clock.Now()
+	repost := &models.Post{
+		ID:         int(s.postIDCounter.Increment()),
+		Kind:       models.PostKindRepost,
+		AuthorID:   user.ID,
+		OriginalID: original.ID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.postRepo.Create(ctx, repost); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при создании репоста: %v", err))
+		return nil, fail(span, err)
+	}
+
+	original = clonePost(original)
+	original.RepostCount++
+	if err := s.postRepo.Update(ctx, original); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при обновлении счетчика репостов поста %d: %v", original.ID, err))
+	}
+
+	view := s.viewPost(ctx, repost)
+	s.publish(ctx, pubsub.EventPostCreated, view, &models.PostView{Post: view, Original: s.viewPost(ctx, original)})
+	span.SetAttributes(attribute.Int("post.repost_id", repost.ID))
+	s.logger.Info(fmt.Sprintf("Пользователь %s репостнул пост %d (репост %d)", username, original.ID, repost.ID))
+	return view, nil
+}
+
+// GetTimeline возвращает посты для ленты: у репостов и цитат встроен оригинал
+func (s *MicroBlogService) GetTimeline(ctx context.Context) ([]*models.PostView, error) {
+	ctx, span := startSpan(ctx, "GetTimeline")
+	defer span.End()
+
+	posts := s.postRepo.List(ctx)
+	views := make([]*models.PostView, 0, len(posts))
+	for _, p := range posts {
+		view := &models.PostView{Post: s.viewPost(ctx, p)}
+		if p.OriginalID != 0 {
+			if original, err := s.postRepo.GetByID(ctx, p.OriginalID); err == nil {
+				view.Original = s.viewPost(ctx, original)
+			}
+		}
+		views = append(views, view)
+	}
+
+	span.SetAttributes(attribute.Int("post.count", len(views)))
+	s.logger.Debug(fmt.Sprintf("Запрошена лента, количество: %d", len(views)))
+	return views, nil
+}
+
+// resolveOriginal возвращает пост по ID; для репоста - пост, который репостнули
+func (s *MicroBlogService) resolveOriginal(ctx context.Context, postID int) (*models.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Kind == models.PostKindRepost && post.OriginalID != 0 {
+		return s.postRepo.GetByID(ctx, post.OriginalID)
+	}
+	return post, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// WithLastIDs продолжает счетчики ID после данных, загруженных в репозитории
+// заранее (например, из архива dump): новые пользователи и посты получат ID
+// больше lastUserID и lastPostID
+func WithLastIDs(lastUserID, lastPostID int) Option {
+	return func(s *MicroBlogService) {
+		s.userIDCounter.Set(int64(max(int(s.userIDCounter.Get()), lastUserID)))
+		s.postIDCounter.Set(int64(max(int(s.postIDCounter.Get()), lastPostID)))
+	}
+}
+
+// LastIDs возвращает наибольшие выданные ID пользователя и поста,
+// включая ID удаленных постов
+func (s *MicroBlogService) LastIDs() (lastUserID, lastPostID int) {
+	return int(s.userIDCounter.Get()), int(s.postIDCounter.Get())
+}
+
+// Reindex заново строит хэштеги, упоминания, поисковый индекс и историю правок
+// по постам из репозитория. Нужен после загрузки данных в репозитории в обход
+// сервиса. У поста без истории исходной версией становится его текущий текст.
+func (s *MicroBlogService) Reindex(ctx context.Context) (int, error) {
+	ctx, span := startSpan(ctx, "Reindex")
+	defer span.End()
+
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	posts := s.postRepo.List(ctx)
+	for _, post := range posts {
+		if err := ctx.Err(); err != nil {
+			return 0, fail(span, err)
+		}
+		if post.Kind == models.PostKindRepost {
+			continue
+		}
+
+		// Хэштеги и упоминания извлекаются заново: в архиве их может не быть
+		post = clonePost(post)
+		post.Hashtags, post.MentionIDs = s.extractEntities(ctx, post.Content)
+		if err := s.postRepo.Update(ctx, post); err != nil {
+			return 0, fail(span, fmt.Errorf("пост %d: %w", post.ID, err))
+		}
+		s.indexEntities(ctx, post)
+
+		history, err := s.revisionRepo.ListByPost(ctx, post.ID)
+		if err != nil {
+			return 0, fail(span, fmt.Errorf("история поста %d: %w", post.ID, err))
+		}
+		if len(history) == 0 {
+			rev := &models.PostRevision{
+				PostID:    post.ID,
+				Version:   1,
+				Content:   post.Content,
+				EditorID:  post.AuthorID,
+				CreatedAt: post.UpdatedAt,
+			}
+			if err := s.revisionRepo.Add(ctx, rev); err != nil {
+				return 0, fail(span, fmt.Errorf("история поста %d: %w", post.ID, err))
+			}
+			history = append(history, rev)
+		}
+		s.enqueueIndex(ctx, models.IndexEvent{
+			Op:       models.IndexOpUpsert,
+			PostID:   post.ID,
+			AuthorID: post.AuthorID,
+			Content:  post.Content,
+			Revision: len(history),
+		})
+	}
+
+	span.SetAttributes(attribute.Int("post.count", len(posts)))
+	s.logger.Info(fmt.Sprintf("Индексы перестроены, постов: %d", len(posts)))
+	return len(posts), nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/search"
+	"github.com/Cere6rum/MicroBlog2/internal/tracing"
+)
+
+// Ограничения выдачи поиска
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// Search ищет посты по тексту запроса (слова и "фразы в кавычках"),
+// при непустом author - только среди постов этого автора. Индекс обновляется
+// асинхронно, поэтому только что созданный пост может найтись не сразу.
+func (s *MicroBlogService) Search(ctx context.Context, text, author string, limit, offset int) (*models.SearchResult, error) {
+	ctx, span := startSpan(ctx, "Search", trace.WithAttributes(
+		attribute.String("search.query", text),
+		attribute.String("search.author", author),
+	))
+	defer span.End()
+
+	q := search.ParseQuery(text)
+	if q.Empty() {
+		return nil, fail(span, ErrEmptyQuery)
+	}
+	if author != "" {
+		// Индекс хранит ID автора: посты находятся и после смены имени
+		user, err := s.userRepo.GetByUsername(ctx, author)
+		if err != nil {
+			return nil, fail(span, ErrUserNotFound)
+		}
+		q.AuthorID = user.ID
+	}
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+	offset = max(offset, 0)
+
+	found := s.searchIndex.Search(q, limit, offset)
+	result := &models.SearchResult{
+		Total:  found.Total,
+		Limit:  limit,
+		Offset: offset,
+		Hits:   make([]models.SearchHit, 0, len(found.Hits)),
+	}
+	for _, h := range found.Hits {
+		post, err := s.postRepo.GetByID(ctx, h.PostID)
+		if err != nil {
+			continue // пост исчез, а индекс еще не обновился
+		}
+		result.Hits = append(result.Hits, models.SearchHit{Post: s.viewPost(ctx, post), Score: h.Score})
+	}
+
+	span.SetAttributes(attribute.Int("search.total", result.Total))
+	s.logger.Debug(fmt.Sprintf("Поиск %q: найдено %d", text, result.Total))
+	return result, nil
+}
+
+// ProcessIndexEvent применяет изменение поста к поисковому индексу (вызывается из очереди)
+func (s *MicroBlogService) ProcessIndexEvent(event models.IndexEvent) error {
+	origin := trace.SpanContextFromContext(tracing.Extract(context.Background(), event.TraceContext))
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int("post.id", event.PostID),
+			attribute.String("index.op", event.Op),
+			attribute.Int("revision.version", event.Revision),
+		),
+	}
+	if origin.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: origin}))
+	}
+	_, span := startSpan(context.Background(), "ProcessIndexEvent", opts...)
+	defer span.End()
+
+	var applied bool
+	switch event.Op {
+	case models.IndexOpUpsert:
+		applied = s.searchIndex.Upsert(event.PostID, event.AuthorID, event.Content, event.Revision)
+	case models.IndexOpDelete:
+		applied = s.searchIndex.Delete(event.PostID, event.Revision)
+	default:
+		return fail(span, fmt.Errorf("неизвестная операция индексации %q", event.Op))
+	}
+	if !applied {
+		span.SetAttributes(attribute.Bool("index.stale", true))
+		s.logger.Debug(fmt.Sprintf("Устаревшее событие индексации поста %d (версия %d) пропущено", event.PostID, event.Revision))
+	}
+	return nil
+}
+
+// enqueueIndex отправляет изменение поста на индексацию. Без очереди
+// (например, в тестах) индекс обновляется сразу.
+func (s *MicroBlogService) enqueueIndex(ctx context.Context, event models.IndexEvent) {
+	event.TraceContext = tracing.Inject(ctx)
+	if s.indexQueue == nil {
+		if err := s.ProcessIndexEvent(event); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка индексации поста %d: %v", event.PostID, err))
+		}
+		return
+	}
+	if !s.indexQueue.Enqueue(event) {
+		s.logger.Error(fmt.Sprintf("Очередь индексации остановлена, пост %d не проиндексирован", event.PostID))
+	}
+}
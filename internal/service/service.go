@@ -1,37 +1,203 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/blob"
 	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
 	"github.com/Cere6rum/MicroBlog2/internal/queue"
 	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/search"
 	"github.com/Cere6rum/MicroBlog2/internal/syncutils"
+	"github.com/Cere6rum/MicroBlog2/internal/trending"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+	"github.com/Cere6rum/MicroBlog2/internal/webhook"
 )
 
+var tracer = otel.Tracer("github.com/Cere6rum/MicroBlog2/internal/service")
+
 // MicroBlogService - основной сервис микроблога
 type MicroBlogService struct {
-	userRepo      repository.UserRepository
-	postRepo      repository.PostRepository
-	userIDCounter *syncutils.AtomicCounter
-	postIDCounter *syncutils.AtomicCounter
-	likeQueue     *queue.LikeQueue
-	logger        *logger.Logger
+	userRepo          repository.UserRepository
+	postRepo          repository.PostRepository
+	revisionRepo      repository.RevisionRepository
+	entityRepo        repository.EntityRepository
+	webhookRepo       repository.WebhookRepository
+	notificationRepo  repository.NotificationRepository
+	mediaRepo         repository.MediaRepository
+	auditRepo         repository.AuditRepository
+	aliasRepo         repository.AliasRepository
+	followRepo        repository.FollowRepository
+	userIDCounter     *syncutils.AtomicCounter
+	postIDCounter     *syncutils.AtomicCounter
+	likeQueue         *queue.LikeQueue
+	indexQueue        *queue.IndexQueue // nil - индекс обновляется синхронно
+	searchIndex       *search.Index
+	trending          *trending.Tracker
+	validator         *validation.Policy
+	events            *pubsub.Hub              // nil - события не публикуются
+	webhookQueue      *queue.WebhookQueue      // nil - вебхуки отключены
+	notificationQueue *queue.NotificationQueue // nil - уведомления создаются синхронно
+	webhookSender     *webhook.Sender
+	logger            *logger.Logger
+	clock             Clock
+
+	// postMu сериализует изменения постов (лайки, правки): репозиторий
+	// отдает общий указатель, и read-modify-write без блокировки теряет обновления
+	postMu sync.Mutex
+
+	// webhookMu сериализует изменения вебхуков (счетчик ошибок, отключение)
+	webhookMu         sync.Mutex
+	webhookIDCounter  *syncutils.AtomicCounter
+	deliveryIDCounter *syncutils.AtomicCounter
+
+	// notificationMu сериализует создание и склейку уведомлений
+	notificationMu        sync.Mutex
+	notificationIDCounter *syncutils.AtomicCounter
+
+	// userMu сериализует регистрацию, смену имени и изменения профилей
+	userMu       sync.Mutex
+	blobs        blob.Store    // nil - загрузка файлов отключена
+	nameCooldown time.Duration // карантин имени после смены
+
+	// mediaMu сериализует загрузку и прикрепление изображений
+	mediaMu        sync.Mutex
+	mediaIDCounter *syncutils.AtomicCounter
+	// pendingMediaTTL - срок хранения неприкрепленных изображений (0 - бессрочно)
+	pendingMediaTTL time.Duration
+
+	auditIDCounter *syncutils.AtomicCounter
+}
+
+// Option - необязательная зависимость сервиса
+type Option func(*MicroBlogService)
+
+// WithClock подменяет источник времени (для тестов)
+func WithClock(c Clock) Option {
+	return func(s *MicroBlogService) {
+		s.clock = c
+	}
+}
+
+// WithRevisionRepo задает хранилище истории правок
+func WithRevisionRepo(r repository.RevisionRepository) Option {
+	return func(s *MicroBlogService) {
+		s.revisionRepo = r
+	}
+}
+
+// WithEntityRepo задает хранилище индексов хэштегов и упоминаний
+func WithEntityRepo(r repository.EntityRepository) Option {
+	return func(s *MicroBlogService) {
+		s.entityRepo = r
+	}
+}
+
+// WithIndexQueue включает асинхронное обновление поискового индекса через очередь.
+// Воркеры очереди запускаются снаружи с обработчиком ProcessIndexEvent.
+func WithIndexQueue(q *queue.IndexQueue) Option {
+	return func(s *MicroBlogService) {
+		s.indexQueue = q
+	}
+}
+
+// WithTrending задает трекер популярности постов и хэштегов
+func WithTrending(t *trending.Tracker) Option {
+	return func(s *MicroBlogService) {
+		s.trending = t
+	}
+}
+
+// WithValidation задает правила проверки имен пользователей и текста постов
+func WithValidation(p *validation.Policy) Option {
+	return func(s *MicroBlogService) {
+		s.validator = p
+	}
 }
 
 // NewMicroBlogService создает новый экземпляр сервиса (обратная совместимость)
-func NewMicroBlogService(log *logger.Logger, likeQueue *queue.LikeQueue) *MicroBlogService {
+func NewMicroBlogService(log *logger.Logger, likeQueue *queue.LikeQueue, opts ...Option) *MicroBlogService {
 	ur := repository.NewInMemoryUserRepo()
 	pr := repository.NewInMemoryPostRepo()
-	return NewMicroBlogServiceWithRepos(log, likeQueue, ur, pr)
+	return NewMicroBlogServiceWithRepos(log, likeQueue, ur, pr, opts...)
 }
 
 // NewMicroBlogServiceWithRepos создаёт сервис с подставными репозиториями (удобно для тестов)
-func NewMicroBlogServiceWithRepos(log *logger.Logger, likeQueue *queue.LikeQueue, ur repository.UserRepository, pr repository.PostRepository) *MicroBlogService {
-	return &MicroBlogService{
-		userRepo:      ur,
-		postRepo:      pr,
-		userIDCounter: syncutils.NewAtomicCounter(0),
-		postIDCounter: syncutils.NewAtomicCounter(0),
-		likeQueue:     likeQueue,
-		logger:        log,
+func NewMicroBlogServiceWithRepos(log *logger.Logger, likeQueue *queue.LikeQueue, ur repository.UserRepository, pr repository.PostRepository, opts ...Option) *MicroBlogService {
+	s := &MicroBlogService{
+		userRepo:         ur,
+		postRepo:         pr,
+		revisionRepo:     repository.NewInMemoryRevisionRepo(),
+		entityRepo:       repository.NewInMemoryEntityRepo(),
+		webhookRepo:      repository.NewInMemoryWebhookRepo(),
+		notificationRepo: repository.NewInMemoryNotificationRepo(),
+		mediaRepo:        repository.NewInMemoryMediaRepo(),
+		auditRepo:        repository.NewInMemoryAuditRepo(),
+		aliasRepo:        repository.NewInMemoryAliasRepo(),
+		followRepo:       repository.NewInMemoryFollowRepo(),
+		userIDCounter:    syncutils.NewAtomicCounter(0),
+		postIDCounter:    syncutils.NewAtomicCounter(0),
+		likeQueue:        likeQueue,
+		searchIndex:      search.NewIndex(),
+		trending:         mustDefaultTracker(),
+		validator:        validation.DefaultPolicy(),
+		logger:           log,
+		clock:            SystemClock{},
+		nameCooldown:     DefaultNameCooldown,
+		pendingMediaTTL:  DefaultPendingMediaTTL,
+
+		webhookIDCounter:  syncutils.NewAtomicCounter(0),
+		deliveryIDCounter: syncutils.NewAtomicCounter(0),
+
+		notificationIDCounter: syncutils.NewAtomicCounter(0),
+
+		mediaIDCounter: syncutils.NewAtomicCounter(0),
+		auditIDCounter: syncutils.NewAtomicCounter(0),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.userRepo = repository.NewTracedUserRepo(s.userRepo)
+	s.postRepo = repository.NewTracedPostRepo(s.postRepo)
+	s.revisionRepo = repository.NewTracedRevisionRepo(s.revisionRepo)
+	s.entityRepo = repository.NewTracedEntityRepo(s.entityRepo)
+	s.webhookRepo = repository.NewTracedWebhookRepo(s.webhookRepo)
+	s.notificationRepo = repository.NewTracedNotificationRepo(s.notificationRepo)
+	s.mediaRepo = repository.NewTracedMediaRepo(s.mediaRepo)
+	s.auditRepo = repository.NewTracedAuditRepo(s.auditRepo)
+	s.aliasRepo = repository.NewTracedAliasRepo(s.aliasRepo)
+	s.followRepo = repository.NewTracedFollowRepo(s.followRepo)
+	return s
+}
+
+// Ping проверяет доступность репозиториев
+func (s *MicroBlogService) Ping(ctx context.Context) error {
+	if err := s.userRepo.Ping(ctx); err != nil {
+		return fmt.Errorf("хранилище пользователей недоступно: %w", err)
+	}
+	if err := s.postRepo.Ping(ctx); err != nil {
+		return fmt.Errorf("хранилище постов недоступно: %w", err)
+	}
+	return nil
+}
+
+// startSpan открывает спан метода сервиса
+func startSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "MicroBlogService."+name, opts...)
+}
+
+// fail помечает спан ошибкой и возвращает ее без изменений
+func fail(span trace.Span, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return err
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -25,7 +26,7 @@ func BenchmarkRegisterUser(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if _, err := service.RegisterUser(fmt.Sprintf("user%d", i)); err != nil {
+		if _, err := service.RegisterUser(context.Background(), fmt.Sprintf("user%d", i)); err != nil {
 			b.Fatalf("Ошибка регистрации пользователя: %v", err)
 		}
 	}
@@ -47,13 +48,13 @@ func BenchmarkCreatePost(b *testing.B) {
 	service := NewMicroBlogService(log, likeQueue)
 
 	// Регистрируем одного пользователя
-	if _, err := service.RegisterUser("benchuser"); err != nil {
+	if _, err := service.RegisterUser(context.Background(), "benchuser"); err != nil {
 		b.Fatalf("Ошибка регистрации пользователя: %v", err)
 	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if _, err := service.CreatePost("benchuser", fmt.Sprintf("Пост номер %d", i)); err != nil {
+		if _, err := service.CreatePost(context.Background(), "benchuser", fmt.Sprintf("Пост номер %d", i)); err != nil {
 			b.Fatalf("Ошибка создания поста: %v", err)
 		}
 	}
@@ -75,18 +76,18 @@ func BenchmarkGetAllPosts(b *testing.B) {
 	service := NewMicroBlogService(log, likeQueue)
 
 	// Создаем 100 постов
-	if _, err := service.RegisterUser("benchuser"); err != nil {
+	if _, err := service.RegisterUser(context.Background(), "benchuser"); err != nil {
 		b.Fatalf("Ошибка регистрации пользователя: %v", err)
 	}
 	for i := 0; i < 100; i++ {
-		if _, err := service.CreatePost("benchuser", fmt.Sprintf("Пост %d", i)); err != nil {
+		if _, err := service.CreatePost(context.Background(), "benchuser", fmt.Sprintf("Пост %d", i)); err != nil {
 			b.Fatalf("Ошибка создания поста %d: %v", i, err)
 		}
 	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if _, err := service.GetAllPosts(); err != nil {
+		if _, err := service.GetAllPosts(context.Background()); err != nil {
 			b.Fatalf("Ошибка получения постов: %v", err)
 		}
 	}
@@ -110,13 +111,13 @@ func BenchmarkLikePost(b *testing.B) {
 	defer likeQueue.Stop()
 
 	// Создаем пользователя и пост
-	if _, err := service.RegisterUser("author"); err != nil {
+	if _, err := service.RegisterUser(context.Background(), "author"); err != nil {
 		b.Fatalf("Ошибка регистрации пользователя author: %v", err)
 	}
-	if _, err := service.RegisterUser("liker"); err != nil {
+	if _, err := service.RegisterUser(context.Background(), "liker"); err != nil {
 		b.Fatalf("Ошибка регистрации пользователя liker: %v", err)
 	}
-	post, err := service.CreatePost("author", "Бенчмарк пост")
+	post, err := service.CreatePost(context.Background(), "author", "Бенчмарк пост")
 	if err != nil {
 		b.Fatalf("Ошибка создания поста: %v", err)
 	}
@@ -124,7 +125,7 @@ func BenchmarkLikePost(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if err := service.LikePost(postID, "liker"); err != nil {
+		if err := service.LikePost(context.Background(), postID, "liker"); err != nil {
 			b.Fatalf("Ошибка лайка поста: %v", err)
 		}
 	}
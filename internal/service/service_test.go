@@ -1,10 +1,33 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/Cere6rum/MicroBlog2/internal/blob"
 	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/models"
 	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+	"github.com/Cere6rum/MicroBlog2/internal/webhook"
 )
 
 // TestRegisterUser тестирует регистрацию пользователя
@@ -27,7 +50,7 @@ func TestRegisterUser(t *testing.T) {
 	service := NewMicroBlogService(log, likeQueue)
 
 	// Тест 1: успешная регистрация
-	user, err := service.RegisterUser("testuser")
+	user, err := service.RegisterUser(context.Background(), "testuser")
 	if err != nil {
 		t.Errorf("Ожидали успешную регистрацию, получили ошибку: %v", err)
 	}
@@ -39,13 +62,13 @@ func TestRegisterUser(t *testing.T) {
 	}
 
 	// Тест 2: повторная регистрация того же пользователя
-	_, err = service.RegisterUser("testuser")
+	_, err = service.RegisterUser(context.Background(), "testuser")
 	if err == nil {
 		t.Error("Ожидали ошибку при повторной регистрации")
 	}
 
 	// Тест 3: регистрация с пустым именем
-	_, err = service.RegisterUser("")
+	_, err = service.RegisterUser(context.Background(), "")
 	if err == nil {
 		t.Error("Ожидали ошибку при регистрации с пустым именем")
 	}
@@ -63,7 +86,7 @@ func TestCreatePost(t *testing.T) {
 	service := NewMicroBlogService(log, likeQueue)
 
 	// Регистрируем пользователя
-	user, err := service.RegisterUser("author")
+	user, err := service.RegisterUser(context.Background(), "author")
 	if err != nil {
 		t.Fatalf("Ошибка регистрации пользователя: %v", err)
 	}
@@ -72,7 +95,7 @@ func TestCreatePost(t *testing.T) {
 	}
 
 	// Тест 1: создание поста
-	post, err := service.CreatePost("author", "Мой первый пост")
+	post, err := service.CreatePost(context.Background(), "author", "Мой первый пост")
 	if err != nil {
 		t.Errorf("Ошибка создания поста: %v", err)
 	}
@@ -84,13 +107,13 @@ func TestCreatePost(t *testing.T) {
 	}
 
 	// Тест 2: создание поста несуществующим пользователем
-	_, err = service.CreatePost("nonexistent", "Тест")
+	_, err = service.CreatePost(context.Background(), "nonexistent", "Тест")
 	if err == nil {
 		t.Error("Ожидали ошибку при создании поста несуществующим пользователем")
 	}
 
 	// Тест 3: создание поста с пустым содержимым
-	_, err = service.CreatePost("author", "")
+	_, err = service.CreatePost(context.Background(), "author", "")
 	if err == nil {
 		t.Error("Ожидали ошибку при создании поста с пустым содержимым")
 	}
@@ -108,7 +131,7 @@ func TestGetAllPosts(t *testing.T) {
 	service := NewMicroBlogService(log, likeQueue)
 
 	// Регистрируем пользователя и создаем посты
-	user, err := service.RegisterUser("user1")
+	user, err := service.RegisterUser(context.Background(), "user1")
 	if err != nil {
 		t.Fatalf("Ошибка регистрации пользователя: %v", err)
 	}
@@ -116,7 +139,7 @@ func TestGetAllPosts(t *testing.T) {
 		t.Fatalf("Ожидали пользователя после регистрации, получили nil")
 	}
 
-	post1, err := service.CreatePost("user1", "Пост 1")
+	post1, err := service.CreatePost(context.Background(), "user1", "Пост 1")
 	if err != nil {
 		t.Fatalf("Ошибка создания первого поста: %v", err)
 	}
@@ -124,7 +147,7 @@ func TestGetAllPosts(t *testing.T) {
 		t.Fatalf("Ожидали первый пост, получили nil")
 	}
 
-	post2, err := service.CreatePost("user1", "Пост 2")
+	post2, err := service.CreatePost(context.Background(), "user1", "Пост 2")
 	if err != nil {
 		t.Fatalf("Ошибка создания второго поста: %v", err)
 	}
@@ -133,7 +156,7 @@ func TestGetAllPosts(t *testing.T) {
 	}
 
 	// Получаем все посты
-	posts, err := service.GetAllPosts()
+	posts, err := service.GetAllPosts(context.Background())
 	if err != nil {
 		t.Fatalf("Ожидали успешное получение постов, получили ошибку: %v", err)
 	}
@@ -158,7 +181,7 @@ func TestLikePost(t *testing.T) {
 	defer likeQueue.Stop()
 
 	// Регистрируем пользователей и создаем пост
-	user1, err := service.RegisterUser("author")
+	user1, err := service.RegisterUser(context.Background(), "author")
 	if err != nil {
 		t.Fatalf("Ошибка регистрации пользователя author: %v", err)
 	}
@@ -166,7 +189,7 @@ func TestLikePost(t *testing.T) {
 		t.Fatal("Ожидали пользователя author после регистрации, получили nil")
 	}
 
-	user2, err := service.RegisterUser("liker")
+	user2, err := service.RegisterUser(context.Background(), "liker")
 	if err != nil {
 		t.Fatalf("Ошибка регистрации пользователя liker: %v", err)
 	}
@@ -174,7 +197,7 @@ func TestLikePost(t *testing.T) {
 		t.Fatal("Ожидали пользователя liker после регистрации, получили nil")
 	}
 
-	post, err := service.CreatePost("author", "Тестовый пост")
+	post, err := service.CreatePost(context.Background(), "author", "Тестовый пост")
 	if err != nil {
 		t.Fatalf("Ошибка создания поста: %v", err)
 	}
@@ -184,20 +207,1519 @@ func TestLikePost(t *testing.T) {
 	postID := post.ID
 
 	// Тест 1: успешный лайк
-	err = service.LikePost(postID, "liker")
+	err = service.LikePost(context.Background(), postID, "liker")
 	if err != nil {
 		t.Errorf("Ошибка при лайке поста: %v", err)
 	}
 
 	// Тест 2: лайк несуществующего поста
-	err = service.LikePost(999, "liker")
+	err = service.LikePost(context.Background(), 999, "liker")
 	if err == nil {
 		t.Error("Ожидали ошибку при лайке несуществующего поста")
 	}
 
 	// Тест 3: лайк несуществующим пользователем
-	err = service.LikePost(postID, "nonexistent")
+	err = service.LikePost(context.Background(), postID, "nonexistent")
 	if err == nil {
 		t.Error("Ожидали ошибку при лайке несуществующим пользователем")
 	}
 }
+
+var (
+	testTracingOnce    sync.Once
+	testSpanExporter   *tracetest.InMemoryExporter
+	testTracerProvider *sdktrace.TracerProvider
+)
+
+// TestLikeEventTraceLink проверяет, что спан асинхронной обработки лайка
+// ссылается на спан запроса, поставившего лайк в очередь
+func TestLikeEventTraceLink(t *testing.T) {
+	// Глобальный провайдер можно подменить только один раз за процесс
+	testTracingOnce.Do(func() {
+		testSpanExporter = tracetest.NewInMemoryExporter()
+		testTracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(testSpanExporter))
+		otel.SetTracerProvider(testTracerProvider)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	exporter, tp := testSpanExporter, testTracerProvider
+	exporter.Reset()
+
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	likeQueue := queue.NewLikeQueue(10, 1)
+	service := NewMicroBlogService(log, likeQueue)
+	likeQueue.Start(service.ProcessLikeEvent)
+	defer likeQueue.Stop()
+
+	ctx := context.Background()
+	if _, err := service.RegisterUser(ctx, "author"); err != nil {
+		t.Fatalf("Ошибка регистрации пользователя: %v", err)
+	}
+	post, err := service.CreatePost(ctx, "author", "Пост для трассировки")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+
+	reqCtx, reqSpan := tp.Tracer("test").Start(ctx, "HTTP POST /posts/1/like")
+	if err := service.LikePost(reqCtx, post.ID, "author"); err != nil {
+		t.Fatalf("Ошибка при лайке поста: %v", err)
+	}
+	reqSpan.End()
+
+	// Ждем, пока воркер обработает событие
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, s := range exporter.GetSpans() {
+			if s.Name != "MicroBlogService.ProcessLikeEvent" {
+				continue
+			}
+			if len(s.Links) != 1 || s.Links[0].SpanContext.TraceID() != reqSpan.SpanContext().TraceID() {
+				t.Fatalf("Ожидали ссылку на трейс запроса %s, получили %+v", reqSpan.SpanContext().TraceID(), s.Links)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Спан MicroBlogService.ProcessLikeEvent не найден")
+}
+
+// fixedClock - управляемые часы для тестов
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+// TestEditPostHistory тестирует временные метки и историю правок поста
+func TestEditPostHistory(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	clock := &fixedClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithClock(clock))
+	ctx := context.Background()
+
+	user, err := service.RegisterUser(ctx, "author")
+	if err != nil {
+		t.Fatalf("Ошибка регистрации пользователя: %v", err)
+	}
+	if !user.CreatedAt.Equal(clock.now) {
+		t.Errorf("Ожидали CreatedAt пользователя %v, получили %v", clock.now, user.CreatedAt)
+	}
+	if _, err := service.RegisterUser(ctx, "other"); err != nil {
+		t.Fatalf("Ошибка регистрации пользователя: %v", err)
+	}
+
+	post, err := service.CreatePost(ctx, "author", "Первая версия")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	created := clock.now
+
+	// Тест 1: правка автором обновляет UpdatedAt, но не CreatedAt
+	clock.now = clock.now.Add(time.Hour)
+	edited, err := service.EditPost(ctx, post.ID, "author", "Вторая версия")
+	if err != nil {
+		t.Fatalf("Ошибка правки поста: %v", err)
+	}
+	if !edited.CreatedAt.Equal(created) || !edited.UpdatedAt.Equal(clock.now) {
+		t.Errorf("Неверные метки времени: created=%v updated=%v", edited.CreatedAt, edited.UpdatedAt)
+	}
+
+	// Тест 2: чужой пост править нельзя
+	if _, err := service.EditPost(ctx, post.ID, "other", "Взлом"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Ожидали ErrForbidden, получили %v", err)
+	}
+
+	// Тест 3: пустое содержимое отклоняется
+	if _, err := service.EditPost(ctx, post.ID, "author", ""); !errors.Is(err, ErrEmptyContent) {
+		t.Errorf("Ожидали ErrEmptyContent, получили %v", err)
+	}
+
+	// Тест 4: история содержит исходную версию и правку
+	history, err := service.GetPostHistory(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("Ошибка получения истории: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Ожидали 2 версии, получили %d", len(history))
+	}
+	if history[0].Content != "Первая версия" || history[1].Content != "Вторая версия" || history[1].Version != 2 {
+		t.Errorf("Неверная история: %+v, %+v", history[0], history[1])
+	}
+
+	// Тест 5: история несуществующего поста
+	if _, err := service.GetPostHistory(ctx, 999); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Ожидали ErrPostNotFound, получили %v", err)
+	}
+}
+
+// TestConcurrentPostUpdates проверяет, что лайки и правки не меняют пост,
+// который в это время читают и сериализуют другие горутины (запускать с -race)
+func TestConcurrentPostUpdates(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+
+	names := []string{"author", "fan1", "fan2", "fan3", "fan4"}
+	for _, name := range names {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	post, err := service.CreatePost(ctx, "author", "Текст #go")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			posts, _ := service.GetAllPosts(ctx)
+			if _, err := json.Marshal(posts); err != nil {
+				t.Errorf("Ошибка сериализации: %v", err)
+				return
+			}
+		}
+	}()
+
+	var writers sync.WaitGroup
+	for _, name := range names[1:] {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			if err := service.ProcessLikeEvent(models.LikeEvent{PostID: post.ID, Username: name}); err != nil {
+				t.Errorf("Ошибка обработки лайка: %v", err)
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := service.EditPost(ctx, post.ID, "author", "Правка "+strconv.Itoa(i)); err != nil {
+			t.Fatalf("Ошибка правки: %v", err)
+		}
+	}
+	writers.Wait()
+	close(stop)
+	wg.Wait()
+
+	got, _ := service.postRepo.GetByID(ctx, post.ID)
+	if len(got.LikeIDs) != 4 || got.Content != "Правка 4" {
+		t.Errorf("Ожидали 4 лайка и последнюю правку, получили %d и %q", len(got.LikeIDs), got.Content)
+	}
+	if post.Content != "Текст #go" || len(post.Likes) != 0 {
+		t.Errorf("Возвращенный ранее пост не должен меняться: %+v", post)
+	}
+}
+
+// TestReplyThread тестирует ответы, счетчики и дерево обсуждения
+func TestReplyThread(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+
+	if _, err := service.RegisterUser(ctx, "user1"); err != nil {
+		t.Fatalf("Ошибка регистрации пользователя: %v", err)
+	}
+	root, err := service.CreatePost(ctx, "user1", "Корень")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+
+	// Тест 1: ответ на несуществующий пост
+	if _, err := service.CreatePost(ctx, "user1", "Ответ", ReplyTo(999)); !errors.Is(err, ErrParentNotFound) {
+		t.Errorf("Ожидали ErrParentNotFound, получили %v", err)
+	}
+
+	// Дерево: root -> a, b; a -> a1 -> a2
+	a, _ := service.CreatePost(ctx, "user1", "A", ReplyTo(root.ID))
+	if _, err := service.CreatePost(ctx, "user1", "B", ReplyTo(root.ID)); err != nil {
+		t.Fatalf("Ошибка создания ответа: %v", err)
+	}
+	a1, _ := service.CreatePost(ctx, "user1", "A1", ReplyTo(a.ID))
+	a2, err := service.CreatePost(ctx, "user1", "A2", ReplyTo(a1.ID))
+	if err != nil {
+		t.Fatalf("Ошибка создания ответа: %v", err)
+	}
+
+	// Тест 2: счетчик ответов (посты в репозитории заменяются копиями)
+	root, _ = service.postRepo.GetByID(ctx, root.ID)
+	a, _ = service.postRepo.GetByID(ctx, a.ID)
+	if root.ReplyCount != 2 || a.ReplyCount != 1 {
+		t.Errorf("Неверные счетчики ответов: root=%d a=%d", root.ReplyCount, a.ReplyCount)
+	}
+
+	// Тест 3: обсуждение, запрошенное по глубокому ответу, строится от корня
+	thread, err := service.GetThread(ctx, a2.ID, 2, 0, 0)
+	if err != nil {
+		t.Fatalf("Ошибка получения обсуждения: %v", err)
+	}
+	if thread.Root.Post.ID != root.ID || len(thread.Root.Replies) != 2 {
+		t.Fatalf("Ожидали корень %d с 2 ответами, получили %d с %d", root.ID, thread.Root.Post.ID, len(thread.Root.Replies))
+	}
+	a1Node := thread.Root.Replies[0].Replies[0]
+	if a1Node.Post.ID != a1.ID || len(a1Node.Replies) != 0 || a1Node.MoreReplies != 1 {
+		t.Errorf("Глубина 2 должна обрезать A2: %+v", a1Node)
+	}
+
+	// Тест 4: постраничная выдача ответов корня
+	thread, err = service.GetThread(ctx, root.ID, 0, 1, 1)
+	if err != nil {
+		t.Fatalf("Ошибка получения обсуждения: %v", err)
+	}
+	if len(thread.Root.Replies) != 1 || thread.Root.Replies[0].Post.Content != "B" || thread.Root.MoreReplies != 1 {
+		t.Errorf("Ожидали вторую страницу с ответом B, получили %+v", thread.Root)
+	}
+}
+
+// TestRepostAndQuote тестирует репосты, цитаты и ленту со встроенным оригиналом
+func TestRepostAndQuote(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+
+	for _, name := range []string{"user1", "user2", "user3"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	original, err := service.CreatePost(ctx, "user1", "Оригинал")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+
+	// Тест 1: свой пост репостнуть нельзя
+	if _, err := service.Repost(ctx, "user1", original.ID); !errors.Is(err, ErrRepostOwnPost) {
+		t.Errorf("Ожидали ErrRepostOwnPost, получили %v", err)
+	}
+
+	// Тест 2: репост и запрет повторного репоста
+	repost, err := service.Repost(ctx, "user2", original.ID)
+	if err != nil {
+		t.Fatalf("Ошибка репоста: %v", err)
+	}
+	if repost.Kind != models.PostKindRepost || repost.OriginalID != original.ID {
+		t.Errorf("Неверный репост: %+v", repost)
+	}
+	if _, err := service.Repost(ctx, "user2", original.ID); !errors.Is(err, ErrAlreadyReposted) {
+		t.Errorf("Ожидали ErrAlreadyReposted, получили %v", err)
+	}
+
+	// Тест 3: репост репоста указывает на оригинал
+	second, err := service.Repost(ctx, "user3", repost.ID)
+	if err != nil {
+		t.Fatalf("Ошибка репоста репоста: %v", err)
+	}
+	original, _ = service.postRepo.GetByID(ctx, original.ID)
+	if second.OriginalID != original.ID || original.RepostCount != 2 {
+		t.Errorf("Ожидали ссылку на %d и 2 репоста, получили %d и %d", original.ID, second.OriginalID, original.RepostCount)
+	}
+
+	// Тест 4: цитата несуществующего и существующего поста
+	if _, err := service.CreatePost(ctx, "user2", "Цитата", Quote(999)); !errors.Is(err, ErrOriginalNotFound) {
+		t.Errorf("Ожидали ErrOriginalNotFound, получили %v", err)
+	}
+	quote, err := service.CreatePost(ctx, "user2", "Цитата", Quote(original.ID))
+	if err != nil {
+		t.Fatalf("Ошибка создания цитаты: %v", err)
+	}
+	original, _ = service.postRepo.GetByID(ctx, original.ID)
+	if quote.Kind != models.PostKindQuote || original.QuoteCount != 1 {
+		t.Errorf("Неверная цитата %+v или счетчик цитат %d", quote, original.QuoteCount)
+	}
+
+	// Тест 5: репост нельзя редактировать
+	if _, err := service.EditPost(ctx, repost.ID, "user2", "Текст"); !errors.Is(err, ErrNotEditable) {
+		t.Errorf("Ожидали ErrNotEditable, получили %v", err)
+	}
+
+	// Тест 6: лента встраивает оригинал в репосты и цитаты
+	timeline, err := service.GetTimeline(ctx)
+	if err != nil {
+		t.Fatalf("Ошибка получения ленты: %v", err)
+	}
+	for _, view := range timeline {
+		if view.Kind != models.PostKindPost && (view.Original == nil || view.Original.ID != original.ID) {
+			t.Errorf("Пост %d должен встраивать оригинал %d", view.ID, original.ID)
+		}
+	}
+}
+
+// TestHashtagsAndMentions тестирует индексы хэштегов и упоминаний
+func TestHashtagsAndMentions(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+
+	for _, name := range []string{"автор", "Вася"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+
+	// Тест 1: упоминание несуществующего пользователя не мешает созданию поста
+	post, err := service.CreatePost(ctx, "автор", "Привет, @Вася и @никто! #Новости")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if len(post.Mentions) != 1 || post.Mentions[0] != "Вася" {
+		t.Errorf("Ожидали упоминание только Вася, получили %v", post.Mentions)
+	}
+
+	// Тест 2: поиск по хэштегу без учета регистра
+	posts, err := service.GetPostsByTag(ctx, "#НОВОСТИ")
+	if err != nil || len(posts) != 1 || posts[0].ID != post.ID {
+		t.Errorf("Ожидали пост %d по тегу, получили %v (ошибка %v)", post.ID, posts, err)
+	}
+
+	// Тест 3: упоминания пользователя и несуществующего пользователя
+	if posts, _ := service.GetMentions(ctx, "Вася"); len(posts) != 1 {
+		t.Errorf("Ожидали 1 упоминание, получили %d", len(posts))
+	}
+	if _, err := service.GetMentions(ctx, "никто"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Ожидали ErrUserNotFound, получили %v", err)
+	}
+
+	// Тест 4: правка переиндексирует пост
+	if _, err := service.EditPost(ctx, post.ID, "автор", "Без тегов"); err != nil {
+		t.Fatalf("Ошибка правки поста: %v", err)
+	}
+	if posts, _ := service.GetPostsByTag(ctx, "новости"); len(posts) != 0 {
+		t.Errorf("После правки тег не должен находить пост, получили %d", len(posts))
+	}
+	if posts, _ := service.GetMentions(ctx, "Вася"); len(posts) != 0 {
+		t.Errorf("После правки упоминание должно исчезнуть, получили %d", len(posts))
+	}
+}
+
+// TestSearch тестирует полнотекстовый поиск с индексацией через очередь
+func TestSearch(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	indexQueue := queue.NewIndexQueue(10, 2)
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithIndexQueue(indexQueue))
+	indexQueue.Start(service.ProcessIndexEvent)
+	defer indexQueue.Stop()
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	post, err := service.CreatePost(ctx, "alice", "Новости программирования на Go")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if _, err := service.CreatePost(ctx, "bob", "Свежие новости"); err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+
+	// Индекс обновляется асинхронно - ждем оба поста
+	waitTotal := func(q string, want int) *models.SearchResult {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			res, err := service.Search(ctx, q, "", 0, 0)
+			if err != nil {
+				t.Fatalf("Ошибка поиска %q: %v", q, err)
+			}
+			if res.Total == want || time.Now().After(deadline) {
+				return res
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Тест 1: поиск по другой форме слова
+	if res := waitTotal("новость", 2); res.Total != 2 || res.Limit != DefaultSearchLimit {
+		t.Errorf("Ожидали 2 поста, получили %d", res.Total)
+	}
+
+	// Тест 2: фильтр по автору
+	res, err := service.Search(ctx, "новости", "alice", 0, 0)
+	if err != nil || res.Total != 1 || res.Hits[0].Post.ID != post.ID {
+		t.Errorf("Ожидали только пост %d от alice, получили %+v (ошибка %v)", post.ID, res, err)
+	}
+	if _, err := service.Search(ctx, "новости", "nobody", 0, 0); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Ожидали ErrUserNotFound, получили %v", err)
+	}
+
+	// Тест 3: запрос из одних стоп-слов
+	if _, err := service.Search(ctx, "и в на", "", 0, 0); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("Ожидали ErrEmptyQuery, получили %v", err)
+	}
+
+	// Тест 4: правка переиндексирует пост
+	if _, err := service.EditPost(ctx, post.ID, "alice", "Про погоду"); err != nil {
+		t.Fatalf("Ошибка правки поста: %v", err)
+	}
+	if res := waitTotal("погода", 1); res.Total != 1 {
+		t.Errorf("Ожидали найти исправленный пост, получили %d", res.Total)
+	}
+	if res := waitTotal("новости", 1); res.Total != 1 {
+		t.Errorf("Старый текст не должен находиться, получили %d", res.Total)
+	}
+}
+
+// TestTrending тестирует популярное по лайкам и ответам с затуханием
+func TestTrending(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	clock := &fixedClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithClock(clock))
+	ctx := context.Background()
+
+	for _, name := range []string{"user1", "user2", "user3"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	old, _ := service.CreatePost(ctx, "user1", "Старый пост #архив")
+	for _, name := range []string{"user1", "user2", "user3"} {
+		if err := service.ProcessLikeEvent(models.LikeEvent{PostID: old.ID, Username: name}); err != nil {
+			t.Fatalf("Ошибка обработки лайка: %v", err)
+		}
+	}
+
+	// Через 6 часов: свежий пост получает ответ и лайк
+	clock.now = clock.now.Add(6 * time.Hour)
+	fresh, _ := service.CreatePost(ctx, "user2", "Свежий пост #новости")
+	if _, err := service.CreatePost(ctx, "user3", "Ответ", ReplyTo(fresh.ID)); err != nil {
+		t.Fatalf("Ошибка создания ответа: %v", err)
+	}
+	if err := service.ProcessLikeEvent(models.LikeEvent{PostID: fresh.ID, Username: "user1"}); err != nil {
+		t.Fatalf("Ошибка обработки лайка: %v", err)
+	}
+
+	trend, err := service.GetTrending(ctx, 0)
+	if err != nil {
+		t.Fatalf("Ошибка получения популярного: %v", err)
+	}
+	if len(trend.Posts) < 2 || trend.Posts[0].Post.ID != fresh.ID {
+		t.Fatalf("Свежая активность должна перевешивать старую: %+v", trend.Posts)
+	}
+	if len(trend.Hashtags) != 2 || trend.Hashtags[0].Tag != "новости" {
+		t.Errorf("Ожидали хэштег новости первым, получили %+v", trend.Hashtags)
+	}
+
+	// Тест 2: ограничение выдачи
+	trend, _ = service.GetTrending(ctx, 1)
+	if len(trend.Posts) != 1 || len(trend.Hashtags) != 1 {
+		t.Errorf("Ожидали по одной записи, получили %d постов и %d хэштегов", len(trend.Posts), len(trend.Hashtags))
+	}
+}
+
+// TestRegisterUserValidation тестирует проверку имени и уникальность без учета регистра
+func TestRegisterUserValidation(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+
+	for _, name := range []string{"Alice", "Bob"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+
+	// Тест 1: то же имя в другом регистре и кириллическое "Вов", неотличимое от "Bob"
+	for _, name := range []string{"alice", "BOB", "Вов"} {
+		if _, err := service.RegisterUser(ctx, name); !errors.Is(err, ErrUserExists) {
+			t.Errorf("Имя %q: ожидали ErrUserExists, получили %v", name, err)
+		}
+	}
+
+	// Тест 2: только пробелы и зарезервированное имя
+	if _, err := service.RegisterUser(ctx, "   "); !errors.Is(err, ErrEmptyUsername) {
+		t.Errorf("Ожидали ErrEmptyUsername, получили %v", err)
+	}
+	if _, err := service.RegisterUser(ctx, "root"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Ожидали ErrInvalidInput, получили %v", err)
+	}
+
+	// Тест 3: слишком длинный пост
+	if _, err := service.CreatePost(ctx, "Alice", strings.Repeat("я", 501)); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Ожидали ErrInvalidInput, получили %v", err)
+	}
+}
+
+// TestDeletePost тестирует удаление поста вместе с репостами и индексами
+func TestDeletePost(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+
+	for _, name := range []string{"user1", "user2"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	original, err := service.CreatePost(ctx, "user1", "Удаляемый пост #тег")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if _, err := service.Repost(ctx, "user2", original.ID); err != nil {
+		t.Fatalf("Ошибка репоста: %v", err)
+	}
+	quote, err := service.CreatePost(ctx, "user2", "Цитата", Quote(original.ID))
+	if err != nil {
+		t.Fatalf("Ошибка создания цитаты: %v", err)
+	}
+
+	// Тест 1: удалить может только автор
+	if err := service.DeletePost(ctx, original.ID, "user2"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Ожидали ErrForbidden, получили %v", err)
+	}
+
+	// Тест 2: удаление убирает пост, его репосты и записи в индексах
+	if err := service.DeletePost(ctx, original.ID, "user1"); err != nil {
+		t.Fatalf("Ошибка удаления поста: %v", err)
+	}
+	if _, err := service.GetPostHistory(ctx, original.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Ожидали ErrPostNotFound для удаленного поста, получили %v", err)
+	}
+	timeline, _ := service.GetTimeline(ctx)
+	if len(timeline) != 1 || timeline[0].ID != quote.ID || timeline[0].Original != nil {
+		t.Errorf("В ленте должна остаться только цитата без оригинала, получили %d постов", len(timeline))
+	}
+	if posts, _ := service.GetPostsByTag(ctx, "тег"); len(posts) != 0 {
+		t.Errorf("Удаленный пост не должен находиться по хэштегу: %+v", posts)
+	}
+	if res, err := service.Search(ctx, "удаляемый", "", 0, 0); err != nil || res.Total != 0 {
+		t.Errorf("Удаленный пост не должен находиться поиском: %+v (%v)", res, err)
+	}
+
+	// Тест 3: повторное удаление
+	if err := service.DeletePost(ctx, original.ID, "user1"); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Ожидали ErrPostNotFound, получили %v", err)
+	}
+
+	// Тест 4: удаление ответа уменьшает счетчик ответов родителя
+	reply, err := service.CreatePost(ctx, "user1", "Ответ", ReplyTo(quote.ID))
+	if err != nil {
+		t.Fatalf("Ошибка создания ответа: %v", err)
+	}
+	if err := service.DeletePost(ctx, reply.ID, "user1"); err != nil {
+		t.Fatalf("Ошибка удаления ответа: %v", err)
+	}
+	if parent, _ := service.postRepo.GetByID(ctx, quote.ID); parent.ReplyCount != 0 {
+		t.Errorf("Ожидали 0 ответов у родителя, получили %d", parent.ReplyCount)
+	}
+}
+
+// TestWebhooks проверяет доставку события подписанным запросом, повтор
+// после ошибки получателя, журнал доставок и отключение вебхука
+func TestWebhooks(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+
+	type request struct {
+		event, delivery string
+		valid           bool
+	}
+	var (
+		mu       sync.Mutex
+		received []request
+		failures = 1 // первый запрос получатель отклоняет
+		secret   = "ключ-подписи-0123456789"
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, request{
+			event:    r.Header.Get(webhook.HeaderEvent),
+			delivery: r.Header.Get(webhook.HeaderDelivery),
+			valid:    webhook.Verify(secret, ts, body, r.Header.Get(webhook.HeaderSignature)),
+		})
+		if failures != 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	webhookQueue := queue.NewWebhookQueue(10, 1)
+	webhookQueue.SetDeadLetterCapacity(10)
+	sender := webhook.NewSender(webhook.Config{
+		Timeout: time.Second, MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, DisableAfter: 2, AllowPrivate: true,
+	})
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithWebhooks(webhookQueue, sender))
+	webhookQueue.Start(service.ProcessWebhookDelivery)
+	defer webhookQueue.Stop()
+	ctx := context.Background()
+
+	for _, name := range []string{"owner", "author"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+
+	// Тест 1: проверка параметров
+	if _, err := service.CreateWebhook(ctx, "owner", WebhookSpec{URL: "ftp://example.com", Events: []string{"post.created"}}); err == nil {
+		t.Error("Ожидали ошибку для адреса не http(s)")
+	}
+	if _, err := service.CreateWebhook(ctx, "owner", WebhookSpec{URL: srv.URL, Events: []string{"user.created"}}); err == nil {
+		t.Error("Ожидали ошибку для неизвестного события")
+	}
+
+	hook, err := service.CreateWebhook(ctx, "owner", WebhookSpec{
+		URL: srv.URL, Events: []string{"post.created"}, Users: []string{"author"}, Secret: secret,
+	})
+	if err != nil {
+		t.Fatalf("Ошибка создания вебхука: %v", err)
+	}
+	if hook.Secret != secret || !hook.Active {
+		t.Errorf("Ожидали активный вебхук с ключом, получили %+v", hook)
+	}
+	if hooks, _ := service.ListWebhooks(ctx, "owner"); len(hooks) != 1 || hooks[0].Secret != "" {
+		t.Errorf("Список вебхуков не должен раскрывать ключ: %+v", hooks)
+	}
+
+	// Тест 2: пост другого автора не проходит фильтр, пост author
+	// доставляется со второй попытки
+	if _, err := service.CreatePost(ctx, "owner", "Мимо фильтра"); err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if _, err := service.CreatePost(ctx, "author", "Для вебхука"); err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	waitAttempts := func(n int) []*models.WebhookAttempt {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			attempts, err := service.ListWebhookDeliveries(ctx, "owner", hook.ID)
+			if err != nil {
+				t.Fatalf("Ошибка чтения журнала доставок: %v", err)
+			}
+			if len(attempts) >= n || time.Now().After(deadline) {
+				return attempts
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	attempts := waitAttempts(2)
+	if len(attempts) != 2 || attempts[0].StatusCode != 500 || attempts[0].Success ||
+		attempts[1].StatusCode != 200 || !attempts[1].Success || attempts[1].Attempt != 2 {
+		t.Fatalf("Ожидали попытки 500 и 200, получили %+v", attempts)
+	}
+	mu.Lock()
+	for _, r := range received {
+		if r.event != "post.created" || !r.valid || r.delivery != received[0].delivery {
+			t.Errorf("Неверный запрос доставки: %+v", r)
+		}
+	}
+	mu.Unlock()
+
+	// Тест 3: после DisableAfter неудавшихся доставок подряд вебхук отключается
+	mu.Lock()
+	failures = -1
+	mu.Unlock()
+	for i := 0; i < 2; i++ {
+		if _, err := service.CreatePost(ctx, "author", "Получатель недоступен"); err != nil {
+			t.Fatalf("Ошибка создания поста: %v", err)
+		}
+		waitAttempts(4 + 2*i)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hooks, _ := service.ListWebhooks(ctx, "owner")
+		if !hooks[0].Active {
+			if hooks[0].DisabledReason == "" || hooks[0].Failures != 2 {
+				t.Errorf("Ожидали причину отключения и 2 неудачи, получили %+v", hooks[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Вебхук не отключился")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(webhookQueue.DeadLetters()) != 2 {
+		t.Errorf("Ожидали 2 доставки в DLQ, получили %d", len(webhookQueue.DeadLetters()))
+	}
+
+	// Тест 4: отключенному вебхуку доставки не ставятся в очередь, включение сбрасывает счетчик
+	if _, err := service.CreatePost(ctx, "author", "Не доставляется"); err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if stats := webhookQueue.Stats(); stats.Pending != 0 {
+		t.Errorf("Отключенному вебхуку не должно быть доставок, в очереди %d", stats.Pending)
+	}
+	enabled, err := service.EnableWebhook(ctx, "owner", hook.ID)
+	if err != nil || !enabled.Active || enabled.Failures != 0 || enabled.DisabledReason != "" {
+		t.Errorf("Ожидали включенный вебхук, получили %+v (%v)", enabled, err)
+	}
+
+	// Тест 5: чужой вебхук недоступен
+	if err := service.DeleteWebhook(ctx, "author", hook.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("Ожидали ErrWebhookNotFound, получили %v", err)
+	}
+	if err := service.DeleteWebhook(ctx, "owner", hook.ID); err != nil {
+		t.Errorf("Ошибка удаления вебхука: %v", err)
+	}
+}
+
+// TestNotifications проверяет уведомления о лайках и упоминаниях, склейку
+// лайков, отметку прочитанными и настройки по типам
+func TestNotifications(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+
+	names := []string{"author", "fan1", "fan2", "fan3", "fan4"}
+	for _, name := range names {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	post, err := service.CreatePost(ctx, "author", "Пост для лайков")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	like := func(username string) {
+		t.Helper()
+		if err := service.ProcessLikeEvent(models.LikeEvent{PostID: post.ID, Username: username}); err != nil {
+			t.Fatalf("Ошибка обработки лайка: %v", err)
+		}
+	}
+
+	// Тест 1: собственный лайк не уведомляет, лайки до прочтения склеиваются
+	like("author")
+	for _, name := range names[1:] {
+		like(name)
+	}
+	list, err := service.ListNotifications(ctx, "author", false, 0, 0)
+	if err != nil {
+		t.Fatalf("Ошибка чтения уведомлений: %v", err)
+	}
+	if list.Unread != 1 || len(list.Notifications) != 1 {
+		t.Fatalf("Ожидали одно непрочитанное уведомление, получили %+v", list)
+	}
+	n := list.Notifications[0]
+	if n.Type != models.NotificationLike || n.Count != 4 || len(n.Actors) != 3 || n.Actors[0] != "fan4" {
+		t.Errorf("Неверное склеенное уведомление: %+v", n)
+	}
+	if n.Text != "fan4 и еще 3 пользователя оценили ваш пост" {
+		t.Errorf("Неверный текст уведомления: %q", n.Text)
+	}
+
+	// Тест 2: упоминание - отдельное уведомление; себя не уведомляем
+	mention, err := service.CreatePost(ctx, "fan1", "Привет, @author и @fan1")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if list, _ := service.ListNotifications(ctx, "fan1", false, 0, 0); list.Total != 0 {
+		t.Errorf("Упоминание самого себя не должно уведомлять: %+v", list.Notifications)
+	}
+	list, _ = service.ListNotifications(ctx, "author", true, 0, 0)
+	if list.Unread != 2 || list.Notifications[0].Type != models.NotificationMention || list.Notifications[0].PostID != mention.ID {
+		t.Fatalf("Ожидали уведомление об упоминании первым, получили %+v", list.Notifications)
+	}
+	// Правка без новых упоминаний не повторяет уведомление
+	if _, err := service.EditPost(ctx, mention.ID, "fan1", "Привет еще раз, @author"); err != nil {
+		t.Fatalf("Ошибка правки поста: %v", err)
+	}
+
+	// Тест 3: после прочтения новый лайк начинает новое уведомление
+	unread, err := service.MarkNotificationsRead(ctx, "author", []int{n.ID})
+	if err != nil || unread != 1 {
+		t.Errorf("Ожидали 1 непрочитанное, получили %d (%v)", unread, err)
+	}
+	if _, err := service.RegisterUser(ctx, "fan5"); err != nil {
+		t.Fatalf("Ошибка регистрации пользователя: %v", err)
+	}
+	like("fan5")
+	list, _ = service.ListNotifications(ctx, "author", false, 0, 0)
+	if list.Total != 3 || list.Unread != 2 || list.Notifications[0].Count != 1 ||
+		list.Notifications[0].Text != "Пользователь fan5 оценил ваш пост" {
+		t.Errorf("Ожидали новое уведомление о лайке, получили %+v", list.Notifications)
+	}
+	if page, _ := service.ListNotifications(ctx, "author", false, 1, 1); len(page.Notifications) != 1 || page.Total != 3 {
+		t.Errorf("Неверная страница уведомлений: %+v", page)
+	}
+
+	// Тест 4: выключенный тип не создает уведомлений
+	if _, err := service.SetNotificationPreferences(ctx, "author", map[string]bool{"repost": true}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Ожидали ErrInvalidInput для неизвестного типа, получили %v", err)
+	}
+	prefs, err := service.SetNotificationPreferences(ctx, "author", map[string]bool{models.NotificationMention: false})
+	if err != nil || prefs[models.NotificationMention] || !prefs[models.NotificationLike] {
+		t.Fatalf("Неверные настройки: %v (%v)", prefs, err)
+	}
+	if _, err := service.CreatePost(ctx, "fan2", "@author, снова ты"); err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if unread, _ := service.MarkNotificationsRead(ctx, "author", nil); unread != 0 {
+		t.Errorf("Ожидали 0 непрочитанных после отметки всех, получили %d", unread)
+	}
+	if list, _ := service.ListNotifications(ctx, "author", false, 0, 0); list.Total != 3 {
+		t.Errorf("Выключенное упоминание не должно создавать уведомление, всего %d", list.Total)
+	}
+
+	// Тест 5: удаление поста убирает уведомления о нем
+	if err := service.DeletePost(ctx, post.ID, "author"); err != nil {
+		t.Fatalf("Ошибка удаления поста: %v", err)
+	}
+	if list, _ := service.ListNotifications(ctx, "author", false, 0, 0); list.Total != 1 {
+		t.Errorf("Ожидали только уведомление об упоминании, получили %+v", list.Notifications)
+	}
+}
+
+// TestFollows проверяет подписки, их списки, уведомление о подписке и
+// удаление подписок вместе с аккаунтом
+func TestFollows(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+	for _, name := range []string{"star", "fan1", "fan2"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	names := func(list *models.UserList) []string {
+		var out []string
+		for _, u := range list.Users {
+			out = append(out, u.Username)
+		}
+		return out
+	}
+
+	// Тест 1: проверка участников
+	if err := service.Follow(ctx, "star", "star"); !errors.Is(err, ErrFollowSelf) {
+		t.Errorf("Ожидали ErrFollowSelf, получили %v", err)
+	}
+	if err := service.Follow(ctx, "nobody", "star"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Ожидали ErrUserNotFound, получили %v", err)
+	}
+
+	// Тест 2: подписки в порядке оформления, повторная ничего не меняет
+	for _, name := range []string{"fan1", "fan2", "fan1"} {
+		if err := service.Follow(ctx, name, "star"); err != nil {
+			t.Fatalf("Ошибка подписки: %v", err)
+		}
+	}
+	if err := service.Follow(ctx, "fan1", "fan2"); err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+	followers, err := service.ListFollowers(ctx, "star", 0, 0)
+	if err != nil || followers.Total != 2 || !slices.Equal(names(followers), []string{"fan1", "fan2"}) {
+		t.Errorf("Ожидали подписчиков fan1 и fan2, получили %v (%v)", names(followers), err)
+	}
+	if page, _ := service.ListFollowers(ctx, "star", 1, 1); page.Total != 2 || !slices.Equal(names(page), []string{"fan2"}) {
+		t.Errorf("Неверная страница подписчиков: %v", names(page))
+	}
+	if following, _ := service.ListFollowing(ctx, "fan1", 0, 0); !slices.Equal(names(following), []string{"star", "fan2"}) {
+		t.Errorf("Ожидали подписки star и fan2, получили %v", names(following))
+	}
+
+	// Тест 3: о каждой новой подписке одно уведомление
+	list, _ := service.ListNotifications(ctx, "star", false, 0, 0)
+	if list.Total != 2 || list.Notifications[0].Type != models.NotificationFollow ||
+		list.Notifications[0].Text != "Пользователь fan2 подписался на вас" {
+		t.Errorf("Ожидали 2 уведомления о подписке, получили %+v", list.Notifications)
+	}
+
+	// Тест 4: отписка, в том числе повторная
+	for i := 0; i < 2; i++ {
+		if err := service.Unfollow(ctx, "fan2", "star"); err != nil {
+			t.Errorf("Ошибка отписки: %v", err)
+		}
+	}
+	if followers, _ := service.ListFollowers(ctx, "star", 0, 0); !slices.Equal(names(followers), []string{"fan1"}) {
+		t.Errorf("Ожидали только fan1, получили %v", names(followers))
+	}
+
+	// Тест 5: подписки переживают смену имени и удаляются вместе с аккаунтом
+	if _, err := service.RenameUser(ctx, "fan1", "fan_one"); err != nil {
+		t.Fatalf("Ошибка смены имени: %v", err)
+	}
+	if followers, _ := service.ListFollowers(ctx, "star", 0, 0); !slices.Equal(names(followers), []string{"fan_one"}) {
+		t.Errorf("Ожидали подписчика под новым именем, получили %v", names(followers))
+	}
+	if _, err := service.DeleteUser(ctx, "fan_one", models.DeletePosts); err != nil {
+		t.Fatalf("Ошибка удаления пользователя: %v", err)
+	}
+	if followers, _ := service.ListFollowers(ctx, "star", 0, 0); followers.Total != 0 {
+		t.Errorf("Подписки удаленного пользователя должны исчезнуть, получили %v", names(followers))
+	}
+	if followers, _ := service.ListFollowers(ctx, "fan2", 0, 0); followers.Total != 0 {
+		t.Errorf("Подписки удаленного пользователя должны исчезнуть, получили %v", names(followers))
+	}
+}
+
+// TestNotifyQueueFull проверяет, что переполненная очередь уведомлений не
+// блокирует действие пользователя
+func TestNotifyQueueFull(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	// Воркеры не запущены: в буфер помещается одно событие
+	notifications := queue.NewNotificationQueue(1, 1)
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithNotificationQueue(notifications))
+	ctx := context.Background()
+	for _, name := range []string{"star", "fan1", "fan2"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, name := range []string{"fan1", "fan2"} {
+			if err := service.Follow(ctx, name, "star"); err != nil {
+				t.Errorf("Ошибка подписки: %v", err)
+			}
+		}
+		if _, err := service.CreatePost(ctx, "fan1", "Привет, @star"); err != nil {
+			t.Errorf("Ошибка создания поста: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Переполненная очередь уведомлений заблокировала действие")
+	}
+	if st := notifications.Stats(); st.Pending != 1 {
+		t.Errorf("Ожидали 1 событие в очереди, получили %d", st.Pending)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	store, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithBlobStore(store))
+	ctx := context.Background()
+	if _, err := service.RegisterUser(ctx, "alice"); err != nil {
+		t.Fatalf("Ошибка регистрации пользователя: %v", err)
+	}
+	str := func(s string) *string { return &s }
+
+	// Тест 1: поля нормализуются, неуказанные не меняются
+	user, err := service.UpdateProfile(ctx, "alice", ProfileUpdate{
+		DisplayName: str("  Алиса  "),
+		Bio:         str("Пишу о Go\nи о котах"),
+		Website:     str("https://example.com/alice"),
+	})
+	if err != nil {
+		t.Fatalf("Ошибка обновления профиля: %v", err)
+	}
+	if user.DisplayName != "Алиса" || user.Bio != "Пишу о Go\nи о котах" || user.Website != "https://example.com/alice" {
+		t.Errorf("Неверный профиль: %+v", user)
+	}
+	user, err = service.UpdateProfile(ctx, "alice", ProfileUpdate{Location: str("Москва"), Website: str("")})
+	if err != nil {
+		t.Fatalf("Ошибка обновления профиля: %v", err)
+	}
+	if user.DisplayName != "Алиса" || user.Location != "Москва" || user.Website != "" {
+		t.Errorf("Ожидали частичное обновление, получили %+v", user)
+	}
+
+	// Тест 2: все нарушения возвращаются сразу, профиль не меняется
+	_, err = service.UpdateProfile(ctx, "alice", ProfileUpdate{
+		DisplayName: str(strings.Repeat("я", 51)),
+		Location:    str("Москва\nПитер"),
+		Website:     str("javascript:alert(1)"),
+	})
+	var fields validation.Errors
+	if !errors.As(err, &fields) || len(fields) != 3 || !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("Ожидали три ошибки полей, получили %v", err)
+	}
+	if user, _ := service.GetUserByUsername(ctx, "alice"); user.Location != "Москва" {
+		t.Errorf("Профиль не должен измениться при ошибке, получили %+v", user)
+	}
+	if _, err := service.UpdateProfile(ctx, "nobody", ProfileUpdate{Bio: str("x")}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Ожидали ErrUserNotFound, получили %v", err)
+	}
+
+	// Тест 3: загрузка аватара создает все размеры
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for i := range src.Pix {
+		src.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("Ошибка кодирования PNG: %v", err)
+	}
+	if _, err := service.SetAvatar(ctx, "alice", []byte("не картинка")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("Ожидали ErrUnsupportedImage, получили %v", err)
+	}
+	user, err = service.SetAvatar(ctx, "alice", buf.Bytes())
+	if err != nil {
+		t.Fatalf("Ошибка загрузки аватара: %v", err)
+	}
+	first := user.Avatar
+	if first == nil || first.ContentType != "image/png" || len(first.Sizes) != len(AvatarSizes) {
+		t.Fatalf("Неверный аватар: %+v", first)
+	}
+	for _, tc := range []struct{ size, want int }{{0, 400}, {48, 48}, {100, 128}, {1000, 400}} {
+		data, avatar, chosen, err := service.GetAvatar(ctx, "alice", tc.size)
+		if err != nil {
+			t.Fatalf("Ошибка чтения аватара %d: %v", tc.size, err)
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil || chosen != tc.want || cfg.Width != tc.want || cfg.Height != tc.want || avatar.Version != first.Version {
+			t.Errorf("size=%d: ожидали %d×%d, получили %d (%d×%d, %v)", tc.size, tc.want, tc.want, chosen, cfg.Width, cfg.Height, err)
+		}
+	}
+
+	// Тест 4: новая загрузка меняет версию и удаляет прежние файлы
+	user, err = service.SetAvatar(ctx, "alice", buf.Bytes())
+	if err != nil {
+		t.Fatalf("Ошибка загрузки аватара: %v", err)
+	}
+	if user.Avatar.Version <= first.Version {
+		t.Errorf("Версия должна вырасти: %d -> %d", first.Version, user.Avatar.Version)
+	}
+	for _, key := range avatarKeys(user.ID, first) {
+		if _, _, err := store.Get(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+			t.Errorf("Прежний файл %s должен быть удален, получили %v", key, err)
+		}
+	}
+
+	// Тест 5: удаление аватара
+	if err := service.DeleteAvatar(ctx, "alice"); err != nil {
+		t.Fatalf("Ошибка удаления аватара: %v", err)
+	}
+	if _, _, _, err := service.GetAvatar(ctx, "alice", 0); !errors.Is(err, ErrAvatarNotFound) {
+		t.Errorf("Ожидали ErrAvatarNotFound, получили %v", err)
+	}
+	if err := service.DeleteAvatar(ctx, "alice"); !errors.Is(err, ErrAvatarNotFound) {
+		t.Errorf("Повторное удаление: ожидали ErrAvatarNotFound, получили %v", err)
+	}
+
+	// Тест 6: без хранилища загрузка отключена
+	plain := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	if _, err := plain.RegisterUser(ctx, "bob"); err != nil {
+		t.Fatalf("Ошибка регистрации пользователя: %v", err)
+	}
+	if _, err := plain.SetAvatar(ctx, "bob", buf.Bytes()); !errors.Is(err, ErrUploadsDisabled) {
+		t.Errorf("Ожидали ErrUploadsDisabled, получили %v", err)
+	}
+}
+
+func TestMedia(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	store, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+	clock := &fixedClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithBlobStore(store), WithClock(clock))
+	ctx := context.Background()
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+
+	encode := func(w, h int) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+			t.Fatalf("Ошибка кодирования PNG: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	// Тест 1: большое изображение уменьшается, миниатюра сохраняет пропорции
+	uploaded, err := service.UploadMedia(ctx, "alice", encode(3000, 1500), encode(100, 50))
+	if err != nil {
+		t.Fatalf("Ошибка загрузки: %v", err)
+	}
+	big, small := uploaded[0], uploaded[1]
+	if big.Width != MediaMaxSide || big.Height != MediaMaxSide/2 || big.ThumbWidth != ThumbnailSide || big.ThumbHeight != ThumbnailSide/2 {
+		t.Errorf("Неверные размеры: %+v", big)
+	}
+	if small.Width != 100 || small.ThumbWidth != 100 {
+		t.Errorf("Маленькое изображение не должно увеличиваться: %+v", small)
+	}
+	data, _, err := service.GetMedia(ctx, big.ID, big.AccessKey, true)
+	if err != nil {
+		t.Fatalf("Ошибка чтения миниатюры: %v", err)
+	}
+	if cfg, err := png.DecodeConfig(bytes.NewReader(data)); err != nil || cfg.Width != ThumbnailSide {
+		t.Errorf("Неверная миниатюра: %+v (%v)", cfg, err)
+	}
+	// До прикрепления изображение отдается только по его ключу доступа
+	for _, key := range []string{"", "alice", small.AccessKey} {
+		if _, _, err := service.GetMedia(ctx, big.ID, key, false); !errors.Is(err, ErrMediaNotFound) {
+			t.Errorf("Ожидали ErrMediaNotFound для ключа %q, получили %v", key, err)
+		}
+	}
+
+	// Тест 2: ошибка в одном файле отклоняет загрузку целиком
+	if _, err := service.UploadMedia(ctx, "alice", encode(10, 10), []byte("текст")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("Ожидали ErrUnsupportedImage, получили %v", err)
+	}
+	if _, err := service.UploadMedia(ctx, "alice", make([][]byte, MaxAttachments+1)...); !errors.Is(err, ErrTooManyAttachments) {
+		t.Errorf("Ожидали ErrTooManyAttachments, получили %v", err)
+	}
+
+	// Тест 3: прикреплять можно только свои и еще не прикрепленные изображения
+	if _, err := service.CreatePost(ctx, "bob", "Чужая картинка", Attach(big.ID)); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Ожидали ErrForbidden, получили %v", err)
+	}
+	if _, err := service.CreatePost(ctx, "alice", "Нет такой", Attach(999)); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("Ожидали ErrAttachmentNotFound, получили %v", err)
+	}
+	post, err := service.CreatePost(ctx, "alice", "Фото с прогулки", Attach(big.ID, small.ID))
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if len(post.Attachments) != 2 || post.Attachments[0].ID != big.ID || post.Attachments[0].PostID != post.ID ||
+		post.Attachments[0].AccessKey != "" {
+		t.Errorf("Неверные вложения: %+v", post.Attachments)
+	}
+	if _, err := service.CreatePost(ctx, "alice", "Повтор", Attach(small.ID)); !errors.Is(err, ErrMediaAttached) {
+		t.Errorf("Ожидали ErrMediaAttached, получили %v", err)
+	}
+	if _, _, err := service.GetMedia(ctx, big.ID, "", false); err != nil {
+		t.Errorf("Прикрепленное изображение должно быть доступно всем: %v", err)
+	}
+
+	// Тест 4: удаление поста удаляет изображения
+	if err := service.DeletePost(ctx, post.ID, "alice"); err != nil {
+		t.Fatalf("Ошибка удаления поста: %v", err)
+	}
+	if _, _, err := service.GetMedia(ctx, big.ID, "", false); !errors.Is(err, ErrMediaNotFound) {
+		t.Errorf("Ожидали ErrMediaNotFound, получили %v", err)
+	}
+	for _, key := range mediaKeys(big) {
+		if _, _, err := store.Get(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+			t.Errorf("Файл %s должен быть удален, получили %v", key, err)
+		}
+	}
+
+	// Тест 5: неприкрепленные изображения удаляются по истечении срока
+	old, err := service.UploadMedia(ctx, "alice", encode(10, 10))
+	if err != nil {
+		t.Fatalf("Ошибка загрузки: %v", err)
+	}
+	clock.now = clock.now.Add(DefaultPendingMediaTTL - time.Minute)
+	fresh, err := service.UploadMedia(ctx, "alice", encode(10, 10))
+	if err != nil {
+		t.Fatalf("Ошибка загрузки: %v", err)
+	}
+	clock.now = clock.now.Add(2 * time.Minute)
+	if n := service.ExpirePendingMedia(ctx); n != 1 {
+		t.Errorf("Ожидали удаление 1 изображения, удалено %d", n)
+	}
+	if _, _, err := service.GetMedia(ctx, old[0].ID, old[0].AccessKey, false); !errors.Is(err, ErrMediaNotFound) {
+		t.Errorf("Просроченное изображение должно быть удалено, получили %v", err)
+	}
+	if _, _, err := service.GetMedia(ctx, fresh[0].ID, fresh[0].AccessKey, false); err != nil {
+		t.Errorf("Свежее изображение должно остаться: %v", err)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1))
+	ctx := context.Background()
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	alicePost, err := service.CreatePost(ctx, "alice", "Пост alice")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	bobPost, err := service.CreatePost(ctx, "bob", "Пост bob для @alice")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	for _, like := range []models.LikeEvent{
+		{PostID: bobPost.ID, Username: "alice"},
+		{PostID: alicePost.ID, Username: "bob"},
+		{PostID: alicePost.ID, Username: "carol"},
+	} {
+		if err := service.ProcessLikeEvent(like); err != nil {
+			t.Fatalf("Ошибка обработки лайка: %v", err)
+		}
+	}
+	if _, err := service.Repost(ctx, "alice", bobPost.ID); err != nil {
+		t.Fatalf("Ошибка репоста: %v", err)
+	}
+	if _, err := service.Repost(ctx, "bob", alicePost.ID); err != nil {
+		t.Fatalf("Ошибка репоста: %v", err)
+	}
+
+	// Тест 1: счетчики пользователя, поиск по ID и постраничный список
+	alice, err := service.GetUserByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Ошибка чтения пользователя: %v", err)
+	}
+	view := service.GetUserView(ctx, alice)
+	if view.PostCount != 2 || view.LikesReceived != 2 || view.LikesGiven != 1 {
+		t.Errorf("Неверные счетчики: %+v", view)
+	}
+	if user, err := service.GetUserByID(ctx, 2); err != nil || user.Username != "bob" {
+		t.Errorf("Ожидали bob по ID 2, получили %v (%v)", user, err)
+	}
+	list, err := service.ListUsers(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("Ошибка чтения списка: %v", err)
+	}
+	if list.Total != 3 || len(list.Users) != 1 || list.Users[0].Username != "bob" {
+		t.Errorf("Неверная страница пользователей: %+v", list)
+	}
+
+	// Тест 2: неизвестный режим отклоняется
+	var fields validation.Errors
+	if _, err := service.DeleteUser(ctx, "alice", "archive"); !errors.As(err, &fields) || fields[0].Field != "posts" {
+		t.Errorf("Ожидали ошибку поля posts, получили %v", err)
+	}
+
+	// Тест 3: обезличивание оставляет посты без автора, снимает лайки и репосты
+	entry, err := service.DeleteUser(ctx, "alice", models.AnonymizePosts)
+	if err != nil {
+		t.Fatalf("Ошибка удаления пользователя: %v", err)
+	}
+	if entry.UserID != alice.ID || entry.PostsAnonymized != 1 || entry.PostsDeleted != 1 || entry.LikesRemoved != 1 {
+		t.Errorf("Неверная запись аудита: %+v", entry)
+	}
+	if _, err := service.GetUserByUsername(ctx, "alice"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Ожидали ErrUserNotFound, получили %v", err)
+	}
+	if _, err := service.GetUserByID(ctx, alice.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Ожидали ErrUserNotFound по ID, получили %v", err)
+	}
+	post, _ := service.postRepo.GetByID(ctx, alicePost.ID)
+	if view := service.viewPost(ctx, post); view.Author != models.AnonymousAuthor || post.AuthorID != 0 || len(post.LikeIDs) != 2 {
+		t.Errorf("Пост должен остаться без автора и с лайками: %+v", view)
+	}
+	post, _ = service.postRepo.GetByID(ctx, bobPost.ID)
+	if len(post.LikeIDs) != 0 || post.RepostCount != 0 {
+		t.Errorf("Лайк и репост alice должны быть сняты: %+v", post)
+	}
+	if len(post.MentionIDs) != 0 {
+		t.Errorf("Упоминание alice должно быть снято: %v", post.MentionIDs)
+	}
+	if list, _ := service.ListNotifications(ctx, "bob", false, 0, 0); list.Total != 0 {
+		t.Errorf("Уведомление о лайке alice должно исчезнуть: %+v", list)
+	}
+	if err := service.ProcessLikeEvent(models.LikeEvent{PostID: bobPost.ID, Username: "alice"}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Запоздавший лайк удаленного пользователя должен отклоняться, получили %v", err)
+	}
+
+	// Тест 4: имя освобождается, новый пользователь получает новый ID
+	if user, err := service.RegisterUser(ctx, "alice"); err != nil || user.ID == alice.ID {
+		t.Errorf("Имя должно освободиться: %v (%v)", user, err)
+	}
+	if posts, err := service.GetMentions(ctx, "alice"); err != nil || len(posts) != 0 {
+		t.Errorf("Новой alice не должны достаться упоминания прежней: %v (%v)", posts, err)
+	}
+
+	// Тест 5: удаление постов удаляет их вместе с репостами
+	if entry, err = service.DeleteUser(ctx, "bob", models.DeletePosts); err != nil {
+		t.Fatalf("Ошибка удаления пользователя: %v", err)
+	}
+	if entry.PostsDeleted != 2 || entry.LikesRemoved != 1 {
+		t.Errorf("Неверная запись аудита: %+v", entry)
+	}
+	if _, err := service.postRepo.GetByID(ctx, bobPost.ID); err == nil {
+		t.Error("Пост bob должен быть удален")
+	}
+	post, _ = service.postRepo.GetByID(ctx, alicePost.ID)
+	if len(post.LikeIDs) != 1 || post.RepostCount != 0 {
+		t.Errorf("Лайк и репост bob должны быть сняты: %+v", post)
+	}
+
+	// Тест 6: журнал аудита - новые записи первыми, без имен
+	audit, err := service.ListAudit(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("Ошибка чтения журнала: %v", err)
+	}
+	if audit.Total != 2 || audit.Entries[0].Mode != models.DeletePosts || audit.Entries[1].UserID != alice.ID {
+		t.Errorf("Неверный журнал аудита: %+v", audit)
+	}
+}
+
+func TestRenameUser(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	clock := &fixedClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	service := NewMicroBlogService(log, queue.NewLikeQueue(10, 1), WithClock(clock))
+	ctx := context.Background()
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := service.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации пользователя: %v", err)
+		}
+	}
+	alicePost, err := service.CreatePost(ctx, "alice", "Привет всем")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	bobPost, err := service.CreatePost(ctx, "bob", "Привет, @alice")
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if err := service.ProcessLikeEvent(models.LikeEvent{PostID: bobPost.ID, Username: "alice"}); err != nil {
+		t.Fatalf("Ошибка обработки лайка: %v", err)
+	}
+
+	// Тест 1: занятое имя не отдается
+	if _, err := service.RenameUser(ctx, "alice", "bob"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Ожидали ErrUserExists, получили %v", err)
+	}
+
+	// Тест 2: посты хранят ID, поэтому новое имя сразу видно в постах,
+	// лайках, упоминаниях, поиске и уведомлениях
+	user, err := service.RenameUser(ctx, "alice", "alicia")
+	if err != nil {
+		t.Fatalf("Ошибка смены имени: %v", err)
+	}
+	if user.Username != "alicia" || user.ID != 1 {
+		t.Errorf("Неверный пользователь после смены имени: %+v", user)
+	}
+	if post, _ := service.postRepo.GetByID(ctx, alicePost.ID); service.viewPost(ctx, post).Author != "alicia" {
+		t.Errorf("Автор поста должен смениться: %+v", service.viewPost(ctx, post))
+	}
+	if post, _ := service.postRepo.GetByID(ctx, bobPost.ID); post.Author != "" || len(post.Likes) != 0 {
+		t.Errorf("В хранилище пост не должен содержать имен: %+v", post)
+	}
+	if posts, err := service.GetMentions(ctx, "alicia"); err != nil || len(posts) != 1 ||
+		!slices.Equal(posts[0].Likes, []string{"alicia"}) || !slices.Equal(posts[0].Mentions, []string{"alicia"}) || posts[0].Content != "Привет, @alice" {
+		t.Errorf("Лайк и прежнее упоминание должны засчитываться новому имени, текст - остаться: %v (%v)", posts, err)
+	}
+	if result, err := service.Search(ctx, "привет", "alicia", 0, 0); err != nil || result.Total != 1 {
+		t.Errorf("Поиск по новому имени автора должен найти пост: %+v (%v)", result, err)
+	}
+	list, _ := service.ListNotifications(ctx, "bob", false, 0, 0)
+	if len(list.Notifications) != 1 || list.Notifications[0].Text != "Пользователь alicia оценил ваш пост" {
+		t.Errorf("Уведомление должно показывать новое имя: %+v", list.Notifications)
+	}
+
+	// Тест 3: прежнее имя ведет к пользователю, лайк из очереди со старым именем не дублируется
+	if name, err := service.ResolveUsername(ctx, "alice"); err != nil || name != "alicia" {
+		t.Errorf("Ожидали alicia по прежнему имени, получили %q (%v)", name, err)
+	}
+	if err := service.ProcessLikeEvent(models.LikeEvent{PostID: bobPost.ID, Username: "alice"}); err != nil {
+		t.Errorf("Лайк со старым именем должен обработаться: %v", err)
+	}
+	if post, _ := service.postRepo.GetByID(ctx, bobPost.ID); len(post.LikeIDs) != 1 {
+		t.Errorf("Лайк не должен дублироваться: %v", post.LikeIDs)
+	}
+
+	// Тест 4: освобожденное имя на карантине для других, но не для владельца
+	if _, err := service.RegisterUser(ctx, "alice"); !errors.Is(err, ErrUsernameReserved) {
+		t.Errorf("Ожидали ErrUsernameReserved при регистрации, получили %v", err)
+	}
+	if _, err := service.RenameUser(ctx, "bob", "alice"); !errors.Is(err, ErrUsernameReserved) {
+		t.Errorf("Ожидали ErrUsernameReserved при смене имени, получили %v", err)
+	}
+	if _, err := service.RenameUser(ctx, "alicia", "alice"); err != nil {
+		t.Fatalf("Владелец должен вернуть прежнее имя: %v", err)
+	}
+	if name, _ := service.ResolveUsername(ctx, "alicia"); name != "alice" {
+		t.Errorf("Ожидали alice по имени alicia, получили %q", name)
+	}
+
+	// Тест 5: после карантина имя можно занять, псевдоним перестает действовать
+	clock.now = clock.now.Add(DefaultNameCooldown + time.Second)
+	if _, err := service.RegisterUser(ctx, "alicia"); err != nil {
+		t.Fatalf("Имя после карантина должно освободиться: %v", err)
+	}
+	if name, _ := service.ResolveUsername(ctx, "alicia"); name != "alicia" {
+		t.Errorf("Имя должно принадлежать новому пользователю, получили %q", name)
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+)
+
+// WithEventHub включает публикацию событий о постах и лайках в хаб
+// (его читают подписчики GET /stream)
+func WithEventHub(h *pubsub.Hub) Option {
+	return func(s *MicroBlogService) {
+		s.events = h
+	}
+}
+
+// publish отправляет событие о посте post в хаб и вебхукам, если они
+// включены. post - копия для выдачи (viewPost): тема автора строится по
+// его текущему имени. Ошибка публикации только логируется: изменение уже
+// сохранено.
+func (s *MicroBlogService) publish(ctx context.Context, typ string, post *models.Post, data any) {
+	if s.events != nil {
+		if _, err := s.events.Publish(typ, data, postTopics(post)...); err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка публикации события %s: %v", typ, err))
+		}
+	}
+	s.enqueueWebhooks(ctx, typ, post, data)
+}
+
+// postTopics возвращает темы событий о посте: автор, хэштеги, сам пост,
+// а также пост, на который он отвечает или который репостит или цитирует
+func postTopics(post *models.Post) []string {
+	topics := []string{pubsub.UserTopic(post.Author), pubsub.PostTopic(post.ID)}
+	if post.ReplyToID != 0 {
+		topics = append(topics, pubsub.PostTopic(post.ReplyToID))
+	}
+	if post.OriginalID != 0 {
+		topics = append(topics, pubsub.PostTopic(post.OriginalID))
+	}
+	for _, tag := range post.Hashtags {
+		topics = append(topics, pubsub.TagTopic(tag))
+	}
+	return topics
+}
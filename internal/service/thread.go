@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// Ограничения выдачи обсуждения
+const (
+	DefaultThreadDepth = 10
+	MaxThreadDepth     = 50
+	DefaultThreadLimit = 20
+	MaxThreadLimit     = 100
+
+	// maxAncestors защищает подъем к корню от патологически длинных цепочек
+	maxAncestors = 1000
+)
+
+// GetThread возвращает обсуждение, в которое входит пост postID, начиная с корня.
+// depth ограничивает количество уровней ответов под корнем; limit и offset
+// постранично выбирают прямые ответы корня, на вложенных уровнях действует только limit.
+func (s *MicroBlogService) GetThread(ctx context.Context, postID, depth, limit, offset int) (*models.Thread, error) {
+	ctx, span := startSpan(ctx, "GetThread", trace.WithAttributes(attribute.Int("post.id", postID)))
+	defer span.End()
+
+	if depth <= 0 {
+		depth = DefaultThreadDepth
+	}
+	depth = min(depth, MaxThreadDepth)
+	if limit <= 0 {
+		limit = DefaultThreadLimit
+	}
+	limit = min(limit, MaxThreadLimit)
+	offset = max(offset, 0)
+
+	post, err := s.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, fail(span, ErrPostNotFound)
+	}
+
+	// Поднимаемся к корню обсуждения
+	root := post
+	for i := 0; root.ReplyToID != 0 && i < maxAncestors; i++ {
+		parent, err := s.postRepo.GetByID(ctx, root.ReplyToID)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Родитель %d поста %d не найден: %v", root.ReplyToID, root.ID, err))
+			break
+		}
+		root = parent
+	}
+
+	thread := &models.Thread{
+		Root:    s.buildThreadNode(ctx, root, depth, limit, offset),
+		FocusID: postID,
+		Depth:   depth,
+		Limit:   limit,
+		Offset:  offset,
+	}
+	span.SetAttributes(attribute.Int("thread.root_id", root.ID))
+	s.logger.Debug(fmt.Sprintf("Запрошено обсуждение поста %d (корень %d)", postID, root.ID))
+	return thread, nil
+}
+
+// buildThreadNode рекурсивно собирает узел дерева с ответами до заданной глубины
+func (s *MicroBlogService) buildThreadNode(ctx context.Context, post *models.Post, depth, limit, offset int) *models.ThreadNode {
+	node := &models.ThreadNode{Post: s.viewPost(ctx, post), Replies: []*models.ThreadNode{}}
+	if depth == 0 {
+		node.MoreReplies = post.ReplyCount
+		return node
+	}
+
+	replies := s.postRepo.ListReplies(ctx, post.ID)
+	start := min(offset, len(replies))
+	end := min(start+limit, len(replies))
+	for _, reply := range replies[start:end] {
+		node.Replies = append(node.Replies, s.buildThreadNode(ctx, reply, depth-1, limit, 0))
+	}
+	node.MoreReplies = len(replies) - (end - start)
+	return node
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/trending"
+)
+
+// Ограничения выдачи популярного
+const (
+	DefaultTrendingLimit = 10
+	MaxTrendingLimit     = 50
+)
+
+// GetTrending возвращает самые популярные хэштеги и посты по недавним лайкам
+// и ответам. Считается по накопленным в памяти окнам, без обхода всех постов.
+func (s *MicroBlogService) GetTrending(ctx context.Context, limit int) (*models.Trending, error) {
+	ctx, span := startSpan(ctx, "GetTrending", trace.WithAttributes(attribute.Int("trending.limit", limit)))
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultTrendingLimit
+	}
+	limit = min(limit, MaxTrendingLimit)
+	now := s.clock.Now()
+
+	result := &models.Trending{
+		Hashtags: make([]models.TrendingTag, 0, limit),
+		Posts:    make([]models.TrendingPost, 0, limit),
+	}
+	for _, e := range s.trending.TopTags(limit, now) {
+		result.Hashtags = append(result.Hashtags, models.TrendingTag{Tag: e.Key, Score: e.Score})
+	}
+	for _, e := range s.trending.TopPosts(limit, now) {
+		post, err := s.postRepo.GetByID(ctx, e.Key)
+		if err != nil {
+			continue
+		}
+		result.Posts = append(result.Posts, models.TrendingPost{Post: s.viewPost(ctx, post), Score: e.Score})
+	}
+
+	span.SetAttributes(
+		attribute.Int("trending.hashtag_count", len(result.Hashtags)),
+		attribute.Int("trending.post_count", len(result.Posts)),
+	)
+	s.logger.Debug(fmt.Sprintf("Запрошено популярное: %d хэштегов, %d постов", len(result.Hashtags), len(result.Posts)))
+	return result, nil
+}
+
+// recordActivity учитывает активность по посту и его хэштегам в подсчете популярности
+func (s *MicroBlogService) recordActivity(post *models.Post, weight float64) {
+	now := s.clock.Now()
+	s.trending.RecordPost(post.ID, weight, now)
+	s.trending.RecordTags(post.Hashtags, weight, now)
+}
+
+// mustDefaultTracker создает трекер популярности с настройками по умолчанию
+func mustDefaultTracker() *trending.Tracker {
+	t, err := trending.NewTracker(trending.DefaultConfig())
+	if err != nil {
+		panic(err) // настройки по умолчанию всегда корректны
+	}
+	return t
+}
@@ -1,44 +1,135 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
 )
 
 // RegisterUser регистрирует нового пользователя
-func (s *MicroBlogService) RegisterUser(username string) (*models.User, error) {
-	if username == "" {
-		s.logger.Error("Попытка регистрации с пустым именем пользователя")
-		return nil, errors.New("имя пользователя не может быть пустым")
+func (s *MicroBlogService) RegisterUser(ctx context.Context, username string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "RegisterUser", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	username, err := s.validator.Username(username)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Недопустимое имя пользователя: %v", err))
+		return nil, fail(span, err)
 	}
 
-	// Проверяем, существует ли пользователь
-	if s.userRepo.Exists(username) {
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	// Проверяем, существует ли пользователь, в том числе с тем же именем
+	// в другом регистре или с похожими буквами
+	canonical := validation.CanonicalUsername(username)
+	if s.userRepo.Exists(ctx, username) || s.userRepo.ExistsCanonical(ctx, canonical) {
 		s.logger.Error(fmt.Sprintf("Пользователь %s уже существует", username))
-		return nil, errors.New("пользователь уже существует")
+		return nil, fail(span, ErrUserExists)
+	}
+	if err := s.checkNameReserved(ctx, canonical, 0); err != nil {
+		return nil, fail(span, err)
 	}
 
 	// Создаем нового пользователя
 	userID := int(s.userIDCounter.Increment())
+	now := s.clock.Now()
 	user := &models.User{
-		ID:       userID,
-		Username: username,
+		ID:            userID,
+		Username:      username,
+		CanonicalName: canonical,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 
 	// Сохраняем в репозитории
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		s.logger.Error(fmt.Sprintf("Ошибка при создании пользователя: %v", err))
-		return nil, err
+		return nil, fail(span, err)
 	}
 
+	// Карантин истек: старые ссылки на прежнего владельца больше не ведут к нему
+	if err := s.aliasRepo.Delete(ctx, canonical); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка удаления псевдонима %s: %v", username, err))
+	}
+
+	span.SetAttributes(attribute.Int("user.id", userID))
 	s.logger.Info(fmt.Sprintf("Зарегистрирован новый пользователь: %s (ID: %d)", username, userID))
 
 	return user, nil
 }
 
+// Размер страницы списка пользователей
+const (
+	DefaultUserLimit = 50
+	MaxUserLimit     = 200
+)
+
 // GetUserByUsername возвращает пользователя по имени
-func (s *MicroBlogService) GetUserByUsername(username string) (*models.User, error) {
-	return s.userRepo.GetByUsername(username)
+func (s *MicroBlogService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "GetUserByUsername", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// GetUserByID возвращает пользователя по ID
+func (s *MicroBlogService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, span := startSpan(ctx, "GetUserByID", trace.WithAttributes(attribute.Int("user.id", id)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// GetUserView возвращает пользователя со счетчиками постов и лайков
+func (s *MicroBlogService) GetUserView(ctx context.Context, user *models.User) *models.UserView {
+	ctx, span := startSpan(ctx, "GetUserView", trace.WithAttributes(attribute.String("user.name", user.Username)))
+	defer span.End()
+
+	view := &models.UserView{User: user}
+	for _, p := range s.postRepo.List(ctx) {
+		if p.AuthorID == user.ID {
+			view.PostCount++
+			view.LikesReceived += len(p.LikeIDs)
+		}
+		if slices.Contains(p.LikeIDs, user.ID) {
+			view.LikesGiven++
+		}
+	}
+	return view
+}
+
+// ListUsers возвращает страницу пользователей в порядке регистрации.
+// limit 0 - DefaultUserLimit, больше MaxUserLimit урезается.
+func (s *MicroBlogService) ListUsers(ctx context.Context, limit, offset int) (*models.UserList, error) {
+	ctx, span := startSpan(ctx, "ListUsers")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = DefaultUserLimit
+	}
+	limit = min(limit, MaxUserLimit)
+	offset = max(offset, 0)
+
+	users := s.userRepo.List(ctx)
+	list := &models.UserList{Total: len(users), Limit: limit, Offset: offset, Users: []*models.User{}}
+	if offset < len(users) {
+		list.Users = users[offset:min(offset+limit, len(users))]
+	}
+	span.SetAttributes(attribute.Int("user.count", len(users)))
+	return list, nil
 }
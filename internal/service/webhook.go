@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/pubsub"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/repository"
+	"github.com/Cere6rum/MicroBlog2/internal/tracing"
+	"github.com/Cere6rum/MicroBlog2/internal/validation"
+	"github.com/Cere6rum/MicroBlog2/internal/webhook"
+)
+
+const (
+	// maxWebhooksPerUser - предел вебхуков одного пользователя
+	maxWebhooksPerUser = 10
+	// minWebhookSecret - минимальная длина собственного ключа подписи
+	minWebhookSecret = 16
+)
+
+// webhookEvents - события, на которые можно подписать вебхук
+var webhookEvents = []string{pubsub.EventPostCreated, pubsub.EventPostLiked, pubsub.EventPostDeleted}
+
+// WebhookSpec - параметры нового вебхука
+type WebhookSpec struct {
+	URL    string
+	Events []string
+	Users  []string // фильтр по авторам постов
+	Tags   []string // фильтр по хэштегам
+	Secret string   // пусто - сгенерировать
+}
+
+// WithWebhooks включает исходящие вебхуки: доставки ставятся в очередь q,
+// воркеры очереди запускаются снаружи с обработчиком ProcessWebhookDelivery
+func WithWebhooks(q *queue.WebhookQueue, sender *webhook.Sender) Option {
+	return func(s *MicroBlogService) {
+		s.webhookQueue = q
+		s.webhookSender = sender
+	}
+}
+
+// WithWebhookRepo задает хранилище вебхуков и журнала доставок
+func WithWebhookRepo(r repository.WebhookRepository) Option {
+	return func(s *MicroBlogService) {
+		s.webhookRepo = r
+	}
+}
+
+// CreateWebhook регистрирует вебхук пользователя username. Ключ подписи
+// возвращается только в ответе на этот вызов.
+func (s *MicroBlogService) CreateWebhook(ctx context.Context, username string, spec WebhookSpec) (*models.Webhook, error) {
+	ctx, span := startSpan(ctx, "CreateWebhook", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	if s.webhookQueue == nil {
+		return nil, fail(span, ErrWebhooksDisabled)
+	}
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Пользователь %s не найден: %v", username, err))
+		return nil, fail(span, ErrUserNotFound)
+	}
+	hook, err := s.checkWebhookSpec(ctx, spec)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Недопустимые параметры вебхука: %v", err))
+		return nil, fail(span, err)
+	}
+
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	owned := 0
+	for _, h := range s.webhookRepo.List(ctx) {
+		if h.OwnerID == user.ID {
+			owned++
+		}
+	}
+	if owned >= maxWebhooksPerUser {
+		return nil, fail(span, ErrTooManyWebhooks)
+	}
+
+	now := s.clock.Now()
+	hook.ID = int(s.webhookIDCounter.Increment())
+	hook.OwnerID = user.ID
+	hook.Active = true
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+	if err := s.webhookRepo.Create(ctx, hook); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при создании вебхука: %v", err))
+		return nil, fail(span, err)
+	}
+
+	span.SetAttributes(attribute.Int("webhook.id", hook.ID))
+	s.logger.Info(fmt.Sprintf("Пользователь %s зарегистрировал вебхук %d на %s", username, hook.ID, hook.URL))
+	return s.viewWebhook(ctx, hook), nil
+}
+
+// checkWebhookSpec проверяет и нормализует параметры вебхука
+func (s *MicroBlogService) checkWebhookSpec(ctx context.Context, spec WebhookSpec) (*models.Webhook, error) {
+	var errs validation.Errors
+	invalid := func(field, msg string) {
+		errs = append(errs, validation.NewFieldError(field, validation.CodeInvalidValue, msg))
+	}
+
+	u, err := url.Parse(spec.URL)
+	switch {
+	case spec.URL == "":
+		errs = append(errs, validation.NewFieldError("url", validation.CodeRequired, "обязательное поле"))
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		invalid("url", "ожидается абсолютный адрес http или https")
+	}
+
+	hook := &models.Webhook{URL: spec.URL, Secret: spec.Secret}
+	if len(spec.Events) == 0 {
+		errs = append(errs, validation.NewFieldError("events", validation.CodeRequired,
+			"укажите хотя бы одно событие: "+strings.Join(webhookEvents, ", ")))
+	}
+	for _, e := range spec.Events {
+		if !slices.Contains(webhookEvents, e) {
+			invalid("events", fmt.Sprintf("неизвестное событие %q", e))
+		} else if !slices.Contains(hook.Events, e) {
+			hook.Events = append(hook.Events, e)
+		}
+	}
+	for _, name := range spec.Users {
+		user, err := s.userRepo.GetByUsername(ctx, name)
+		if err != nil {
+			invalid("users", fmt.Sprintf("пользователь %q не найден", name))
+		} else if !slices.Contains(hook.UserIDs, user.ID) {
+			hook.UserIDs = append(hook.UserIDs, user.ID)
+		}
+	}
+	for _, tag := range spec.Tags {
+		// Хэштеги в постах хранятся в нижнем регистре, без '#'
+		tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+		if tag == "" {
+			invalid("tags", "пустой хэштег")
+		} else if !slices.Contains(hook.Tags, tag) {
+			hook.Tags = append(hook.Tags, tag)
+		}
+	}
+
+	if hook.Secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("генерация ключа подписи: %w", err)
+		}
+		hook.Secret = hex.EncodeToString(buf)
+	} else if len(hook.Secret) < minWebhookSecret {
+		errs = append(errs, validation.NewFieldError("secret", validation.CodeTooShort,
+			fmt.Sprintf("должно содержать не меньше %d символов", minWebhookSecret)))
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return hook, nil
+}
+
+// ListWebhooks возвращает вебхуки пользователя без ключей подписи
+func (s *MicroBlogService) ListWebhooks(ctx context.Context, username string) ([]*models.Webhook, error) {
+	ctx, span := startSpan(ctx, "ListWebhooks", trace.WithAttributes(attribute.String("user.name", username)))
+	defer span.End()
+
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, fail(span, ErrUserNotFound)
+	}
+
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	hooks := make([]*models.Webhook, 0)
+	for _, h := range s.webhookRepo.List(ctx) {
+		if h.OwnerID == user.ID {
+			hooks = append(hooks, s.publicWebhook(ctx, h))
+		}
+	}
+	span.SetAttributes(attribute.Int("webhook.count", len(hooks)))
+	return hooks, nil
+}
+
+// DeleteWebhook удаляет вебхук вместе с журналом доставок
+func (s *MicroBlogService) DeleteWebhook(ctx context.Context, username string, id int) error {
+	ctx, span := startSpan(ctx, "DeleteWebhook", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.Int("webhook.id", id),
+	))
+	defer span.End()
+
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	if _, err := s.ownedWebhook(ctx, username, id); err != nil {
+		return fail(span, err)
+	}
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при удалении вебхука %d: %v", id, err))
+		return fail(span, err)
+	}
+	s.logger.Info(fmt.Sprintf("Пользователь %s удалил вебхук %d", username, id))
+	return nil
+}
+
+// EnableWebhook снова включает вебхук, отключенный после неудачных доставок
+func (s *MicroBlogService) EnableWebhook(ctx context.Context, username string, id int) (*models.Webhook, error) {
+	ctx, span := startSpan(ctx, "EnableWebhook", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.Int("webhook.id", id),
+	))
+	defer span.End()
+
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	hook, err := s.ownedWebhook(ctx, username, id)
+	if err != nil {
+		return nil, fail(span, err)
+	}
+	hook.Active = true
+	hook.Failures = 0
+	hook.DisabledReason = ""
+	hook.UpdatedAt = s.clock.Now()
+	if err := s.webhookRepo.Update(ctx, hook); err != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при включении вебхука %d: %v", id, err))
+		return nil, fail(span, err)
+	}
+	s.logger.Info(fmt.Sprintf("Пользователь %s включил вебхук %d", username, id))
+	return s.publicWebhook(ctx, hook), nil
+}
+
+// ListWebhookDeliveries возвращает журнал последних попыток доставки, старые первыми
+func (s *MicroBlogService) ListWebhookDeliveries(ctx context.Context, username string, id int) ([]*models.WebhookAttempt, error) {
+	ctx, span := startSpan(ctx, "ListWebhookDeliveries", trace.WithAttributes(
+		attribute.String("user.name", username),
+		attribute.Int("webhook.id", id),
+	))
+	defer span.End()
+
+	s.webhookMu.Lock()
+	_, err := s.ownedWebhook(ctx, username, id)
+	s.webhookMu.Unlock()
+	if err != nil {
+		return nil, fail(span, err)
+	}
+	attempts, err := s.webhookRepo.ListAttempts(ctx, id)
+	if err != nil {
+		return nil, fail(span, err)
+	}
+	return attempts, nil
+}
+
+// ownedWebhook возвращает вебхук id пользователя username (вызывается под webhookMu).
+// Чужой вебхук неотличим от несуществующего.
+func (s *MicroBlogService) ownedWebhook(ctx context.Context, username string, id int) (*models.Webhook, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	hook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil || hook.OwnerID != user.ID {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, nil
+}
+
+// viewWebhook возвращает копию вебхука с текущими именами владельца и
+// авторов из фильтра
+func (s *MicroBlogService) viewWebhook(ctx context.Context, h *models.Webhook) *models.Webhook {
+	out := *h
+	out.Owner = s.username(ctx, h.OwnerID)
+	out.UserIDs = slices.Clone(h.UserIDs)
+	out.Users = s.usernames(ctx, h.UserIDs)
+	out.Events = slices.Clone(h.Events)
+	out.Tags = slices.Clone(h.Tags)
+	return &out
+}
+
+// publicWebhook возвращает копию вебхука для выдачи без ключа подписи
+func (s *MicroBlogService) publicWebhook(ctx context.Context, h *models.Webhook) *models.Webhook {
+	out := s.viewWebhook(ctx, h)
+	out.Secret = ""
+	return out
+}
+
+// webhookEnvelope - тело запроса доставки
+type webhookEnvelope struct {
+	ID        int             `json:"id"` // ID доставки, совпадает с заголовком X-MicroBlog-Delivery
+	Event     string          `json:"event"`
+	WebhookID int             `json:"webhook_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// enqueueWebhooks ставит в очередь доставку события всем подходящим вебхукам.
+// Очередь не блокирует изменение поста: при переполнении доставка
+// пропускается и попадает в журнал как неудачная.
+func (s *MicroBlogService) enqueueWebhooks(ctx context.Context, typ string, post *models.Post, data any) {
+	if s.webhookQueue == nil {
+		return
+	}
+
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	var raw json.RawMessage
+	for _, hook := range s.webhookRepo.List(ctx) {
+		if !hook.Active || !webhookMatches(hook, typ, post) {
+			continue
+		}
+		if raw == nil {
+			var err error
+			if raw, err = json.Marshal(data); err != nil {
+				s.logger.Error(fmt.Sprintf("Ошибка кодирования события %s для вебхуков: %v", typ, err))
+				return
+			}
+		}
+
+		id := int(s.deliveryIDCounter.Increment())
+		now := s.clock.Now()
+		body, err := json.Marshal(webhookEnvelope{ID: id, Event: typ, WebhookID: hook.ID, CreatedAt: now, Data: raw})
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Ошибка кодирования доставки %d: %v", id, err))
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			ID:           id,
+			WebhookID:    hook.ID,
+			Event:        typ,
+			Body:         body,
+			TraceContext: tracing.Inject(ctx),
+		}
+		if !s.webhookQueue.TryEnqueue(delivery) {
+			s.logger.Error(fmt.Sprintf("Очередь вебхуков переполнена, доставка %d вебхуку %d пропущена", id, hook.ID))
+			s.logAttempt(ctx, delivery, webhook.Attempt{Err: errors.New("очередь доставки переполнена")}, now)
+		}
+	}
+}
+
+// webhookMatches проверяет фильтры вебхука: тип события и, если заданы,
+// авторов и хэштеги поста
+func webhookMatches(hook *models.Webhook, typ string, post *models.Post) bool {
+	if !slices.Contains(hook.Events, typ) {
+		return false
+	}
+	if len(hook.UserIDs) == 0 && len(hook.Tags) == 0 {
+		return true
+	}
+	if slices.Contains(hook.UserIDs, post.AuthorID) {
+		return true
+	}
+	for _, tag := range hook.Tags {
+		if slices.Contains(post.Hashtags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessWebhookDelivery выполняет одну попытку доставки (вызывается из
+// очереди вебхуков). Каждая попытка пишется в журнал. Повтор ставится в
+// очередь заново со сроком, поэтому воркер не ждет паузу между попытками.
+// Доставка, не удавшаяся после всех попыток, возвращает ошибку и попадает
+// в DLQ очереди; повтор из DLQ получает одну попытку. После DisableAfter
+// таких доставок подряд вебхук отключается.
+func (s *MicroBlogService) ProcessWebhookDelivery(d models.WebhookDelivery) error {
+	origin := trace.SpanContextFromContext(tracing.Extract(context.Background(), d.TraceContext))
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int("webhook.id", d.WebhookID),
+			attribute.Int("webhook.delivery_id", d.ID),
+			attribute.String("webhook.event", d.Event),
+		),
+	}
+	if origin.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: origin}))
+	}
+	ctx, span := startSpan(context.Background(), "ProcessWebhookDelivery", opts...)
+	defer span.End()
+
+	s.webhookMu.Lock()
+	hook, err := s.webhookRepo.GetByID(ctx, d.WebhookID)
+	var target, secret string
+	active := err == nil && hook.Active
+	if active {
+		target, secret = hook.URL, hook.Secret
+	}
+	s.webhookMu.Unlock()
+	if !active {
+		// Вебхук удален или отключен, пока доставка ждала в очереди
+		s.logger.Debug(fmt.Sprintf("Доставка %d пропущена: вебхук %d удален или отключен", d.ID, d.WebhookID))
+		return nil
+	}
+
+	attempt := s.webhookSender.Deliver(ctx, target, secret, d)
+	s.logAttempt(ctx, d, attempt, s.clock.Now())
+	err = attempt.Err
+	if delay, ok := s.webhookSender.RetryDelay(attempt); ok {
+		d.Attempt = attempt.Number
+		if s.webhookQueue.EnqueueAfter(d, delay) {
+			s.logger.Debug(fmt.Sprintf("Доставка %d вебхуку %d повторится через %s: %v", d.ID, d.WebhookID, delay, err))
+			return nil
+		}
+		// Очередь остановлена - не вина получателя, счетчик ошибок не меняется
+		return fail(span, err)
+	}
+
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	hook, getErr := s.webhookRepo.GetByID(ctx, d.WebhookID)
+	if getErr != nil {
+		return nil
+	}
+	if err == nil {
+		hook.Failures = 0
+	} else {
+		hook.Failures++
+		if limit := s.webhookSender.Config().DisableAfter; limit > 0 && hook.Failures >= limit && hook.Active {
+			hook.Active = false
+			hook.DisabledReason = fmt.Sprintf("%d доставок подряд не удались, последняя ошибка: %v", hook.Failures, err)
+			s.logger.Error(fmt.Sprintf("Вебхук %d отключен: %s", hook.ID, hook.DisabledReason))
+		}
+	}
+	hook.UpdatedAt = s.clock.Now()
+	if updErr := s.webhookRepo.Update(ctx, hook); updErr != nil {
+		s.logger.Error(fmt.Sprintf("Ошибка при обновлении вебхука %d: %v", hook.ID, updErr))
+	}
+
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Доставка %d вебхуку %d не удалась: %v", d.ID, d.WebhookID, err))
+		return fail(span, err)
+	}
+	s.logger.Info(fmt.Sprintf("Доставка %d (%s) вебхуку %d выполнена", d.ID, d.Event, d.WebhookID))
+	return nil
+}
+
+// logAttempt пишет попытку доставки в журнал вебхука
+func (s *MicroBlogService) logAttempt(ctx context.Context, d models.WebhookDelivery, a webhook.Attempt, at time.Time) {
+	attempt := &models.WebhookAttempt{
+		DeliveryID: d.ID,
+		WebhookID:  d.WebhookID,
+		Event:      d.Event,
+		Attempt:    a.Number,
+		StatusCode: a.StatusCode,
+		Success:    a.Err == nil,
+		Duration:   a.Duration,
+		At:         at,
+	}
+	if a.Err != nil {
+		attempt.Error = a.Err.Error()
+	}
+	if err := s.webhookRepo.AddAttempt(ctx, attempt); err != nil {
+		s.logger.Debug(fmt.Sprintf("Попытка доставки %d не записана: %v", d.ID, err))
+	}
+}
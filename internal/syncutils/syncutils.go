@@ -42,6 +42,33 @@ func (s *SafeUserStorage) Exists(username string) bool {
 	return exists
 }
 
+// Delete удаляет пользователя
+func (s *SafeUserStorage) Delete(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, username)
+}
+
+// Rename переносит пользователя под новое имя: читатели видят либо
+// прежнее имя, либо новое, но не пропажу пользователя
+func (s *SafeUserStorage) Rename(oldUsername, newUsername string, user interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, oldUsername)
+	s.users[newUsername] = user
+}
+
+// GetAll возвращает всех пользователей в произвольном порядке
+func (s *SafeUserStorage) GetAll() []interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]interface{}, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u)
+	}
+	return users
+}
+
 // SafePostStorage - потокобезопасное хранилище постов
 type SafePostStorage struct {
 	mu    sync.RWMutex
@@ -62,6 +89,28 @@ func (s *SafePostStorage) Add(post interface{}) {
 	s.posts = append(s.posts, post)
 }
 
+// Insert помещает пост по индексу, дополняя хранилище пустыми ячейками.
+// Занятую ячейку перезаписать нельзя.
+func (s *SafePostStorage) Insert(index int, post interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 {
+		return fmt.Errorf("index out of range")
+	}
+	if index < len(s.posts) {
+		if s.posts[index] != nil {
+			return fmt.Errorf("index %d is occupied", index)
+		}
+		s.posts[index] = post
+		return nil
+	}
+	for len(s.posts) < index {
+		s.posts = append(s.posts, nil)
+	}
+	s.posts = append(s.posts, post)
+	return nil
+}
+
 // GetAll возвращает все посты
 func (s *SafePostStorage) GetAll() []interface{} {
 	s.mu.RLock()
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Cere6rum/MicroBlog2/internal/tracing"
+
+// statusRecorder запоминает код ответа для атрибутов спана
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush пробрасывает сброс буфера (нужен для потоковых ответов)
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack пробрасывает захват соединения (нужен для WebSocket)
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("соединение не поддерживает захват")
+	}
+	if r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return hj.Hijack()
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware создает серверный спан на каждый HTTP-запрос.
+// Контекст трассировки извлекается из заголовков traceparent/tracestate.
+// Спан называется по шаблону маршрута (r.Pattern, его заполняет ServeMux),
+// а не по пути запроса: иначе у каждого /posts/{id} было бы свое имя.
+// Конкретный путь попадает в атрибут url.path.
+func Middleware(next http.Handler) http.Handler {
+	tracer := otel.Tracer(instrumentationName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", r.RemoteAddr),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		// ServeMux записывает шаблон в переданный ему запрос
+		req := r.WithContext(ctx)
+		next.ServeHTTP(rec, req)
+
+		if name, route := spanName(r.Method, req.Pattern); route != "" {
+			span.SetName(name)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// spanName возвращает имя спана "METHOD route" и шаблон пути из шаблона
+// ServeMux вида "[METHOD ][HOST]/path". Без шаблона (запрос не нашел
+// маршрута) route пуст и спан остается с именем по методу.
+func spanName(method, pattern string) (name, route string) {
+	if pattern == "" {
+		return method, ""
+	}
+	route = pattern
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		route = strings.TrimSpace(path)
+	}
+	if i := strings.Index(route, "/"); i > 0 {
+		route = route[i:] // шаблон с хостом
+	}
+	return method + " " + route, route
+}
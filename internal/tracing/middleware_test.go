@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestMiddlewareSpanName проверяет, что спан называется по шаблону
+// маршрута, а конкретный путь попадает в url.path
+func TestMiddlewareSpanName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/posts/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Middleware(mux)
+
+	tests := []struct {
+		path, name, route string
+	}{
+		{"/api/v1/posts/123", "GET /api/v1/posts/{id}", "/api/v1/posts/{id}"},
+		{"/api/v1/posts/124", "GET /api/v1/posts/{id}", "/api/v1/posts/{id}"},
+		{"/unknown/1", "GET", ""},
+	}
+	for _, tt := range tests {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != len(tests) {
+		t.Fatalf("Ожидали %d спанов, получили %d", len(tests), len(spans))
+	}
+	for i, tt := range tests {
+		attrs := map[attribute.Key]string{}
+		for _, kv := range spans[i].Attributes() {
+			attrs[kv.Key] = kv.Value.Emit()
+		}
+		if spans[i].Name() != tt.name {
+			t.Errorf("%s: ожидали имя %q, получили %q", tt.path, tt.name, spans[i].Name())
+		}
+		if attrs["url.path"] != tt.path || attrs["http.route"] != tt.route {
+			t.Errorf("%s: неверные атрибуты %v", tt.path, attrs)
+		}
+	}
+}
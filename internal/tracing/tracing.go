@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Поддерживаемые экспортеры трейсов
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Config - настройки трассировки
+type Config struct {
+	Exporter     string  // none, stdout, file, otlp
+	File         string  // путь к файлу для экспортера file
+	OTLPEndpoint string  // host:port коллектора для экспортера otlp (пусто - из OTEL_EXPORTER_OTLP_ENDPOINT)
+	Insecure     bool    // отправлять OTLP без TLS
+	ServiceName  string  // имя сервиса в ресурсе
+	SampleRatio  float64 // доля сэмплируемых трейсов (0..1)
+}
+
+// ShutdownFunc сбрасывает буферы и останавливает экспортер
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup настраивает глобальный TracerProvider и пропагатор W3C Trace Context.
+// При экспортере none провайдер не создается, и трейсы не собираются.
+func Setup(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Exporter == "" || cfg.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeFn, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать ресурс трассировки: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closeFn != nil {
+			if cerr := closeFn(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// newExporter создает экспортер по имени из конфигурации
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, func() error, error) {
+	switch cfg.Exporter {
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, fmt.Errorf("не удалось создать stdout-экспортер: %w", err)
+		}
+		return exp, nil, nil
+
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, nil, fmt.Errorf("не указан файл для экспортера трейсов")
+		}
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			return nil, nil, fmt.Errorf("не удалось открыть файл трейсов: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, nil, fmt.Errorf("не удалось создать файловый экспортер: %w", err)
+		}
+		return exp, file.Close, nil
+
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("не удалось создать OTLP-экспортер: %w", err)
+		}
+		return exp, nil, nil
+
+	default:
+		return nil, nil, fmt.Errorf("неизвестный экспортер трейсов: %q", cfg.Exporter)
+	}
+}
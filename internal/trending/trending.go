@@ -0,0 +1,215 @@
+// Package trending считает популярность постов и хэштегов по недавней
+// активности. События складываются в скользящее окно из временных корзин,
+// вклад каждой корзины экспоненциально затухает с возрастом.
+package trending
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Веса событий при подсчете популярности
+const (
+	LikeWeight  = 1.0 // лайк поста
+	ReplyWeight = 2.0 // ответ на пост: требует больше усилий, чем лайк
+	TagWeight   = 1.0 // новый пост с хэштегом
+)
+
+// Config - параметры подсчета популярности
+type Config struct {
+	Window   time.Duration // события старше окна не учитываются
+	Bucket   time.Duration // шаг окна: события внутри корзины считаются одновременными
+	HalfLife time.Duration // за это время вклад события уменьшается вдвое
+}
+
+// DefaultConfig - окно в сутки с корзинами по минуте и полураспадом в 2 часа
+func DefaultConfig() Config {
+	return Config{Window: 24 * time.Hour, Bucket: time.Minute, HalfLife: 2 * time.Hour}
+}
+
+// Validate проверяет согласованность параметров
+func (c Config) Validate() error {
+	switch {
+	case c.Window <= 0 || c.Bucket <= 0 || c.HalfLife <= 0:
+		return errors.New("window, bucket и half_life должны быть положительными")
+	case c.Bucket > c.Window:
+		return fmt.Errorf("bucket (%s) больше window (%s)", c.Bucket, c.Window)
+	}
+	return nil
+}
+
+// Entry - ключ и его текущая популярность
+type Entry[K comparable] struct {
+	Key   K
+	Score float64
+}
+
+// Tracker накапливает активность по постам и хэштегам. Безопасен для конкурентного использования.
+type Tracker struct {
+	cfg     Config
+	mu      sync.Mutex
+	posts   window[int]
+	tags    window[string]
+	evicted int64 // корзины раньше этой уже выброшены
+}
+
+// NewTracker создает трекер популярности
+func NewTracker(cfg Config) (*Tracker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Tracker{
+		cfg:   cfg,
+		posts: newWindow[int](),
+		tags:  newWindow[string](),
+	}, nil
+}
+
+// RecordPost учитывает активность по посту в момент at
+func (t *Tracker) RecordPost(postID int, weight float64, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.posts.add(postID, t.bucket(at), weight)
+	t.evict(at)
+}
+
+// RecordTags учитывает активность по каждому из хэштегов в момент at
+func (t *Tracker) RecordTags(tags []string, weight float64, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tag := range tags {
+		t.tags.add(tag, t.bucket(at), weight)
+	}
+	t.evict(at)
+}
+
+// TopPosts возвращает n самых популярных постов на момент now
+func (t *Tracker) TopPosts(n int, now time.Time) []Entry[int] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.evict(now)
+	// При равной популярности выше более новый пост
+	return top(&t.posts, n, t.decay(now), func(a, b int) int { return b - a })
+}
+
+// TopTags возвращает n самых популярных хэштегов на момент now
+func (t *Tracker) TopTags(n int, now time.Time) []Entry[string] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.evict(now)
+	return top(&t.tags, n, t.decay(now), strings.Compare)
+}
+
+// Forget убирает пост из подсчета (например, после удаления)
+func (t *Tracker) Forget(postID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.posts.remove(postID)
+}
+
+// bucket - номер корзины для момента at
+func (t *Tracker) bucket(at time.Time) int64 {
+	return at.UnixNano() / int64(t.cfg.Bucket)
+}
+
+// evict выбрасывает корзины, целиком вышедшие из окна (вызывается под мьютексом)
+func (t *Tracker) evict(now time.Time) {
+	oldest := t.bucket(now.Add(-t.cfg.Window))
+	if oldest <= t.evicted {
+		return // окно не сдвинулось на целую корзину
+	}
+	t.evicted = oldest
+	t.posts.evict(oldest)
+	t.tags.evict(oldest)
+}
+
+// decay возвращает множитель затухания для корзины на момент now
+func (t *Tracker) decay(now time.Time) func(bucket int64) float64 {
+	lambda := math.Ln2 / float64(t.cfg.HalfLife)
+	return func(bucket int64) float64 {
+		age := float64(now.UnixNano() - bucket*int64(t.cfg.Bucket))
+		return math.Exp(-lambda * max(age, 0))
+	}
+}
+
+// window - веса событий по ключам и корзинам
+type window[K comparable] struct {
+	buckets map[int64]map[K]float64 // корзина -> ключ -> суммарный вес
+	keys    map[K]int               // ключ -> количество корзин, где он встречается
+}
+
+func newWindow[K comparable]() window[K] {
+	return window[K]{buckets: make(map[int64]map[K]float64), keys: make(map[K]int)}
+}
+
+func (w *window[K]) add(key K, bucket int64, weight float64) {
+	b := w.buckets[bucket]
+	if b == nil {
+		b = make(map[K]float64)
+		w.buckets[bucket] = b
+	}
+	if _, ok := b[key]; !ok {
+		w.keys[key]++
+	}
+	b[key] += weight
+}
+
+func (w *window[K]) evict(oldest int64) {
+	for id, b := range w.buckets {
+		if id >= oldest {
+			continue
+		}
+		for key := range b {
+			if w.keys[key]--; w.keys[key] == 0 {
+				delete(w.keys, key)
+			}
+		}
+		delete(w.buckets, id)
+	}
+}
+
+func (w *window[K]) remove(key K) {
+	for id, b := range w.buckets {
+		if _, ok := b[key]; ok {
+			delete(b, key)
+			if len(b) == 0 {
+				delete(w.buckets, id)
+			}
+		}
+	}
+	delete(w.keys, key)
+}
+
+// top суммирует затухающие веса по ключам и возвращает n лучших;
+// при равенстве порядок задает tie
+func top[K comparable](w *window[K], n int, decay func(int64) float64, tie func(a, b K) int) []Entry[K] {
+	scores := make(map[K]float64, len(w.keys))
+	for id, b := range w.buckets {
+		f := decay(id)
+		for key, weight := range b {
+			scores[key] += weight * f
+		}
+	}
+	out := make([]Entry[K], 0, len(scores))
+	for key, score := range scores {
+		out = append(out, Entry[K]{Key: key, Score: score})
+	}
+	slices.SortFunc(out, func(a, b Entry[K]) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return tie(a.Key, b.Key)
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package trending
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestDecayAndWindow проверяет затухание вклада событий и выход их из окна
+func TestDecayAndWindow(t *testing.T) {
+	tr, err := NewTracker(Config{Window: 10 * time.Hour, Bucket: time.Minute, HalfLife: time.Hour})
+	if err != nil {
+		t.Fatalf("Ошибка создания трекера: %v", err)
+	}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Пост 1: три лайка час назад, пост 2: два лайка только что
+	for range 3 {
+		tr.RecordPost(1, LikeWeight, start)
+	}
+	now := start.Add(time.Hour)
+	tr.RecordPost(2, LikeWeight, now)
+	tr.RecordPost(2, LikeWeight, now)
+
+	top := tr.TopPosts(10, now)
+	if len(top) != 2 || top[0].Key != 2 {
+		t.Fatalf("Свежие лайки должны перевешивать старые: %+v", top)
+	}
+	if math.Abs(top[1].Score-1.5) > 1e-9 {
+		t.Errorf("Через период полураспада 3 лайка должны весить 1.5, получили %g", top[1].Score)
+	}
+
+	// Через 10 часов события первого часа выходят из окна
+	tr.RecordTags([]string{"go"}, TagWeight, now)
+	later := start.Add(10*time.Hour + time.Minute)
+	top = tr.TopPosts(10, later)
+	if len(top) != 1 || top[0].Key != 2 {
+		t.Errorf("Пост 1 должен выйти из окна: %+v", top)
+	}
+	if tags := tr.TopTags(10, later); len(tags) != 1 || tags[0].Key != "go" {
+		t.Errorf("Ожидали хэштег go, получили %+v", tags)
+	}
+
+	// Ограничение количества и удаление поста
+	tr.Forget(2)
+	if top := tr.TopPosts(1, later); len(top) != 0 {
+		t.Errorf("Забытый пост не должен попадать в выдачу: %+v", top)
+	}
+}
+
+// TestConfigValidate проверяет отказ от некорректных параметров
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("Конфигурация по умолчанию должна быть корректной: %v", err)
+	}
+	if _, err := NewTracker(Config{Window: time.Minute, Bucket: time.Hour, HalfLife: time.Hour}); err == nil {
+		t.Error("Корзина больше окна должна быть ошибкой")
+	}
+}
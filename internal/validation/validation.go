@@ -0,0 +1,291 @@
+// Package validation проверяет и нормализует пользовательский ввод: имена
+// пользователей, текст постов и поля профиля. Ошибки содержат сведения
+// о каждом поле.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Коды нарушений - стабильные идентификаторы для клиентов API
+const (
+	CodeRequired     = "required"
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeInvalidChars = "invalid_chars"
+	CodeMixedScripts = "mixed_scripts"
+	CodeReserved     = "reserved"
+	CodeInvalidValue = "invalid_value"
+)
+
+// Ошибки, которые можно проверить через errors.Is
+var (
+	ErrEmptyUsername = errors.New("имя пользователя не может быть пустым")
+	ErrEmptyContent  = errors.New("содержимое поста не может быть пустым")
+	ErrInvalid       = errors.New("значение не прошло проверку")
+)
+
+// FieldError - нарушение правила для одного поля
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	err     error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.err
+}
+
+// Errors - все нарушения, найденные при проверке
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e Errors) Unwrap() []error {
+	out := make([]error, len(e))
+	for i, fe := range e {
+		out[i] = fe
+	}
+	return out
+}
+
+// Policy - правила проверки ввода
+type Policy struct {
+	MaxPostRunes      int      // максимальная длина поста в символах (рунах)
+	UsernameMinRunes  int      // минимальная длина имени пользователя
+	UsernameMaxRunes  int      // максимальная длина имени пользователя
+	ReservedUsernames []string // имена, которые нельзя занять (сравниваются по каноническому виду)
+
+	reserved map[string]bool
+}
+
+// DefaultPolicy возвращает правила по умолчанию
+func DefaultPolicy() *Policy {
+	p, _ := NewPolicy(Policy{
+		MaxPostRunes:     500,
+		UsernameMinRunes: 3,
+		UsernameMaxRunes: 32,
+		ReservedUsernames: []string{
+			"admin", "administrator", "root", "system", "support", "moderator",
+			"api", "microblog", "me", "null", "undefined",
+		},
+	})
+	return p
+}
+
+// NewPolicy проверяет параметры и подготавливает правила к использованию
+func NewPolicy(p Policy) (*Policy, error) {
+	switch {
+	case p.MaxPostRunes < 1:
+		return nil, fmt.Errorf("max_post_runes должно быть больше 0, получено %d", p.MaxPostRunes)
+	case p.UsernameMinRunes < 1:
+		return nil, fmt.Errorf("username_min_runes должно быть больше 0, получено %d", p.UsernameMinRunes)
+	case p.UsernameMaxRunes < p.UsernameMinRunes:
+		return nil, fmt.Errorf("username_max_runes (%d) меньше username_min_runes (%d)", p.UsernameMaxRunes, p.UsernameMinRunes)
+	}
+	p.reserved = make(map[string]bool, len(p.ReservedUsernames))
+	for _, name := range p.ReservedUsernames {
+		p.reserved[CanonicalUsername(name)] = true
+	}
+	return &p, nil
+}
+
+// Username проверяет имя пользователя и возвращает его в нормализованном
+// виде (NFC, без пробелов по краям). Имя должно начинаться с буквы и
+// состоять из букв одного алфавита (латиница или кириллица), цифр и '_'.
+func (p *Policy) Username(name string) (string, error) {
+	const field = "username"
+	if len(name) > p.UsernameMaxRunes*utf8.UTFMax+64 {
+		return "", Errors{tooLong(field, p.UsernameMaxRunes)} // не нормализуем заведомо длинный ввод
+	}
+	name = norm.NFC.String(strings.TrimSpace(name))
+	if name == "" {
+		return "", Errors{{Field: field, Code: CodeRequired, Message: ErrEmptyUsername.Error(), err: ErrEmptyUsername}}
+	}
+
+	var errs Errors
+	n := utf8.RuneCountInString(name)
+	switch {
+	case n < p.UsernameMinRunes:
+		errs = append(errs, &FieldError{Field: field, Code: CodeTooShort, err: ErrInvalid,
+			Message: fmt.Sprintf("должно содержать не меньше %d символов", p.UsernameMinRunes)})
+	case n > p.UsernameMaxRunes:
+		errs = append(errs, tooLong(field, p.UsernameMaxRunes))
+	}
+
+	first, _ := utf8.DecodeRuneInString(name)
+	var latin, cyrillic, invalid bool
+	for _, r := range name {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		case r == '_' || (r >= '0' && r <= '9'):
+		default:
+			invalid = true
+		}
+	}
+	switch {
+	case invalid:
+		errs = append(errs, &FieldError{Field: field, Code: CodeInvalidChars, err: ErrInvalid,
+			Message: "допустимы только буквы латиницы или кириллицы, цифры и '_'"})
+	case !unicode.IsLetter(first):
+		errs = append(errs, &FieldError{Field: field, Code: CodeInvalidChars, err: ErrInvalid,
+			Message: "должно начинаться с буквы"})
+	case latin && cyrillic:
+		errs = append(errs, &FieldError{Field: field, Code: CodeMixedScripts, err: ErrInvalid,
+			Message: "нельзя смешивать латиницу и кириллицу"})
+	}
+
+	if p.reserved[CanonicalUsername(name)] {
+		errs = append(errs, &FieldError{Field: field, Code: CodeReserved, err: ErrInvalid,
+			Message: "имя зарезервировано"})
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+	return name, nil
+}
+
+// PostContent проверяет текст поста и возвращает его в форме NFC.
+// Длина считается в символах; из управляющих символов допустимы только
+// перевод строки и табуляция.
+func (p *Policy) PostContent(content string) (string, error) {
+	const field = "content"
+	if len(content) > p.MaxPostRunes*utf8.UTFMax {
+		return "", Errors{tooLong(field, p.MaxPostRunes)} // не нормализуем заведомо длинный ввод
+	}
+	content = norm.NFC.String(content)
+	if strings.TrimSpace(content) == "" {
+		return "", Errors{{Field: field, Code: CodeRequired, Message: ErrEmptyContent.Error(), err: ErrEmptyContent}}
+	}
+
+	var errs Errors
+	if utf8.RuneCountInString(content) > p.MaxPostRunes {
+		errs = append(errs, tooLong(field, p.MaxPostRunes))
+	}
+	if !utf8.ValidString(content) {
+		errs = append(errs, &FieldError{Field: field, Code: CodeInvalidChars, err: ErrInvalid,
+			Message: "текст не является корректной строкой UTF-8"})
+	} else if i := strings.IndexFunc(content, func(r rune) bool {
+		return unicode.IsControl(r) && r != '\n' && r != '\t'
+	}); i >= 0 {
+		errs = append(errs, &FieldError{Field: field, Code: CodeInvalidChars, err: ErrInvalid,
+			Message: fmt.Sprintf("недопустимый управляющий символ в позиции %d", utf8.RuneCountInString(content[:i]))})
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+	return content, nil
+}
+
+// Пределы длины полей профиля в символах
+const (
+	MaxDisplayNameRunes = 50
+	MaxBioRunes         = 160
+	MaxLocationRunes    = 30
+	MaxWebsiteRunes     = 100
+)
+
+// ProfileFields - изменяемые поля профиля; nil - поле не меняется,
+// пустая строка очищает поле
+type ProfileFields struct {
+	DisplayName *string
+	Bio         *string
+	Location    *string
+	Website     *string
+}
+
+// Profile проверяет поля профиля и возвращает их в форме NFC без пробелов
+// по краям. Перевод строки допустим только в bio, website - абсолютный
+// адрес http или https.
+func (p *Policy) Profile(f ProfileFields) (ProfileFields, error) {
+	var errs Errors
+	check := func(field string, value *string, max int, multiline bool) *string {
+		if value == nil {
+			return nil
+		}
+		if len(*value) > max*utf8.UTFMax+64 {
+			errs = append(errs, tooLong(field, max)) // не нормализуем заведомо длинный ввод
+			return nil
+		}
+		v := norm.NFC.String(strings.TrimSpace(*value))
+		switch {
+		case utf8.RuneCountInString(v) > max:
+			errs = append(errs, tooLong(field, max))
+		case !utf8.ValidString(v):
+			errs = append(errs, &FieldError{Field: field, Code: CodeInvalidChars, err: ErrInvalid,
+				Message: "текст не является корректной строкой UTF-8"})
+		case strings.IndexFunc(v, func(r rune) bool {
+			return unicode.IsControl(r) && !(multiline && r == '\n')
+		}) >= 0:
+			errs = append(errs, &FieldError{Field: field, Code: CodeInvalidChars, err: ErrInvalid,
+				Message: "недопустимый управляющий символ"})
+		}
+		return &v
+	}
+
+	out := ProfileFields{
+		DisplayName: check("display_name", f.DisplayName, MaxDisplayNameRunes, false),
+		Bio:         check("bio", f.Bio, MaxBioRunes, true),
+		Location:    check("location", f.Location, MaxLocationRunes, false),
+		Website:     check("website", f.Website, MaxWebsiteRunes, false),
+	}
+	if out.Website != nil && *out.Website != "" {
+		u, err := url.Parse(*out.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, &FieldError{Field: "website", Code: CodeInvalidValue, err: ErrInvalid,
+				Message: "ожидается абсолютный адрес http или https"})
+		}
+	}
+	if len(errs) > 0 {
+		return ProfileFields{}, errs
+	}
+	return out, nil
+}
+
+// NewFieldError создает нарушение для поля, проверяемого вне этого пакета;
+// ошибка распознается через errors.Is(err, ErrInvalid)
+func NewFieldError(field, code, message string) *FieldError {
+	return &FieldError{Field: field, Code: code, Message: message, err: ErrInvalid}
+}
+
+func tooLong(field string, max int) *FieldError {
+	return &FieldError{Field: field, Code: CodeTooLong, err: ErrInvalid,
+		Message: fmt.Sprintf("должно содержать не больше %d символов", max)}
+}
+
+// homoglyphs - кириллические буквы, неотличимые на вид от латинских
+var homoglyphs = strings.NewReplacer(
+	"а", "a", "в", "b", "е", "e", "ё", "e", "к", "k", "м", "m", "н", "h", "о", "o",
+	"р", "p", "с", "c", "т", "t", "у", "y", "х", "x", "і", "i", "ј", "j", "ѕ", "s", "ԁ", "d",
+)
+
+// CanonicalUsername возвращает ключ уникальности имени: регистр не учитывается,
+// совместимые символы приводятся к одному виду (NFKC), а похожие кириллические
+// буквы заменяются латинскими, поэтому "Admin", "admin" и "аdmin" с кириллической
+// "а" дают один и тот же ключ.
+func CanonicalUsername(name string) string {
+	folded := cases.Fold().String(norm.NFKC.String(strings.TrimSpace(name)))
+	return homoglyphs.Replace(folded)
+}
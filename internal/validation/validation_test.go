@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestUsername проверяет правила для имени пользователя
+func TestUsername(t *testing.T) {
+	p := DefaultPolicy()
+	tests := []struct {
+		name string
+		in   string
+		code string // пусто - имя допустимо
+	}{
+		{"латиница", "alice_1", ""},
+		{"кириллица", "Вася", ""},
+		{"пробелы", "   ", CodeRequired},
+		{"коротко", "ab", CodeTooShort},
+		{"длинно", strings.Repeat("a", 33), CodeTooLong},
+		{"цифра в начале", "1alice", CodeInvalidChars},
+		{"управляющий символ", "ali\x00ce", CodeInvalidChars},
+		{"смешение алфавитов", "pаypal", CodeMixedScripts}, // кириллическая "а"
+		{"зарезервировано", "Admin", CodeReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Username(tt.in)
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("Ожидали допустимое имя, получили %v", err)
+				}
+				return
+			}
+			var fields Errors
+			if !errors.As(err, &fields) || fields[0].Code != tt.code || fields[0].Field != "username" {
+				t.Errorf("Ожидали код %s, получили %v", tt.code, err)
+			}
+		})
+	}
+
+	// NFC: "й" из двух кодовых точек приводится к одной
+	if name, err := p.Username("  Андре\u0438\u0306  "); err != nil || name != "Андрей" {
+		t.Errorf("Ожидали нормализованное имя Андрей, получили %q (%v)", name, err)
+	}
+	if _, err := p.Username(""); !errors.Is(err, ErrEmptyUsername) {
+		t.Errorf("Ожидали ErrEmptyUsername, получили %v", err)
+	}
+}
+
+// TestPostContent проверяет длину в символах и допустимые символы текста
+func TestPostContent(t *testing.T) {
+	p, err := NewPolicy(Policy{MaxPostRunes: 5, UsernameMinRunes: 1, UsernameMaxRunes: 10})
+	if err != nil {
+		t.Fatalf("Ошибка создания правил: %v", err)
+	}
+
+	if _, err := p.PostContent("Привет"); err == nil {
+		t.Error("6 символов при лимите 5 должны быть ошибкой")
+	}
+	if _, err := p.PostContent("Приве"); err != nil {
+		t.Errorf("5 кириллических символов (10 байт) допустимы: %v", err)
+	}
+	if _, err := p.PostContent(" \n\t "); !errors.Is(err, ErrEmptyContent) {
+		t.Errorf("Ожидали ErrEmptyContent, получили %v", err)
+	}
+	if _, err := p.PostContent("a\x07b"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Управляющий символ должен быть ошибкой, получили %v", err)
+	}
+	if _, err := p.PostContent(strings.Repeat("x", 10<<20)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Пост в 10 МБ должен быть отклонен, получили %v", err)
+	}
+}
+
+// TestCanonicalUsername проверяет ключ уникальности имен
+func TestCanonicalUsername(t *testing.T) {
+	want := CanonicalUsername("admin")
+	for _, name := range []string{"Admin", "ADMIN", "аdmin", "ａｄｍｉｎ"} {
+		if got := CanonicalUsername(name); got != want {
+			t.Errorf("CanonicalUsername(%q) = %q, ожидали %q", name, got, want)
+		}
+	}
+}
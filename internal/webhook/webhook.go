@@ -0,0 +1,285 @@
+// Package webhook отправляет подписанные HMAC события на адреса внешних
+// сервисов и решает, когда повторить неудавшуюся попытку. Сама пауза между
+// попытками выдерживается очередью, а не отправителем.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+	"github.com/Cere6rum/MicroBlog2/internal/tracing"
+)
+
+// Заголовки запроса доставки
+const (
+	HeaderEvent     = "X-MicroBlog-Event"     // тип события
+	HeaderDelivery  = "X-MicroBlog-Delivery"  // ID доставки, одинаковый во всех попытках
+	HeaderTimestamp = "X-MicroBlog-Timestamp" // время отправки, Unix-секунды
+	HeaderSignature = "X-MicroBlog-Signature" // sha256=<hex HMAC от "timestamp.body">
+)
+
+// ErrForbiddenAddress - адрес получателя находится во внутренней сети
+var ErrForbiddenAddress = errors.New("адрес получателя во внутренней сети запрещен")
+
+// Sign возвращает подпись тела body: HMAC-SHA256 с ключом secret от
+// строки "timestamp.body". Метка времени в подписи не дает повторно
+// использовать перехваченный запрос.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись за постоянное время (для получателей и тестов)
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Config - параметры доставки
+type Config struct {
+	Timeout     time.Duration // таймаут одной попытки
+	MaxAttempts int           // попыток на одну доставку
+	Backoff     time.Duration // пауза перед второй попыткой, дальше удваивается
+	MaxBackoff  time.Duration // предел паузы (в том числе из Retry-After)
+	// DisableAfter - после стольких неудавшихся доставок подряд вебхук
+	// отключается (0 - не отключать)
+	DisableAfter int
+	// AllowPrivate разрешает доставку на loopback, частные и link-local адреса
+	// (локальная разработка и тесты)
+	AllowPrivate bool
+}
+
+// Validate проверяет параметры доставки
+func (c Config) Validate() error {
+	var errs []error
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("timeout должен быть положительным, получено %s", c.Timeout))
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("max_attempts должно быть не меньше 1, получено %d", c.MaxAttempts))
+	}
+	if c.Backoff <= 0 {
+		errs = append(errs, fmt.Errorf("backoff должен быть положительным, получено %s", c.Backoff))
+	}
+	if c.MaxBackoff < c.Backoff {
+		errs = append(errs, fmt.Errorf("max_backoff (%s) меньше backoff (%s)", c.MaxBackoff, c.Backoff))
+	}
+	if c.DisableAfter < 0 {
+		errs = append(errs, fmt.Errorf("disable_after не может быть отрицательным"))
+	}
+	return errors.Join(errs...)
+}
+
+// Attempt - результат одной попытки доставки
+type Attempt struct {
+	Number     int
+	StatusCode int // 0 - ответ не получен
+	Err        error
+	Duration   time.Duration
+	RetryAfter time.Duration // пауза, запрошенная получателем в Retry-After
+}
+
+// Sender отправляет доставки вебхуков
+type Sender struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewSender создает отправителя. Перенаправления не выполняются: ответ
+// 3xx считается неудачей, чтобы подписанное тело не ушло на другой адрес.
+// Адрес проверяется после разрешения имени, при подключении: так имя,
+// указывающее на внутреннюю сеть, не обходит проверку, даже если DNS
+// отвечает по-разному при создании вебхука и при доставке.
+func NewSender(cfg Config) *Sender {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = checkAddress
+	}
+	return &Sender{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				// Без прокси: подключение через него не проверяло бы адрес получателя
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: cfg.Timeout,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// deniedPrefixes - адреса специального назначения из реестров IANA
+// (RFC 6890 и последующие): внутренние сети, loopback, link-local,
+// документация, тестовые и зарезервированные диапазоны. Проверка по
+// явному списку, а не по предикатам net.IP, которые пропускают, например,
+// CGNAT и сети для тестов производительности.
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "эта" сеть
+	netip.MustParsePrefix("10.0.0.0/8"),      // частная сеть
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, в том числе метаданные облаков
+	netip.MustParsePrefix("172.16.0.0/12"),   // частная сеть
+	netip.MustParsePrefix("192.0.0.0/24"),    // назначения протоколов IETF
+	netip.MustParsePrefix("192.0.2.0/24"),    // документация
+	netip.MustParsePrefix("192.88.99.0/24"),  // ретрансляторы 6to4
+	netip.MustParsePrefix("192.168.0.0/16"),  // частная сеть
+	netip.MustParsePrefix("198.18.0.0/15"),   // тесты производительности
+	netip.MustParsePrefix("198.51.100.0/24"), // документация
+	netip.MustParsePrefix("203.0.113.0/24"),  // документация
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // зарезервировано, broadcast
+
+	netip.MustParsePrefix("::/128"),         // неопределенный адрес
+	netip.MustParsePrefix("::1/128"),        // loopback
+	netip.MustParsePrefix("64:ff9b:1::/48"), // локальный NAT64
+	netip.MustParsePrefix("100::/64"),       // discard
+	netip.MustParsePrefix("2001::/23"),      // назначения протоколов IETF, Teredo
+	netip.MustParsePrefix("2001:db8::/32"),  // документация
+	netip.MustParsePrefix("2002::/16"),      // 6to4
+	netip.MustParsePrefix("fc00::/7"),       // уникальные локальные адреса
+	netip.MustParsePrefix("fe80::/10"),      // link-local
+	netip.MustParsePrefix("fec0::/10"),      // site-local (устарели)
+	netip.MustParsePrefix("ff00::/8"),       // multicast
+}
+
+// nat64Prefix - общий префикс NAT64: в последних 4 байтах адрес IPv4,
+// который и проверяется
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// checkAddress запрещает подключение к адресам специального назначения
+// (deniedPrefixes), в том числе записанным как IPv4-mapped или NAT64
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if deniedAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
+
+// deniedAddr сообщает, входит ли адрес в deniedPrefixes
+func deniedAddr(ip netip.Addr) bool {
+	ip = ip.WithZone("").Unmap()
+	if nat64Prefix.Contains(ip) {
+		b := ip.As16()
+		ip = netip.AddrFrom4([4]byte(b[12:]))
+	}
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config возвращает параметры доставки
+func (s *Sender) Config() Config {
+	return s.cfg
+}
+
+// Deliver выполняет очередную попытку доставки d на url. Номер попытки -
+// d.Attempt+1; повторять ли неудавшуюся попытку, решает RetryDelay.
+func (s *Sender) Deliver(ctx context.Context, url, secret string, d models.WebhookDelivery) Attempt {
+	started := time.Now()
+	status, retryAfter, err := s.send(ctx, url, secret, d)
+	return Attempt{
+		Number:     d.Attempt + 1,
+		StatusCode: status,
+		Err:        err,
+		Duration:   time.Since(started),
+		RetryAfter: retryAfter,
+	}
+}
+
+// RetryDelay возвращает паузу перед следующей попыткой, если неудавшуюся
+// попытку a стоит повторить: при сетевой ошибке, 408, 429 и 5xx, пока не
+// исчерпаны MaxAttempts. Пауза удваивается с каждой попыткой, начиная с
+// Backoff; Retry-After получателя имеет приоритет. Обе ограничены MaxBackoff.
+func (s *Sender) RetryDelay(a Attempt) (time.Duration, bool) {
+	if a.Err == nil || a.Number >= s.cfg.MaxAttempts || !retryable(a.StatusCode) {
+		return 0, false
+	}
+	if a.RetryAfter > 0 {
+		return min(a.RetryAfter, s.cfg.MaxBackoff), true
+	}
+	delay := s.cfg.Backoff
+	for i := 1; i < a.Number && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.cfg.MaxBackoff), true
+}
+
+// send выполняет одну попытку и возвращает код ответа и паузу из Retry-After
+func (s *Sender) send(ctx context.Context, url, secret string, d models.WebhookDelivery) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MicroBlog-Webhook/1")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(d.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, d.Body))
+	for k, v := range tracing.Inject(ctx) {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	// Тело ответа не нужно, но дочитываем его, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, 0, nil
+	}
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+	return resp.StatusCode, retryAfter, fmt.Errorf("получатель ответил %d", resp.StatusCode)
+}
+
+// retryable сообщает, имеет ли смысл повторить попытку с таким кодом ответа
+func retryable(status int) bool {
+	switch {
+	case status == 0: // сетевая ошибка
+		return true
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= 500
+	}
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/models"
+)
+
+// TestSignVerify проверяет подпись тела и ее привязку к метке времени
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"post.created"}`)
+	sig := Sign("секретный-ключ-16", 1700000000, body)
+
+	if !Verify("секретный-ключ-16", 1700000000, body, sig) {
+		t.Error("Подпись не прошла проверку")
+	}
+	if Verify("другой-ключ-000000", 1700000000, body, sig) {
+		t.Error("Подпись прошла проверку с другим ключом")
+	}
+	if Verify("секретный-ключ-16", 1700000001, body, sig) {
+		t.Error("Подпись прошла проверку с другой меткой времени")
+	}
+	if Verify("секретный-ключ-16", 1700000000, []byte(`{}`), sig) {
+		t.Error("Подпись прошла проверку с другим телом")
+	}
+}
+
+// TestDeliver проверяет заголовки запроса и решение о повторе по коду ответа
+func TestDeliver(t *testing.T) {
+	var status atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if !Verify("secret", ts, body, r.Header.Get(HeaderSignature)) {
+			t.Error("Неверная подпись запроса")
+		}
+		if r.Header.Get(HeaderDelivery) != "7" || r.Header.Get(HeaderEvent) != "post.liked" {
+			t.Errorf("Неверные заголовки доставки: %v", r.Header)
+		}
+		if status.Load() == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "2")
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	sender := NewSender(Config{Timeout: time.Second, MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute, AllowPrivate: true})
+	d := models.WebhookDelivery{ID: 7, Event: "post.liked", Body: []byte(`{"likes":1}`)}
+
+	// Тест 1: успешная попытка не повторяется
+	status.Store(http.StatusNoContent)
+	a := sender.Deliver(context.Background(), srv.URL, "secret", d)
+	if a.Err != nil || a.StatusCode != 204 || a.Number != 1 {
+		t.Fatalf("Ожидали успешную первую попытку, получили %+v", a)
+	}
+	if _, retry := sender.RetryDelay(a); retry {
+		t.Error("Успешная попытка не должна повторяться")
+	}
+
+	// Тест 2: 500 повторяется, номер попытки берется из доставки
+	status.Store(http.StatusInternalServerError)
+	d.Attempt = 1
+	a = sender.Deliver(context.Background(), srv.URL, "secret", d)
+	if a.Err == nil || a.StatusCode != 500 || a.Number != 2 {
+		t.Fatalf("Ожидали неудачную вторую попытку, получили %+v", a)
+	}
+	if delay, retry := sender.RetryDelay(a); !retry || delay != 2*time.Second {
+		t.Errorf("Ожидали повтор через 2s, получили %s, %v", delay, retry)
+	}
+
+	// Тест 3: Retry-After получателя важнее собственной паузы
+	status.Store(http.StatusTooManyRequests)
+	d.Attempt = 0
+	a = sender.Deliver(context.Background(), srv.URL, "secret", d)
+	if delay, retry := sender.RetryDelay(a); !retry || delay != 2*time.Second {
+		t.Errorf("Ожидали повтор через 2s из Retry-After, получили %s, %v", delay, retry)
+	}
+
+	// Тест 4: 400 не повторяется
+	status.Store(http.StatusBadRequest)
+	a = sender.Deliver(context.Background(), srv.URL, "secret", d)
+	if _, retry := sender.RetryDelay(a); a.Err == nil || retry {
+		t.Errorf("Ответ 400 не должен повторяться, получили %+v", a)
+	}
+}
+
+// TestRetryDelay проверяет удвоение паузы, ее предел и число попыток
+func TestRetryDelay(t *testing.T) {
+	sender := NewSender(Config{Timeout: time.Second, MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second})
+	failed := errors.New("получатель ответил 503")
+
+	for _, tc := range []struct {
+		attempt Attempt
+		delay   time.Duration
+		retry   bool
+	}{
+		{Attempt{Number: 1, StatusCode: 503, Err: failed}, time.Second, true},
+		{Attempt{Number: 2, Err: failed}, 2 * time.Second, true},
+		{Attempt{Number: 3, StatusCode: 503, Err: failed}, 4 * time.Second, true},
+		{Attempt{Number: 4, StatusCode: 503, Err: failed}, 5 * time.Second, true},
+		{Attempt{Number: 2, StatusCode: 503, Err: failed, RetryAfter: time.Hour}, 5 * time.Second, true},
+		{Attempt{Number: 5, StatusCode: 503, Err: failed}, 0, false},
+		{Attempt{Number: 1, StatusCode: 404, Err: failed}, 0, false},
+	} {
+		if delay, retry := sender.RetryDelay(tc.attempt); delay != tc.delay || retry != tc.retry {
+			t.Errorf("Для %+v ожидали %s, %v, получили %s, %v", tc.attempt, tc.delay, tc.retry, delay, retry)
+		}
+	}
+}
+
+// TestPrivateAddress проверяет, что без AllowPrivate доставка во внутреннюю
+// сеть отклоняется при подключении, а не после ответа
+func TestPrivateAddress(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	sender := NewSender(Config{Timeout: time.Second, MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute})
+	// Имя localhost разрешается в loopback уже после проверки URL
+	url := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	for _, target := range []string{srv.URL, url} {
+		a := sender.Deliver(context.Background(), target, "secret", models.WebhookDelivery{ID: 1})
+		if !errors.Is(a.Err, ErrForbiddenAddress) {
+			t.Errorf("Ожидали ErrForbiddenAddress для %s, получили %v", target, a.Err)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Запрос не должен дойти до получателя, дошло %d", calls.Load())
+	}
+
+	for _, address := range []string{
+		"10.0.0.1:80", "192.168.1.1:443", "169.254.169.254:80", "[::1]:80", "[fe80::1%eth0]:80", "0.0.0.0:80",
+		"0.1.2.3:80", "100.64.0.1:80", "198.18.0.1:80", "255.255.255.255:80", "[::ffff:127.0.0.1]:80",
+		"[64:ff9b::10.0.0.1]:80", "[64:ff9b::a9fe:a9fe]:80", "[fd00::1]:80", "[2002:a00:1::]:80",
+	} {
+		if err := checkAddress("tcp", address, nil); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Ожидали запрет адреса %s, получили %v", address, err)
+		}
+	}
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1::1]:443", "[64:ff9b::5db8:d822]:443"} {
+		if err := checkAddress("tcp", address, nil); err != nil {
+			t.Errorf("Публичный адрес %s не должен запрещаться: %v", address, err)
+		}
+	}
+}
@@ -0,0 +1,631 @@
+// Package client - типизированный Go-клиент HTTP API микроблога (/api/v1).
+// Клиент покрывает все маршруты из GET /routes, кроме потоковых (/stream
+// и /ws): для них нужен клиент SSE или WebSocket.
+//
+// Ошибки сервера возвращаются как *APIError и сравниваются с ошибками
+// пакета через errors.Is, например errors.Is(err, client.ErrUserNotFound).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIPrefix - префикс версии API, которую использует клиент
+const APIPrefix = "/api/v1"
+
+// Client - клиент API. Безопасен для конкурентного использования.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	adminToken string
+}
+
+// Option - необязательный параметр клиента
+type Option func(*Client)
+
+// WithHTTPClient задает HTTP-клиент (таймауты, транспорт)
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithRetries задает количество повторов и начальную паузу между ними
+// (пауза удваивается с каждой попыткой; заголовок Retry-After имеет приоритет)
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithAdminToken задает токен для административных методов (/admin/*),
+// DeleteUser и RenameUser
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// New создает клиент для сервера baseURL, например http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreatePostRequest - параметры нового поста; ReplyToID, QuoteOfID
+// и MediaIDs (ID из UploadMedia) необязательны
+type CreatePostRequest struct {
+	Username  string `json:"username"`
+	Content   string `json:"content"`
+	ReplyToID int    `json:"reply_to_id,omitempty"`
+	QuoteOfID int    `json:"quote_of_id,omitempty"`
+	MediaIDs  []int  `json:"media_ids,omitempty"`
+}
+
+// ThreadOptions - ограничения выдачи обсуждения (0 - значение сервера по умолчанию)
+type ThreadOptions struct {
+	Depth, Limit, Offset int
+}
+
+// ListUsersOptions - параметры страницы списка пользователей
+type ListUsersOptions struct {
+	Limit, Offset int
+}
+
+// ListAuditOptions - параметры страницы журнала аудита
+type ListAuditOptions struct {
+	Limit, Offset int
+}
+
+// MediaOptions - параметры запроса изображения
+type MediaOptions struct {
+	Thumbnail bool
+	AccessKey string // Media.AccessKey из UploadMedia; нужен, пока изображение не прикреплено к посту
+}
+
+// NotificationOptions - параметры страницы уведомлений
+type NotificationOptions struct {
+	UnreadOnly    bool
+	Limit, Offset int
+}
+
+// ProfileUpdate - изменяемые поля профиля: nil - не менять, пустая строка - очистить
+type ProfileUpdate struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	Location    *string `json:"location,omitempty"`
+	Website     *string `json:"website,omitempty"`
+}
+
+// WebhookRequest - параметры нового вебхука. Пустые Users и Tags - без
+// фильтра; пустой Secret - сервер сгенерирует ключ подписи сам.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Users  []string `json:"users,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// SearchOptions - параметры поиска
+type SearchOptions struct {
+	Query  string
+	Author string
+	Limit  int
+	Offset int
+}
+
+// RegisterUser регистрирует пользователя
+func (c *Client) RegisterUser(ctx context.Context, username string) (*User, error) {
+	var user User
+	err := c.do(ctx, http.MethodPost, "/register", nil, map[string]string{"username": username}, &user)
+	return &user, err
+}
+
+// ListUsers возвращает страницу пользователей в порядке регистрации
+func (c *Client) ListUsers(ctx context.Context, opts ListUsersOptions) (*UserList, error) {
+	q := url.Values{}
+	setInt(q, "limit", opts.Limit)
+	setInt(q, "offset", opts.Offset)
+	var list UserList
+	err := c.do(ctx, http.MethodGet, "/users", q, nil, &list)
+	return &list, err
+}
+
+// GetUser возвращает пользователя по имени (или по ID, если передать
+// его строкой) со счетчиками постов и лайков
+func (c *Client) GetUser(ctx context.Context, username string) (*UserView, error) {
+	var user UserView
+	err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(username), nil, nil, &user)
+	return &user, err
+}
+
+// RenameUser меняет имя пользователя. Запросы по прежнему имени сервер
+// перенаправляет на новое, и клиент следует редиректу сам. Нужен токен
+// администратора (WithAdminToken).
+func (c *Client) RenameUser(ctx context.Context, username, newName string) (*User, error) {
+	var user User
+	err := c.do(ctx, http.MethodPost, "/users/"+url.PathEscape(username)+"/rename", nil, map[string]string{"username": newName}, &user)
+	return &user, err
+}
+
+// DeleteUser удаляет аккаунт; mode - DeletePosts или AnonymizePosts,
+// пустой - посты удаляются. Нужен токен администратора (WithAdminToken).
+func (c *Client) DeleteUser(ctx context.Context, username, mode string) (*AuditEntry, error) {
+	q := url.Values{}
+	if mode != "" {
+		q.Set("posts", mode)
+	}
+	var entry AuditEntry
+	err := c.do(ctx, http.MethodDelete, "/users/"+url.PathEscape(username), q, nil, &entry)
+	return &entry, err
+}
+
+// UpdateProfile меняет поля профиля пользователя
+func (c *Client) UpdateProfile(ctx context.Context, username string, update ProfileUpdate) (*User, error) {
+	var user User
+	err := c.do(ctx, http.MethodPatch, "/users/"+url.PathEscape(username), nil, update, &user)
+	return &user, err
+}
+
+// UploadAvatar загружает аватар (JPEG, PNG или GIF)
+func (c *Client) UploadAvatar(ctx context.Context, username string, image []byte) (*User, error) {
+	var user User
+	err := c.send(ctx, http.MethodPut, userPath(username, "/avatar"), nil, image, "application/octet-stream", &user)
+	return &user, err
+}
+
+// GetAvatar возвращает копию аватара не меньше size пикселей (0 - наибольшую)
+func (c *Client) GetAvatar(ctx context.Context, username string, size int) (*Image, error) {
+	q := url.Values{}
+	setInt(q, "size", size)
+	var img Image
+	err := c.do(ctx, http.MethodGet, userPath(username, "/avatar"), q, nil, &img)
+	return &img, err
+}
+
+// DeleteAvatar удаляет аватар
+func (c *Client) DeleteAvatar(ctx context.Context, username string) error {
+	return c.do(ctx, http.MethodDelete, userPath(username, "/avatar"), nil, nil, nil)
+}
+
+// ListPosts возвращает ленту; у репостов и цитат встроен оригинал
+func (c *Client) ListPosts(ctx context.Context) ([]*PostView, error) {
+	var posts []*PostView
+	err := c.do(ctx, http.MethodGet, "/posts", nil, nil, &posts)
+	return posts, err
+}
+
+// CreatePost создает пост, ответ или цитату
+func (c *Client) CreatePost(ctx context.Context, req CreatePostRequest) (*Post, error) {
+	var post Post
+	err := c.do(ctx, http.MethodPost, "/posts", nil, req, &post)
+	return &post, err
+}
+
+// EditPost меняет текст поста
+func (c *Client) EditPost(ctx context.Context, postID int, username, content string) (*Post, error) {
+	var post Post
+	body := map[string]string{"username": username, "content": content}
+	err := c.do(ctx, http.MethodPatch, postPath(postID, ""), nil, body, &post)
+	return &post, err
+}
+
+// DeletePost удаляет пост вместе с его репостами; удалить может только автор
+func (c *Client) DeletePost(ctx context.Context, postID int, username string) error {
+	return c.do(ctx, http.MethodDelete, postPath(postID, ""), nil, map[string]string{"username": username}, nil)
+}
+
+// LikePost ставит лайк; сервер обрабатывает его асинхронно
+func (c *Client) LikePost(ctx context.Context, postID int, username string) error {
+	return c.do(ctx, http.MethodPost, postPath(postID, "/like"), nil, map[string]string{"username": username}, nil)
+}
+
+// Repost делает репост
+func (c *Client) Repost(ctx context.Context, postID int, username string) (*Post, error) {
+	var post Post
+	err := c.do(ctx, http.MethodPost, postPath(postID, "/repost"), nil, map[string]string{"username": username}, &post)
+	return &post, err
+}
+
+// PostHistory возвращает версии поста, начиная с исходной
+func (c *Client) PostHistory(ctx context.Context, postID int) ([]*PostRevision, error) {
+	var history []*PostRevision
+	err := c.do(ctx, http.MethodGet, postPath(postID, "/history"), nil, nil, &history)
+	return history, err
+}
+
+// Thread возвращает обсуждение, в которое входит пост
+func (c *Client) Thread(ctx context.Context, postID int, opts ThreadOptions) (*Thread, error) {
+	q := url.Values{}
+	setInt(q, "depth", opts.Depth)
+	setInt(q, "limit", opts.Limit)
+	setInt(q, "offset", opts.Offset)
+	var thread Thread
+	err := c.do(ctx, http.MethodGet, postPath(postID, "/thread"), q, nil, &thread)
+	return &thread, err
+}
+
+// UploadMedia загружает изображения для будущего поста; их ID передаются
+// в CreatePostRequest.MediaIDs
+func (c *Client) UploadMedia(ctx context.Context, username string, images ...[]byte) ([]*Media, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for i, data := range images {
+		part, err := mw.CreateFormFile("file", "image"+strconv.Itoa(i+1))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var media []*Media
+	q := url.Values{"username": {username}}
+	err := c.send(ctx, http.MethodPost, "/media", q, buf.Bytes(), mw.FormDataContentType(), &media)
+	return media, err
+}
+
+// GetMedia возвращает изображение или его миниатюру. Изображение, еще не
+// прикрепленное к посту, сервер отдает только по ключу opts.AccessKey.
+func (c *Client) GetMedia(ctx context.Context, id int, opts MediaOptions) (*Image, error) {
+	path := "/media/" + strconv.Itoa(id)
+	if opts.Thumbnail {
+		path += "/thumbnail"
+	}
+	q := url.Values{}
+	if opts.AccessKey != "" {
+		q.Set("key", opts.AccessKey)
+	}
+	var img Image
+	err := c.do(ctx, http.MethodGet, path, q, nil, &img)
+	return &img, err
+}
+
+// PostsByTag возвращает посты с хэштегом
+func (c *Client) PostsByTag(ctx context.Context, tag string) ([]*Post, error) {
+	var posts []*Post
+	err := c.do(ctx, http.MethodGet, "/tags/"+url.PathEscape(strings.TrimPrefix(tag, "#"))+"/posts", nil, nil, &posts)
+	return posts, err
+}
+
+// Mentions возвращает посты с упоминанием пользователя
+func (c *Client) Mentions(ctx context.Context, username string) ([]*Post, error) {
+	var posts []*Post
+	err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(username)+"/mentions", nil, nil, &posts)
+	return posts, err
+}
+
+// Follow подписывает follower на пользователя username
+func (c *Client) Follow(ctx context.Context, username, follower string) error {
+	return c.do(ctx, http.MethodPost, userPath(username, "/follow"), nil, map[string]string{"username": follower}, nil)
+}
+
+// Unfollow отменяет подписку follower на пользователя username
+func (c *Client) Unfollow(ctx context.Context, username, follower string) error {
+	q := url.Values{"username": {follower}}
+	return c.do(ctx, http.MethodDelete, userPath(username, "/follow"), q, nil, nil)
+}
+
+// Followers возвращает страницу подписчиков пользователя в порядке подписки
+func (c *Client) Followers(ctx context.Context, username string, opts ListUsersOptions) (*UserList, error) {
+	return c.follows(ctx, userPath(username, "/followers"), opts)
+}
+
+// Following возвращает страницу пользователей, на которых подписан username
+func (c *Client) Following(ctx context.Context, username string, opts ListUsersOptions) (*UserList, error) {
+	return c.follows(ctx, userPath(username, "/following"), opts)
+}
+
+func (c *Client) follows(ctx context.Context, path string, opts ListUsersOptions) (*UserList, error) {
+	q := url.Values{}
+	setInt(q, "limit", opts.Limit)
+	setInt(q, "offset", opts.Offset)
+	var list UserList
+	err := c.do(ctx, http.MethodGet, path, q, nil, &list)
+	return &list, err
+}
+
+// CreateWebhook регистрирует вебхук пользователя. Ключ подписи
+// возвращается только в этом ответе.
+func (c *Client) CreateWebhook(ctx context.Context, username string, req WebhookRequest) (*Webhook, error) {
+	var hook Webhook
+	err := c.do(ctx, http.MethodPost, userPath(username, "/webhooks"), nil, req, &hook)
+	return &hook, err
+}
+
+// ListWebhooks возвращает вебхуки пользователя
+func (c *Client) ListWebhooks(ctx context.Context, username string) ([]*Webhook, error) {
+	var hooks []*Webhook
+	err := c.do(ctx, http.MethodGet, userPath(username, "/webhooks"), nil, nil, &hooks)
+	return hooks, err
+}
+
+// DeleteWebhook удаляет вебхук
+func (c *Client) DeleteWebhook(ctx context.Context, username string, id int) error {
+	return c.do(ctx, http.MethodDelete, webhookPath(username, id, ""), nil, nil, nil)
+}
+
+// EnableWebhook включает вебхук, отключенный после неудачных доставок
+func (c *Client) EnableWebhook(ctx context.Context, username string, id int) (*Webhook, error) {
+	var hook Webhook
+	err := c.do(ctx, http.MethodPost, webhookPath(username, id, "/enable"), nil, nil, &hook)
+	return &hook, err
+}
+
+// WebhookDeliveries возвращает журнал попыток доставки вебхуку
+func (c *Client) WebhookDeliveries(ctx context.Context, username string, id int) ([]*WebhookAttempt, error) {
+	var attempts []*WebhookAttempt
+	err := c.do(ctx, http.MethodGet, webhookPath(username, id, "/deliveries"), nil, nil, &attempts)
+	return attempts, err
+}
+
+// Notifications возвращает страницу уведомлений пользователя
+func (c *Client) Notifications(ctx context.Context, username string, opts NotificationOptions) (*NotificationList, error) {
+	q := url.Values{"username": {username}}
+	if opts.UnreadOnly {
+		q.Set("unread", "true")
+	}
+	setInt(q, "limit", opts.Limit)
+	setInt(q, "offset", opts.Offset)
+	var list NotificationList
+	err := c.do(ctx, http.MethodGet, "/notifications", q, nil, &list)
+	return &list, err
+}
+
+// MarkNotificationsRead отмечает уведомления прочитанными (без ids - все)
+// и возвращает число оставшихся непрочитанных
+func (c *Client) MarkNotificationsRead(ctx context.Context, username string, ids ...int) (int, error) {
+	body := struct {
+		Username string `json:"username"`
+		IDs      []int  `json:"ids,omitempty"`
+	}{username, ids}
+	var resp struct {
+		Unread int `json:"unread"`
+	}
+	err := c.do(ctx, http.MethodPost, "/notifications/read", nil, body, &resp)
+	return resp.Unread, err
+}
+
+// NotificationPreferences возвращает настройки уведомлений: тип -> включен
+func (c *Client) NotificationPreferences(ctx context.Context, username string) (map[string]bool, error) {
+	var prefs map[string]bool
+	err := c.do(ctx, http.MethodGet, "/notifications/preferences", url.Values{"username": {username}}, nil, &prefs)
+	return prefs, err
+}
+
+// SetNotificationPreferences меняет переданные настройки уведомлений
+// и возвращает настройки целиком
+func (c *Client) SetNotificationPreferences(ctx context.Context, username string, prefs map[string]bool) (map[string]bool, error) {
+	body := struct {
+		Username    string          `json:"username"`
+		Preferences map[string]bool `json:"preferences"`
+	}{username, prefs}
+	var updated map[string]bool
+	err := c.do(ctx, http.MethodPatch, "/notifications/preferences", nil, body, &updated)
+	return updated, err
+}
+
+// Search выполняет полнотекстовый поиск
+func (c *Client) Search(ctx context.Context, opts SearchOptions) (*SearchResult, error) {
+	q := url.Values{"q": {opts.Query}}
+	if opts.Author != "" {
+		q.Set("author", opts.Author)
+	}
+	setInt(q, "limit", opts.Limit)
+	setInt(q, "offset", opts.Offset)
+	var result SearchResult
+	err := c.do(ctx, http.MethodGet, "/search", q, nil, &result)
+	return &result, err
+}
+
+// Trending возвращает популярные хэштеги и посты
+func (c *Client) Trending(ctx context.Context, limit int) (*Trending, error) {
+	q := url.Values{}
+	setInt(q, "limit", limit)
+	var trending Trending
+	err := c.do(ctx, http.MethodGet, "/trending", q, nil, &trending)
+	return &trending, err
+}
+
+// Routes возвращает таблицу маршрутов сервера
+func (c *Client) Routes(ctx context.Context) ([]Route, error) {
+	var routes []Route
+	err := c.do(ctx, http.MethodGet, "/routes", nil, nil, &routes)
+	return routes, err
+}
+
+// QueueStats возвращает состояние очередей сервера по имени
+func (c *Client) QueueStats(ctx context.Context) (map[string]QueueStats, error) {
+	var stats map[string]QueueStats
+	err := c.do(ctx, http.MethodGet, "/admin/queues", nil, nil, &stats)
+	return stats, err
+}
+
+// ReplayDeadLetters возвращает недоставленные события очереди на обработку
+// и сообщает их количество
+func (c *Client) ReplayDeadLetters(ctx context.Context, queueName string) (int, error) {
+	var resp struct {
+		Replayed int `json:"replayed"`
+	}
+	err := c.do(ctx, http.MethodPost, "/admin/queues/"+url.PathEscape(queueName)+"/dlq/replay", nil, nil, &resp)
+	return resp.Replayed, err
+}
+
+// ListAudit возвращает страницу журнала аудита (нужен WithAdminToken)
+func (c *Client) ListAudit(ctx context.Context, opts ListAuditOptions) (*AuditList, error) {
+	q := url.Values{}
+	setInt(q, "limit", opts.Limit)
+	setInt(q, "offset", opts.Offset)
+	var list AuditList
+	err := c.do(ctx, http.MethodGet, "/admin/audit", q, nil, &list)
+	return &list, err
+}
+
+// do выполняет запрос с телом in в JSON. Ответ разбирается в out как JSON,
+// а в *Image записывается как есть.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("кодирование запроса: %w", err)
+		}
+	}
+	return c.send(ctx, method, path, query, body, "application/json", out)
+}
+
+// send выполняет запрос с готовым телом и повторами. Безопасные методы (GET)
+// повторяются при сетевых ошибках и ответах 429/502/503/504; остальные -
+// только при 429 и 503, когда сервер гарантированно не начал обработку.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body []byte, contentType string, out any) error {
+	u := c.baseURL + APIPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.once(ctx, method, u, body, contentType, out)
+		if err == nil || attempt >= c.maxRetries || !c.shouldRetry(method, err) {
+			return err
+		}
+
+		wait := delay
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		delay *= 2
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) shouldRetry(method string, err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		// Сетевая ошибка: запрос мог дойти до сервера
+		return method == http.MethodGet
+	}
+	if method == http.MethodGet {
+		return IsRetryable(err)
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// once выполняет одну попытку и возвращает паузу из Retry-After (если есть)
+func (c *Client) once(ctx context.Context, method, u string, body []byte, contentType string, out any) (time.Duration, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	img, isImage := out.(*Image)
+	if !isImage {
+		req.Header.Set("Accept", "application/json")
+	}
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var retryAfter time.Duration
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+			retryAfter = time.Duration(s) * time.Second
+		}
+		return retryAfter, decodeError(resp)
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+	if isImage {
+		img.ContentType = resp.Header.Get("Content-Type")
+		if img.Data, err = io.ReadAll(resp.Body); err != nil {
+			return 0, fmt.Errorf("чтение ответа %s %s: %w", method, u, err)
+		}
+		return 0, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("разбор ответа %s %s: %w", method, u, err)
+	}
+	return 0, nil
+}
+
+// decodeError читает тело ответа с ошибкой: текст или JSON с ошибками полей
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		var body struct {
+			Error  string        `json:"error"`
+			Fields []*FieldError `json:"fields"`
+		}
+		if json.Unmarshal(data, &body) == nil && body.Error != "" {
+			apiErr.Message = body.Error
+			apiErr.Fields = body.Fields
+		}
+	}
+	return apiErr
+}
+
+func postPath(id int, suffix string) string {
+	return "/posts/" + strconv.Itoa(id) + suffix
+}
+
+func userPath(username, suffix string) string {
+	return "/users/" + url.PathEscape(username) + suffix
+}
+
+func webhookPath(username string, id int, suffix string) string {
+	return userPath(username, "/webhooks/"+strconv.Itoa(id)+suffix)
+}
+
+func setInt(q url.Values, key string, v int) {
+	if v > 0 {
+		q.Set(key, strconv.Itoa(v))
+	}
+}
@@ -0,0 +1,313 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/parser"
+	"go/token"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Cere6rum/MicroBlog2/internal/blob"
+	"github.com/Cere6rum/MicroBlog2/internal/handlers"
+	"github.com/Cere6rum/MicroBlog2/internal/logger"
+	"github.com/Cere6rum/MicroBlog2/internal/queue"
+	"github.com/Cere6rum/MicroBlog2/internal/service"
+)
+
+// TestClient проверяет клиент против настоящих обработчиков
+func TestClient(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	mux := http.NewServeMux()
+	handlers.NewMicroBlogHandler(service.NewMicroBlogService(log, queue.NewLikeQueue(10, 1))).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL, WithRetries(0, 0))
+
+	// Тест 1: регистрация и повторная регистрация
+	if _, err := c.RegisterUser(ctx, "alice"); err != nil {
+		t.Fatalf("Ошибка регистрации: %v", err)
+	}
+	if _, err := c.RegisterUser(ctx, "alice"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Ожидали ErrUserExists, получили %v", err)
+	}
+
+	// Тест 2: ошибка проверки ввода сопоставляется с ошибкой сервиса
+	_, err := c.RegisterUser(ctx, "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || !errors.Is(err, ErrEmptyUsername) {
+		t.Errorf("Ожидали 400 с ErrEmptyUsername, получили %v", err)
+	}
+
+	// Тест 3: пост, редактирование и история
+	post, err := c.CreatePost(ctx, CreatePostRequest{Username: "alice", Content: "привет #go"})
+	if err != nil {
+		t.Fatalf("Ошибка создания поста: %v", err)
+	}
+	if _, err := c.EditPost(ctx, post.ID, "alice", "привет, мир #go"); err != nil {
+		t.Fatalf("Ошибка редактирования: %v", err)
+	}
+	history, err := c.PostHistory(ctx, post.ID)
+	if err != nil || len(history) != 2 {
+		t.Errorf("Ожидали 2 версии, получили %d (%v)", len(history), err)
+	}
+	if _, err := c.PostHistory(ctx, 999); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Ожидали ErrPostNotFound, получили %v", err)
+	}
+
+	// Тест 4: лента и хэштеги
+	posts, err := c.ListPosts(ctx)
+	if err != nil || len(posts) != 1 {
+		t.Errorf("Ожидали 1 пост в ленте, получили %d (%v)", len(posts), err)
+	}
+	tagged, err := c.PostsByTag(ctx, "#go")
+	if err != nil || len(tagged) != 1 {
+		t.Errorf("Ожидали 1 пост с #go, получили %d (%v)", len(tagged), err)
+	}
+
+	// Тест 5: таблица маршрутов
+	routes, err := c.Routes(ctx)
+	if err != nil || len(routes) == 0 {
+		t.Errorf("Ожидали непустую таблицу маршрутов, получили %d (%v)", len(routes), err)
+	}
+}
+
+// TestClientExtended проверяет профиль, аватар, изображения, уведомления,
+// вебхуки и журнал аудита
+func TestClientExtended(t *testing.T) {
+	log, _ := logger.NewLogger("test.log")
+	defer func() {
+		if err := log.Close(); err != nil {
+			t.Errorf("Ошибка закрытия логгера: %v", err)
+		}
+	}()
+	store, err := blob.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+	svc := service.NewMicroBlogService(log, queue.NewLikeQueue(10, 1), service.WithBlobStore(store))
+	mux := http.NewServeMux()
+	handlers.NewMicroBlogHandler(svc, handlers.WithAdminToken("secret")).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL, WithRetries(0, 0))
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := c.RegisterUser(ctx, name); err != nil {
+			t.Fatalf("Ошибка регистрации: %v", err)
+		}
+	}
+	var png1 bytes.Buffer
+	if err := png.Encode(&png1, image.NewGray(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatalf("Ошибка кодирования PNG: %v", err)
+	}
+
+	// Тест 1: профиль и аватар
+	bio := "о себе"
+	if user, err := c.UpdateProfile(ctx, "alice", ProfileUpdate{Bio: &bio}); err != nil || user.Bio != bio {
+		t.Errorf("Ожидали обновленный профиль, получили %+v (%v)", user, err)
+	}
+	if _, err := c.GetAvatar(ctx, "alice", 0); !errors.Is(err, ErrAvatarNotFound) {
+		t.Errorf("Ожидали ErrAvatarNotFound, получили %v", err)
+	}
+	if _, err := c.UploadAvatar(ctx, "alice", []byte("не картинка")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("Ожидали ErrUnsupportedImage, получили %v", err)
+	}
+	if user, err := c.UploadAvatar(ctx, "alice", png1.Bytes()); err != nil || user.Avatar == nil {
+		t.Fatalf("Ошибка загрузки аватара: %+v (%v)", user, err)
+	}
+	if img, err := c.GetAvatar(ctx, "alice", 64); err != nil || img.ContentType != "image/png" || len(img.Data) == 0 {
+		t.Errorf("Ожидали PNG-аватар, получили %v", err)
+	}
+	if err := c.DeleteAvatar(ctx, "alice"); err != nil {
+		t.Errorf("Ошибка удаления аватара: %v", err)
+	}
+
+	// Тест 2: изображения в посте
+	media, err := c.UploadMedia(ctx, "alice", png1.Bytes(), png1.Bytes())
+	if err != nil || len(media) != 2 {
+		t.Fatalf("Ожидали 2 изображения, получили %d (%v)", len(media), err)
+	}
+	post, err := c.CreatePost(ctx, CreatePostRequest{Username: "alice", Content: "фото для @bob", MediaIDs: []int{media[0].ID}})
+	if err != nil || len(post.Attachments) != 1 {
+		t.Fatalf("Ожидали пост с вложением, получили %+v (%v)", post, err)
+	}
+	if _, err := c.GetMedia(ctx, media[1].ID, MediaOptions{}); !errors.Is(err, ErrMediaNotFound) {
+		t.Errorf("Неприкрепленное изображение доступно только владельцу, получили %v", err)
+	}
+	if img, err := c.GetMedia(ctx, media[1].ID, MediaOptions{AccessKey: media[1].AccessKey}); err != nil || len(img.Data) == 0 {
+		t.Errorf("Ошибка получения изображения владельцем: %v", err)
+	}
+	if img, err := c.GetMedia(ctx, media[0].ID, MediaOptions{Thumbnail: true}); err != nil || len(img.Data) == 0 {
+		t.Errorf("Ошибка получения миниатюры: %v", err)
+	}
+	if _, err := c.GetMedia(ctx, 999, MediaOptions{}); !errors.Is(err, ErrMediaNotFound) {
+		t.Errorf("Ожидали ErrMediaNotFound, получили %v", err)
+	}
+
+	// Тест 3: уведомление об упоминании и настройки
+	list, err := c.Notifications(ctx, "bob", NotificationOptions{UnreadOnly: true})
+	if err != nil || list.Unread != 1 || list.Notifications[0].Type != NotificationMention {
+		t.Fatalf("Ожидали уведомление об упоминании, получили %+v (%v)", list, err)
+	}
+	if unread, err := c.MarkNotificationsRead(ctx, "bob"); err != nil || unread != 0 {
+		t.Errorf("Ожидали 0 непрочитанных, получили %d (%v)", unread, err)
+	}
+	prefs, err := c.SetNotificationPreferences(ctx, "bob", map[string]bool{NotificationLike: false})
+	if err != nil || prefs[NotificationLike] || !prefs[NotificationMention] {
+		t.Errorf("Неверные настройки уведомлений %v (%v)", prefs, err)
+	}
+
+	// Тест 4: подписка, списки подписчиков и подписок, уведомление о подписке
+	if err := c.Follow(ctx, "alice", "alice"); !errors.Is(err, ErrFollowSelf) {
+		t.Errorf("Ожидали ErrFollowSelf, получили %v", err)
+	}
+	if err := c.Follow(ctx, "alice", "bob"); err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+	if users, err := c.Followers(ctx, "alice", ListUsersOptions{}); err != nil || users.Total != 1 || users.Users[0].Username != "bob" {
+		t.Errorf("Ожидали подписчика bob, получили %+v (%v)", users, err)
+	}
+	if users, err := c.Following(ctx, "bob", ListUsersOptions{}); err != nil || users.Total != 1 || users.Users[0].Username != "alice" {
+		t.Errorf("Ожидали подписку на alice, получили %+v (%v)", users, err)
+	}
+	if list, err := c.Notifications(ctx, "alice", NotificationOptions{}); err != nil || list.Total != 1 || list.Notifications[0].Type != NotificationFollow {
+		t.Errorf("Ожидали уведомление о подписке, получили %+v (%v)", list, err)
+	}
+	if err := c.Unfollow(ctx, "alice", "bob"); err != nil {
+		t.Errorf("Ошибка отписки: %v", err)
+	}
+	if users, err := c.Followers(ctx, "alice", ListUsersOptions{}); err != nil || users.Total != 0 {
+		t.Errorf("Ожидали пустой список подписчиков, получили %+v (%v)", users, err)
+	}
+
+	// Тест 5: без очереди доставки вебхуки отключены
+	if _, err := c.CreateWebhook(ctx, "alice", WebhookRequest{URL: "https://example.com/hook", Events: []string{EventPostCreated}}); !errors.Is(err, ErrWebhooksDisabled) {
+		t.Errorf("Ожидали ErrWebhooksDisabled, получили %v", err)
+	}
+
+	// Тест 6: удаление аккаунта и журнал аудита требуют токен администратора
+	var apiErr *APIError
+	if _, err := c.DeleteUser(ctx, "bob", AnonymizePosts); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Ожидали 401 при удалении без токена, получили %v", err)
+	}
+	if _, err := c.ListAudit(ctx, ListAuditOptions{}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Ожидали 401 без токена, получили %v", err)
+	}
+	admin := New(srv.URL, WithRetries(0, 0), WithAdminToken("secret"))
+	if _, err := admin.DeleteUser(ctx, "bob", AnonymizePosts); err != nil {
+		t.Fatalf("Ошибка удаления пользователя: %v", err)
+	}
+	if audit, err := admin.ListAudit(ctx, ListAuditOptions{}); err != nil || audit.Total != 1 || audit.Entries[0].Mode != AnonymizePosts {
+		t.Errorf("Ожидали запись об удалении bob, получили %+v (%v)", audit, err)
+	}
+}
+
+// TestErrorsMatchServer проверяет, что тексты ошибок клиента совпадают
+// с серверными: по ним клиент распознает ошибку
+func TestErrorsMatchServer(t *testing.T) {
+	pairs := []struct{ client, server error }{
+		{ErrEmptyUsername, service.ErrEmptyUsername}, {ErrUserExists, service.ErrUserExists},
+		{ErrUserNotFound, service.ErrUserNotFound}, {ErrUsernameReserved, service.ErrUsernameReserved},
+		{ErrEmptyContent, service.ErrEmptyContent}, {ErrPostNotFound, service.ErrPostNotFound},
+		{ErrForbidden, service.ErrForbidden}, {ErrInvalidInput, service.ErrInvalidInput},
+		{ErrParentNotFound, service.ErrParentNotFound}, {ErrOriginalNotFound, service.ErrOriginalNotFound},
+		{ErrAlreadyReposted, service.ErrAlreadyReposted}, {ErrRepostOwnPost, service.ErrRepostOwnPost},
+		{ErrNotEditable, service.ErrNotEditable}, {ErrEmptyQuery, service.ErrEmptyQuery},
+		{ErrFollowSelf, service.ErrFollowSelf},
+		{ErrWebhookNotFound, service.ErrWebhookNotFound}, {ErrWebhooksDisabled, service.ErrWebhooksDisabled},
+		{ErrTooManyWebhooks, service.ErrTooManyWebhooks}, {ErrUploadsDisabled, service.ErrUploadsDisabled},
+		{ErrAvatarNotFound, service.ErrAvatarNotFound}, {ErrUnsupportedImage, service.ErrUnsupportedImage},
+		{ErrImageTooLarge, service.ErrImageTooLarge}, {ErrCorruptImage, service.ErrCorruptImage},
+		{ErrMediaNotFound, service.ErrMediaNotFound}, {ErrMediaAttached, service.ErrMediaAttached},
+		{ErrAttachmentNotFound, service.ErrAttachmentNotFound}, {ErrNoMedia, service.ErrNoMedia},
+		{ErrTooManyAttachments, service.ErrTooManyAttachments}, {ErrTooManyPendingMedia, service.ErrTooManyPendingMedia},
+	}
+	for _, p := range pairs {
+		if p.client.Error() != p.server.Error() {
+			t.Errorf("Текст ошибки клиента %q не совпадает с серверным %q", p.client, p.server)
+		}
+	}
+}
+
+// TestNoInternalImports проверяет, что клиент не зависит от внутренних пакетов сервера
+func TestNoInternalImports(t *testing.T) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ImportsOnly)
+	if err != nil {
+		t.Fatalf("Ошибка разбора пакета: %v", err)
+	}
+	for _, pkg := range pkgs {
+		for name, f := range pkg.Files {
+			for _, imp := range f.Imports {
+				if strings.Contains(imp.Path.Value, "/internal/") {
+					t.Errorf("%s импортирует %s", name, imp.Path.Value)
+				}
+			}
+		}
+	}
+}
+
+// TestClientRetries проверяет повторы при временных ошибках
+func TestClientRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "перегрузка", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	// Тест 1: GET повторяется до успеха
+	c := New(srv.URL, WithRetries(3, time.Millisecond))
+	if _, err := c.ListPosts(ctx); err != nil {
+		t.Fatalf("Ожидали успех после повторов, получили %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Ожидали 3 попытки, получили %d", n)
+	}
+
+	// Тест 2: после исчерпания повторов возвращается последняя ошибка
+	calls.Store(0)
+	c = New(srv.URL, WithRetries(1, time.Millisecond))
+	if _, err := c.ListPosts(ctx); !IsRetryable(err) {
+		t.Errorf("Ожидали временную ошибку, получили %v", err)
+	}
+
+	// Тест 3: 502 для POST не повторяется - запрос мог быть выполнен
+	calls.Store(0)
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "шлюз", http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	c = New(bad.URL, WithRetries(3, time.Millisecond))
+	if err := c.LikePost(ctx, 1, "alice"); err == nil {
+		t.Error("Ожидали ошибку")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("POST не должен повторяться при 502, попыток: %d", n)
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Ошибки сервера, которые можно проверить через errors.Is на ошибке клиента.
+// Сервер отдает текст ошибки в теле ответа, по нему ошибка и распознается,
+// поэтому тексты должны совпадать с серверными.
+var (
+	ErrEmptyUsername    = errors.New("имя пользователя не может быть пустым")
+	ErrUserExists       = errors.New("пользователь уже существует")
+	ErrUserNotFound     = errors.New("пользователь не найден")
+	ErrUsernameReserved = errors.New("имя недавно освобождено и пока недоступно")
+	ErrEmptyContent     = errors.New("содержимое поста не может быть пустым")
+	ErrPostNotFound     = errors.New("пост не найден")
+	ErrForbidden        = errors.New("недостаточно прав для этого действия")
+	// ErrInvalidInput - ввод не прошел проверку; подробности в APIError.Fields
+	ErrInvalidInput     = errors.New("значение не прошло проверку")
+	ErrParentNotFound   = errors.New("пост, на который дается ответ, не найден")
+	ErrOriginalNotFound = errors.New("исходный пост для репоста или цитаты не найден")
+	ErrAlreadyReposted  = errors.New("пост уже репостнут этим пользователем")
+	ErrRepostOwnPost    = errors.New("нельзя репостнуть собственный пост")
+	ErrNotEditable      = errors.New("репост нельзя редактировать")
+	ErrFollowSelf       = errors.New("нельзя подписаться на самого себя")
+	ErrEmptyQuery       = errors.New("поисковый запрос не содержит значимых слов")
+
+	ErrWebhookNotFound  = errors.New("вебхук не найден")
+	ErrWebhooksDisabled = errors.New("вебхуки отключены")
+	ErrTooManyWebhooks  = errors.New("превышено число вебхуков пользователя")
+
+	ErrUploadsDisabled  = errors.New("загрузка файлов отключена")
+	ErrAvatarNotFound   = errors.New("аватар не загружен")
+	ErrUnsupportedImage = errors.New("формат изображения не поддерживается: ожидается JPEG, PNG или GIF")
+	ErrImageTooLarge    = errors.New("изображение больше 8192×8192 или 40000000 пикселей")
+	ErrCorruptImage     = errors.New("изображение повреждено")
+
+	ErrMediaNotFound       = errors.New("изображение не найдено")
+	ErrMediaAttached       = errors.New("изображение уже прикреплено к посту")
+	ErrAttachmentNotFound  = errors.New("прикрепляемое изображение не найдено")
+	ErrNoMedia             = errors.New("не передано ни одного изображения")
+	ErrTooManyAttachments  = errors.New("к посту можно прикрепить не больше 4 изображений")
+	ErrTooManyPendingMedia = errors.New("больше 20 загруженных, но не прикрепленных изображений")
+)
+
+// knownErrors - ошибки сервера по тексту, который он отдает в теле ответа
+var knownErrors = func() map[string]error {
+	m := make(map[string]error)
+	for _, err := range []error{
+		ErrEmptyUsername, ErrUserExists, ErrUserNotFound, ErrEmptyContent, ErrPostNotFound,
+		ErrForbidden, ErrParentNotFound, ErrOriginalNotFound, ErrAlreadyReposted,
+		ErrRepostOwnPost, ErrNotEditable, ErrFollowSelf, ErrEmptyQuery, ErrUsernameReserved,
+		ErrWebhookNotFound, ErrWebhooksDisabled, ErrTooManyWebhooks,
+		ErrUploadsDisabled, ErrAvatarNotFound, ErrUnsupportedImage, ErrImageTooLarge, ErrCorruptImage,
+		ErrMediaNotFound, ErrMediaAttached, ErrAttachmentNotFound, ErrNoMedia,
+		ErrTooManyAttachments, ErrTooManyPendingMedia,
+	} {
+		m[err.Error()] = err
+	}
+	return m
+}()
+
+// FieldError - нарушение правила проверки для одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// APIError - ответ сервера с кодом ошибки
+type APIError struct {
+	StatusCode int
+	Message    string
+	Fields     []*FieldError // заполнено для ошибок проверки ввода
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Unwrap позволяет сравнивать ошибку с ошибками сервиса через errors.Is
+func (e *APIError) Unwrap() []error {
+	var out []error
+	if err, ok := knownErrors[e.Message]; ok {
+		out = append(out, err)
+	}
+	for _, f := range e.Fields {
+		if err, ok := knownErrors[f.Message]; ok {
+			out = append(out, err)
+		} else {
+			out = append(out, ErrInvalidInput)
+		}
+	}
+	return out
+}
+
+// IsRetryable сообщает, имеет ли смысл повторить запрос позже
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
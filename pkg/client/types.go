@@ -0,0 +1,271 @@
+package client
+
+import "time"
+
+// Типы ответов API. Повторяют JSON сервера и не зависят от его внутренних
+// пакетов: клиент можно обновлять отдельно от сервера, пока формат тот же.
+
+// User - пользователь с профилем
+type User struct {
+	ID          int       `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Bio         string    `json:"bio,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Website     string    `json:"website,omitempty"`
+	Avatar      *Avatar   `json:"avatar,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Avatar - сведения о загруженном аватаре
+type Avatar struct {
+	Version     int64     `json:"version"`
+	ContentType string    `json:"content_type"`
+	Sizes       []int     `json:"sizes"` // сторона квадрата в пикселях, по возрастанию
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserView - пользователь со счетчиками активности
+type UserView struct {
+	*User
+	PostCount     int `json:"post_count"`
+	LikesReceived int `json:"likes_received"`
+	LikesGiven    int `json:"likes_given"`
+}
+
+// UserList - страница пользователей в порядке регистрации
+type UserList struct {
+	Total  int     `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	Users  []*User `json:"users"`
+}
+
+// Режимы удаления аккаунта для DeleteUser
+const (
+	DeletePosts    = "delete"    // посты удаляются вместе с репостами
+	AnonymizePosts = "anonymize" // посты остаются без автора, репосты удаляются
+)
+
+// AuditEntry - запись журнала аудита
+type AuditEntry struct {
+	ID              int       `json:"id"`
+	Action          string    `json:"action"`
+	UserID          int       `json:"user_id"`
+	Mode            string    `json:"mode"`
+	PostsDeleted    int       `json:"posts_deleted"`
+	PostsAnonymized int       `json:"posts_anonymized"`
+	LikesRemoved    int       `json:"likes_removed"`
+	MediaRemoved    int       `json:"media_removed"`
+	WebhooksRemoved int       `json:"webhooks_removed"`
+	TraceID         string    `json:"trace_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// AuditList - страница журнала аудита, новые записи первыми
+type AuditList struct {
+	Total   int           `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+	Entries []*AuditEntry `json:"entries"`
+}
+
+// Виды постов
+const (
+	PostKindPost   = "post"
+	PostKindRepost = "repost"
+	PostKindQuote  = "quote"
+)
+
+// Post - пост, ответ, репост или цитата
+type Post struct {
+	ID          int       `json:"id"`
+	Kind        string    `json:"kind"`
+	AuthorID    int       `json:"author_id"`
+	Author      string    `json:"author"`
+	Content     string    `json:"content"`
+	Likes       []string  `json:"likes"`
+	ReplyToID   int       `json:"reply_to_id,omitempty"`
+	ReplyCount  int       `json:"reply_count"`
+	OriginalID  int       `json:"original_id,omitempty"`
+	RepostCount int       `json:"repost_count"`
+	QuoteCount  int       `json:"quote_count"`
+	Hashtags    []string  `json:"hashtags,omitempty"`
+	Mentions    []string  `json:"mentions,omitempty"`
+	Attachments []Media   `json:"attachments,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PostView - пост для ленты: репост и цитата несут встроенный оригинал
+type PostView struct {
+	*Post
+	Original *Post `json:"original,omitempty"`
+}
+
+// PostRevision - версия текста поста
+type PostRevision struct {
+	PostID    int       `json:"post_id"`
+	Version   int       `json:"version"`
+	Content   string    `json:"content"`
+	EditorID  int       `json:"editor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ThreadNode - пост и ответы на него в дереве обсуждения
+type ThreadNode struct {
+	Post        *Post         `json:"post"`
+	Replies     []*ThreadNode `json:"replies"`
+	MoreReplies int           `json:"more_replies,omitempty"`
+}
+
+// Thread - обсуждение целиком, начиная с корневого поста
+type Thread struct {
+	Root    *ThreadNode `json:"root"`
+	FocusID int         `json:"focus_id"`
+	Depth   int         `json:"depth"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+}
+
+// SearchHit - найденный пост и его релевантность
+type SearchHit struct {
+	Post  *Post   `json:"post"`
+	Score float64 `json:"score"`
+}
+
+// SearchResult - страница результатов поиска
+type SearchResult struct {
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+	Hits   []SearchHit `json:"hits"`
+}
+
+// TrendingTag - популярный хэштег
+type TrendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
+
+// TrendingPost - популярный пост
+type TrendingPost struct {
+	Post  *Post   `json:"post"`
+	Score float64 `json:"score"`
+}
+
+// Trending - популярные хэштеги и посты
+type Trending struct {
+	Hashtags []TrendingTag  `json:"hashtags"`
+	Posts    []TrendingPost `json:"posts"`
+}
+
+// Media - загруженное изображение
+type Media struct {
+	ID          int       `json:"id"`
+	OwnerID     int       `json:"owner_id"`
+	Owner       string    `json:"owner"`
+	PostID      int       `json:"post_id,omitempty"` // 0 - еще не прикреплено к посту
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int       `json:"size"`
+	ThumbWidth  int       `json:"thumb_width"`
+	ThumbHeight int       `json:"thumb_height"`
+	CreatedAt   time.Time `json:"created_at"`
+	AccessKey   string    `json:"access_key,omitempty"` // только в ответе UploadMedia
+}
+
+// Image - файл изображения (аватар или вложение)
+type Image struct {
+	ContentType string
+	Data        []byte
+}
+
+// Типы событий вебхуков
+const (
+	EventPostCreated = "post.created"
+	EventPostLiked   = "post.liked"
+	EventPostDeleted = "post.deleted"
+)
+
+// Webhook - подписка на события о постах
+type Webhook struct {
+	ID             int       `json:"id"`
+	OwnerID        int       `json:"owner_id"`
+	Owner          string    `json:"owner"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	Users          []string  `json:"users,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	Secret         string    `json:"secret,omitempty"` // только в ответе на создание
+	Active         bool      `json:"active"`
+	Failures       int       `json:"consecutive_failures"`
+	DisabledReason string    `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// WebhookAttempt - попытка доставки события вебхуку
+type WebhookAttempt struct {
+	DeliveryID int           `json:"delivery_id"`
+	WebhookID  int           `json:"webhook_id"`
+	Event      string        `json:"event"`
+	Attempt    int           `json:"attempt"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Success    bool          `json:"success"`
+	Duration   time.Duration `json:"duration_ns"`
+	At         time.Time     `json:"at"`
+}
+
+// Типы уведомлений, они же ключи настроек
+const (
+	NotificationLike    = "like"
+	NotificationMention = "mention"
+	NotificationFollow  = "follow"
+)
+
+// Notification - уведомление пользователя
+type Notification struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Type      string    `json:"type"`
+	PostID    int       `json:"post_id"` // 0 - уведомление о подписке
+	Actors    []string  `json:"actors"`  // последние участники, новые первыми
+	Count     int       `json:"count"`   // всего участников
+	Text      string    `json:"text"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationList - страница уведомлений, новые первыми
+type NotificationList struct {
+	Unread        int             `json:"unread"` // непрочитанных всего, независимо от страницы
+	Total         int             `json:"total"`
+	Limit         int             `json:"limit"`
+	Offset        int             `json:"offset"`
+	Notifications []*Notification `json:"notifications"`
+}
+
+// QueueStats - состояние очереди сервера
+type QueueStats struct {
+	Pending     int           `json:"pending"`
+	Capacity    int           `json:"capacity"`
+	Workers     int           `json:"workers"`
+	Running     int           `json:"running"`
+	Processed   uint64        `json:"processed"`
+	AvgLatency  time.Duration `json:"avg_latency_ns"`
+	Delayed     int           `json:"delayed"`
+	DeadLetters int           `json:"dead_letters"`
+	DeadDropped uint64        `json:"dead_dropped"`
+}
+
+// Route - маршрут API из GET /routes
+type Route struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Summary string `json:"summary"`
+}